@@ -45,22 +45,15 @@ func TokenAuthMiddleware() gin.HandlerFunc {
 }
 func RequireRole(role string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		claims, exists := c.Get("claims")
+		// role diisi oleh TokenAuthMiddleware dari klaim token
+		userRole, exists := c.Get("role")
 		if !exists {
 			c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
 			c.Abort()
 			return
 		}
 
-		mapClaims, ok := claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
-			c.Abort()
-			return
-		}
-
-		userRole, ok := mapClaims["role"].(string)
-		if !ok || userRole != role {
+		if roleStr, ok := userRole.(string); !ok || roleStr != role {
 			c.JSON(http.StatusForbidden, gin.H{"message": "Forbidden"})
 			c.Abort()
 			return
@@ -18,6 +18,26 @@ func main() {
 	initializers.DB.AutoMigrate(
 		&models.File{},
 		&models.Notification{},
+		&models.NotificationRecipient{},
+		&models.ShareLink{},
+		&models.ShareLinkAccess{},
+		&models.StorageQuota{},
+		&models.CalendarFeedToken{},
+		&models.ICSImport{},
+		&models.Holiday{},
+		&models.NotificationJob{},
+		&models.NotificationSetting{},
+		&models.NotificationPreference{},
+		&models.DomainEvent{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
 	)
 
+	// File yang diunggah sebelum pemindaian virus ada mendapat default pending dari kolom scan_status dan tidak
+	// pernah dipindai (scanned_at kosong). Upload baru selalu dipindai sebelum disimpan, jadi hanya file lama
+	// yang cocok. Tandai legacy agar tetap bisa diunduh, lalu pindai lewat POST /quarantine/scan.
+	initializers.DB.Table("common.files").
+		Where("scan_status = ? AND scanned_at IS NULL", models.ScanPending).
+		Update("scan_status", models.ScanLegacy)
+
 }
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type File struct {
 	ID          uint      `gorm:"primaryKey"`     // ID unik untuk file
@@ -11,6 +14,26 @@ type File struct {
 	FileName    string    `gorm:"not null"`       // Nama file asli
 	ContentType string    `gorm:"not null"`       // Jenis konten file, misal 'application/pdf'
 	Size        int64     `gorm:"not null"`       // Ukuran file dalam byte
+	Checksum    string    `gorm:"size:64"`        // SHA-256 (hex) dari isi file
+	UploadedBy  string    `gorm:"index"`          // Username yang mengunggah file
+
+	ScanStatus     string     `gorm:"default:pending;index"` // pending, clean, infected, atau error
+	ScanResult     string     // Nama signature virus atau pesan error dari scanner
+	ScannedAt      *time.Time // Waktu pemindaian terakhir
+	QuarantinePath string     // Lokasi file jika dikarantina
+}
+
+const (
+	ScanPending  = "pending"
+	ScanClean    = "clean"
+	ScanInfected = "infected"
+	ScanError    = "error"
+	ScanLegacy   = "legacy" // Diunggah sebelum pemindaian virus ada, tetap bisa diunduh sampai dipindai ulang
+)
+
+// Downloadable mengembalikan true jika file boleh diunduh: bersih, atau file lama yang belum dipindai
+func (f File) Downloadable() bool {
+	return f.ScanStatus == ScanClean || f.ScanStatus == ScanLegacy
 }
 
 func (File) Tablefiles() string {
@@ -18,12 +41,241 @@ func (File) Tablefiles() string {
 }
 
 type Notification struct {
-	ID       uint      `gorm:"primaryKey" json:"id"`
-	Title    string    `json:"title"`
-	Start    time.Time `json:"start"`
-	Category string    `json:"category"`
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	Title      string    `json:"title"`
+	Start      time.Time `json:"start"`
+	Category   string    `json:"category"`
+	SourceType string    `gorm:"index:idx_notification_source" json:"source_type,omitempty"` // Jenis resource sumber, misal BookingRapat atau Memo
+	SourceID   uint      `gorm:"index:idx_notification_source" json:"source_id,omitempty"`
+	Link       string    `json:"link,omitempty"` // Path API resource sumber jika ada
+}
+
+// NotificationRecipient menyimpan status baca notifikasi untuk satu pengguna
+type NotificationRecipient struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	NotificationID uint       `gorm:"uniqueIndex:idx_notification_recipient;not null" json:"notification_id"`
+	Username       string     `gorm:"uniqueIndex:idx_notification_recipient;index;not null" json:"username"`
+	ReadAt         *time.Time `json:"read_at,omitempty"`
+	DismissedAt    *time.Time `json:"dismissed_at,omitempty"` // Dihapus dari inbox pengguna ini saja
 }
 
 func (File) TableNotification() string {
     return "common.notifications"  // Menentukan schema.table_name
 }
+
+type ShareLink struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	Token         string     `gorm:"uniqueIndex;not null" json:"-"` // Token acak, dikirim bersama signature di URL
+	Resource      string     `gorm:"not null" json:"resource"`      // Nama resource, misal 'sk' atau 'suratmasuk'
+	BaseDir       string     `gorm:"not null" json:"-"`             // Direktori upload milik resource
+	RecordID      uint       `gorm:"index" json:"record_id"`
+	DocNumber     string     `json:"doc_number"`
+	FileName      string     `json:"file_name"` // Kosong berarti ZIP seluruh lampiran record
+	PasswordHash  string     `json:"-"`
+	HasPassword   bool       `json:"has_password"`
+	ExpiresAt     time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+	DownloadCount int        `gorm:"default:0" json:"download_count"`
+	CreateBy      string     `json:"create_by"`
+}
+
+type ShareLinkAccess struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ShareLinkID uint      `gorm:"index" json:"share_link_id"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"user_agent"`
+	Success     bool      `json:"success"`
+	Reason      string    `json:"reason"` // Alasan penolakan, kosong jika berhasil
+}
+
+// StorageQuota membatasi total ukuran lampiran per resource (lihat utils.StorageResources)
+type StorageQuota struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Resource       string    `gorm:"uniqueIndex;not null" json:"resource"`
+	LimitBytes     int64     `gorm:"not null" json:"limit_bytes"`
+	WarningPercent int       `gorm:"default:80" json:"warning_percent"` // Ambang peringatan dalam persen dari kuota
+	UpdateBy       string    `json:"update_by"`
+}
+
+// CalendarFeedToken memberi akses feed iCalendar per user tanpa JWT, token asli hanya ditampilkan sekali
+type CalendarFeedToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UserID     uint       `gorm:"index" json:"user_id"`
+	Username   string     `json:"username"`
+	TokenHash  string     `gorm:"uniqueIndex;size:64" json:"-"` // SHA-256 (hex) dari token
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// ICSImport menyimpan hasil preview import file .ics sampai dikonfirmasi
+type ICSImport struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Target    string    `gorm:"index" json:"target"` // bookingRapat, jadwalRapat, meetingSchedule
+	FileName  string    `json:"file_name"`
+	Events    string    `gorm:"type:text" json:"-"` // JSON []ICSImportEvent hasil preview
+	Status    string    `gorm:"default:preview" json:"status"` // preview, imported
+	Imported  int       `json:"imported"`
+	Skipped   int       `json:"skipped"`
+	CreateBy  string    `json:"create_by"`
+}
+
+// Holiday adalah hari libur nasional atau cuti bersama, tidak dihitung sebagai hari kerja
+type Holiday struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	Date      time.Time `gorm:"type:date;uniqueIndex" json:"date"`
+	Name      string    `json:"name"`
+	CreateBy  string    `json:"create_by"`
+}
+
+// MarshalJSON menampilkan Date sebagai tanggal tanpa jam
+func (h Holiday) MarshalJSON() ([]byte, error) {
+	type Alias Holiday
+	return json.Marshal(&struct {
+		Date string `json:"date"`
+		Alias
+	}{
+		Date:  h.Date.Format("2006-01-02"),
+		Alias: Alias(h),
+	})
+}
+
+// Status NotificationJob
+const (
+	NotificationJobPending    = "pending"
+	NotificationJobProcessing = "processing"
+	NotificationJobSent       = "sent"
+	NotificationJobFailed     = "failed"    // Percobaan habis
+	NotificationJobCancelled  = "cancelled" // Event sumber berubah atau dihapus
+	NotificationJobSkipped    = "skipped"   // Event sudah dimulai atau tidak ada penerima saat job diproses
+	NotificationJobDigest     = "digest"    // Menunggu dikirim dalam ringkasan harian penerima
+	NotificationJobDigested   = "digested"  // Sudah digabung ke job ringkasan harian
+)
+
+// Jenis NotificationJob
+const (
+	NotificationKindReminder = "reminder" // Pengingat sebelum event, diperiksa ulang ke event sumber
+	NotificationKindMessage  = "message"  // Pesan sekali kirim, misal hasil persetujuan atau penugasan dokumen
+)
+
+// NotificationJob adalah pengingat terjadwal untuk sebuah event, dikirim worker lewat satu channel
+type NotificationJob struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	Kind          string     `gorm:"default:reminder" json:"kind"`
+	Category      string     `gorm:"index:idx_notification_job_source" json:"category"` // Jenis sumber, misal JadwalRapat
+	SourceID      uint       `gorm:"index:idx_notification_job_source" json:"source_id"`
+	Title         string     `json:"title"`
+	EventStart    time.Time  `json:"event_start"`
+	OffsetMinutes int        `json:"offset_minutes"` // Menit sebelum EventStart
+	Channel       string     `gorm:"index" json:"channel"`
+	Recipient     string     `json:"recipient,omitempty"`                // Username, PIC, atau alamat email
+	Payload       string     `gorm:"type:text" json:"payload,omitempty"` // Data template pesan dalam JSON
+	RunAt         time.Time  `gorm:"index" json:"run_at"`
+	Status        string     `gorm:"index;default:pending" json:"status"`
+	Attempts      int        `json:"attempts"`
+	MaxAttempts   int        `json:"max_attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	LockedBy      string     `json:"locked_by,omitempty"`
+	LockedAt      *time.Time `json:"locked_at,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
+
+// Mode NotificationPreference
+const (
+	NotificationModeInstant = "instant" // Dikirim saat jatuh tempo
+	NotificationModeDigest  = "digest"  // Digabung ke ringkasan harian, hanya untuk pesan (bukan pengingat)
+	NotificationModeOff     = "off"     // Tidak dikirim
+)
+
+// NotificationSetting adalah pengaturan notifikasi seorang pengguna yang berlaku untuk semua kategori
+type NotificationSetting struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Username        string    `gorm:"uniqueIndex;not null" json:"username"`
+	ReminderOffsets string    `json:"reminder_offsets"`  // Misal "24h,1h", kosong memakai NOTIFICATION_OFFSETS
+	QuietHoursStart string    `json:"quiet_hours_start"` // Jam WIB "22:00", kosong berarti tanpa jam tenang
+	QuietHoursEnd   string    `json:"quiet_hours_end"`
+	DailyDigest     bool      `json:"daily_digest"` // Pesan prioritas rendah digabung ke ringkasan harian
+	DigestTime      string    `json:"digest_time"`  // Jam WIB pengiriman ringkasan, default "08:00"
+	WebhookURL      string    `json:"webhook_url"`  // Tujuan channel webhook pengguna ini
+}
+
+// NotificationPreference menimpa mode pengiriman satu kategori di satu channel untuk seorang pengguna
+type NotificationPreference struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Username  string    `gorm:"uniqueIndex:idx_notification_preference;not null" json:"username"`
+	Category  string    `gorm:"uniqueIndex:idx_notification_preference;not null" json:"category"`
+	Channel   string    `gorm:"uniqueIndex:idx_notification_preference;not null" json:"channel"` // inapp, email, atau webhook
+	Mode      string    `gorm:"not null" json:"mode"`
+}
+
+// DomainEvent dicatat dalam transaksi yang sama dengan perubahan data (outbox), lalu disebar ke webhook
+type DomainEvent struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	EventID      string     `gorm:"uniqueIndex;not null" json:"event_id"` // UUID, dipakai penerima untuk deduplikasi
+	Type         string     `gorm:"index;not null" json:"type"`           // <resource>.<action>, misal surat_masuk.created
+	Service      string     `json:"service"`
+	Resource     string     `json:"resource"`
+	ResourceID   string     `gorm:"index" json:"resource_id,omitempty"`
+	Action       string     `json:"action"`
+	Data         string     `gorm:"type:text" json:"data,omitempty"` // JSON data resource, field rahasia dibuang
+	DispatchedAt *time.Time `gorm:"index" json:"dispatched_at,omitempty"`
+}
+
+// WebhookSubscription adalah URL penerima domain event
+type WebhookSubscription struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Name       string    `json:"name"`
+	URL        string    `gorm:"not null" json:"url"`
+	Secret     string    `gorm:"not null" json:"-"`           // Kunci HMAC, hanya ditampilkan saat dibuat
+	EventTypes string    `gorm:"not null" json:"event_types"` // Dipisah koma, misal "surat_masuk.created,project.*" atau "*"
+	Active     bool      `gorm:"not null" json:"active"`
+	CreateBy   string    `json:"create_by"`
+}
+
+// Status WebhookDelivery
+const (
+	WebhookDeliveryPending    = "pending"
+	WebhookDeliveryProcessing = "processing"
+	WebhookDeliveryDelivered  = "delivered"
+	WebhookDeliveryFailed     = "failed" // Percobaan habis
+)
+
+// WebhookDelivery adalah pengiriman satu domain event ke satu WebhookSubscription beserta hasil percobaan terakhirnya
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	SubscriptionID uint       `gorm:"index;not null" json:"subscription_id"`
+	EventID        string     `gorm:"index" json:"event_id"`
+	EventType      string     `gorm:"index" json:"event_type"`
+	Payload        string     `gorm:"type:text" json:"payload"` // Body JSON yang ditandatangani
+	Status         string     `gorm:"index;default:pending" json:"status"`
+	Attempts       int        `json:"attempts"`
+	MaxAttempts    int        `json:"max_attempts"`
+	NextAttemptAt  time.Time  `gorm:"index" json:"next_attempt_at"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	ResponseBody   string     `gorm:"type:text" json:"response_body,omitempty"` // Dipotong 2 KB
+	DurationMs     int64      `json:"duration_ms,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LockedBy       string     `json:"locked_by,omitempty"`
+	LockedAt       *time.Time `json:"locked_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	RedeliveryOf   *uint      `json:"redelivery_of,omitempty"` // Pengiriman asal jika dikirim ulang manual
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	"github.com/arkaramadhan/its-vo/common/utils"
+)
+
+func init() {
+
+	initializers.LoadEnvVariables()
+	initializers.ConnectToDB("common")
+
+}
+
+func main() {
+
+	apply := flag.Bool("apply", false, "perbaiki inkonsistensi (default hanya dry-run)")
+	resource := flag.String("resource", "", "hanya periksa resource tertentu, misal 'sk'")
+	flag.Parse()
+
+	resources := utils.StorageResources
+	if *resource != "" {
+		found, ok := utils.FindStorageResource(*resource)
+		if !ok {
+			log.Fatalf("resource tidak dikenal: %s", *resource)
+		}
+		resources = []utils.StorageResource{found}
+	}
+
+	report, err := utils.ReconcileStorage(resources, *apply)
+	if err != nil {
+		log.Fatalf("Reconcile gagal: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("Gagal menulis laporan: %v", err)
+	}
+
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	"github.com/arkaramadhan/its-vo/common/models"
+	"github.com/gin-gonic/gin"
+)
+
+// CalendarFeeds adalah nama feed yang tersedia di /ics/:token/<nama>.ics
+var CalendarFeeds = []string{
+	"bookingRapat", "jadwalRapat", "jadwalCuti", "timelineDesktop", // kegiatan-service
+	"timelineProject", "meetingSchedule", // weeklyTimeline-service
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func feedURLs(token string) map[string]string {
+	urls := make(map[string]string, len(CalendarFeeds))
+	for _, feed := range CalendarFeeds {
+		urls[feed] = fmt.Sprintf("/ics/%s/%s.ics", token, feed)
+	}
+	return urls
+}
+
+// CreateCalendarFeedToken membuat token feed baru untuk user yang login dan mencabut token lamanya
+func CreateCalendarFeedToken(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	token, err := generateShareToken()
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal membuat token")
+		return
+	}
+
+	if err := initializers.DB.Table("common.calendar_feed_tokens").
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal mencabut token lama: "+err.Error())
+		return
+	}
+
+	feedToken := models.CalendarFeedToken{
+		UserID:    userID,
+		Username:  c.GetString("username"),
+		TokenHash: hashFeedToken(token),
+	}
+	if err := initializers.DB.Table("common.calendar_feed_tokens").Create(&feedToken).Error; err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal menyimpan token: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "token feed kalender berhasil dibuat, simpan URL ini karena token tidak bisa ditampilkan lagi",
+		"token":   feedToken,
+		"urls":    feedURLs(token),
+	})
+}
+
+func GetCalendarFeedToken(c *gin.Context) {
+	var feedToken models.CalendarFeedToken
+	if err := initializers.DB.Table("common.calendar_feed_tokens").
+		Where("user_id = ? AND revoked_at IS NULL", c.MustGet("userID").(uint)).
+		First(&feedToken).Error; err != nil {
+		RespondError(c, http.StatusNotFound, "token feed kalender belum dibuat")
+		return
+	}
+	c.JSON(http.StatusOK, feedToken)
+}
+
+func RevokeCalendarFeedToken(c *gin.Context) {
+	result := initializers.DB.Table("common.calendar_feed_tokens").
+		Where("user_id = ? AND revoked_at IS NULL", c.MustGet("userID").(uint)).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal mencabut token: "+result.Error.Error())
+		return
+	}
+	if result.RowsAffected == 0 {
+		RespondError(c, http.StatusNotFound, "token feed kalender tidak ditemukan")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "token feed kalender berhasil dicabut"})
+}
+
+// CalendarFeedAuth memvalidasi token pada path /ics/:token/... untuk klien kalender yang tidak bisa mengirim JWT.
+// Dipasang sebelum TokenAuthMiddleware.
+func CalendarFeedAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var feedToken models.CalendarFeedToken
+		if err := initializers.DB.Table("common.calendar_feed_tokens").
+			Where("token_hash = ? AND revoked_at IS NULL", hashFeedToken(c.Param("token"))).
+			First(&feedToken).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Token feed tidak valid"})
+			return
+		}
+
+		if err := initializers.DB.Table("common.calendar_feed_tokens").Where("id = ?", feedToken.ID).
+			UpdateColumn("last_used_at", time.Now()).Error; err != nil {
+			log.Printf("Error updating calendar feed token: %v", err)
+		}
+
+		c.Set("calendarFeed", true)
+		c.Set("username", feedToken.Username)
+		c.Set("userID", feedToken.UserID)
+		c.Next()
+	}
+}
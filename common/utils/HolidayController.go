@@ -0,0 +1,363 @@
+package utils
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	"github.com/arkaramadhan/its-vo/common/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	holidayImportMaxSize = 1 << 20 // 1 MB
+	maxWorkingDaysRange  = 3660    // Kira-kira 10 tahun
+)
+
+// Format tanggal yang diterima pada import CSV
+var holidayDateLayouts = []string{EventDateLayout, "02/01/2006", "2/1/2006"}
+
+type HolidayRequest struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+// HolidayImportResult adalah ringkasan import hari libur satu tahun
+type HolidayImportResult struct {
+	Year     int              `json:"year"`
+	Imported int              `json:"imported"` // Baru atau diperbarui namanya
+	Removed  int              `json:"removed"`  // Dihapus karena replace=true dan tidak ada di file
+	Skipped  []string         `json:"skipped"`  // Baris yang dilewati beserta alasannya
+	Holidays []models.Holiday `json:"holidays"`
+}
+
+// GetHolidays mengembalikan hari libur, query year membatasi ke satu tahun
+func GetHolidays(c *gin.Context) {
+	query := initializers.DB.Table("common.holidays").Order("date")
+	if value := c.Query("year"); value != "" {
+		year, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "year tidak valid"})
+			return
+		}
+		query = query.Where("EXTRACT(YEAR FROM date) = ?", year)
+	}
+	var holidays []models.Holiday
+	if err := query.Find(&holidays).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, holidays)
+}
+
+func CreateHoliday(c *gin.Context) {
+	var holiday models.Holiday
+	if !bindHoliday(c, &holiday) {
+		return
+	}
+	holiday.CreateBy = c.GetString("username")
+	if err := initializers.DB.Table("common.holidays").Create(&holiday).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, holiday)
+}
+
+func UpdateHoliday(c *gin.Context) {
+	var holiday models.Holiday
+	if err := initializers.DB.Table("common.holidays").First(&holiday, "id = ?", c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Hari libur tidak ditemukan"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+	if !bindHoliday(c, &holiday) {
+		return
+	}
+	if err := initializers.DB.Table("common.holidays").Save(&holiday).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, holiday)
+}
+
+func DeleteHoliday(c *gin.Context) {
+	id := c.Param("id")
+	result := initializers.DB.Table("common.holidays").Where("id = ?", id).Delete(&models.Holiday{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Hari libur tidak ditemukan"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ImportHolidays membaca daftar hari libur satu tahun (query year) dari file .csv atau .ics pada field file.
+// CSV berisi kolom tanggal dan nama, baris judul boleh ada. Event .ics sepanjang hari dipecah per tanggal.
+// Tanggal yang sudah ada diperbarui namanya; dengan replace=true hari libur tahun itu yang tidak ada di file dihapus.
+func ImportHolidays(c *gin.Context) {
+	year, err := strconv.Atoi(c.Query("year"))
+	if err != nil || year < 1900 || year > 9999 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "year harus diisi dengan tahun yang valid"})
+		return
+	}
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "File .csv atau .ics wajib diunggah pada field file"})
+		return
+	}
+	defer file.Close()
+	if header.Size > holidayImportMaxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "Ukuran file maksimal 1 MB"})
+		return
+	}
+
+	result := HolidayImportResult{Year: year, Skipped: []string{}}
+	var parsed map[string]string
+	reader := io.LimitReader(file, holidayImportMaxSize)
+	switch strings.ToLower(filepath.Ext(header.Filename)) {
+	case ".csv":
+		parsed, err = parseHolidayCSV(reader, year, &result)
+	case ".ics":
+		parsed, err = parseHolidayICS(reader, year, &result)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "File harus berekstensi .csv atau .ics"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "File tidak valid: " + err.Error()})
+		return
+	}
+	if len(parsed) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Tidak ada hari libur tahun ini di file tersebut", "skipped": result.Skipped})
+		return
+	}
+
+	dates := make([]string, 0, len(parsed))
+	for date := range parsed {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		for _, date := range dates {
+			day, _ := time.Parse(EventDateLayout, date)
+			holiday := models.Holiday{Date: day, Name: parsed[date], CreateBy: c.GetString("username")}
+			if err := tx.Table("common.holidays").Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "date"}},
+				DoUpdates: clause.AssignmentColumns([]string{"name"}),
+			}).Create(&holiday).Error; err != nil {
+				return err
+			}
+			result.Imported++
+		}
+		if c.Query("replace") == "true" {
+			removed := tx.Table("common.holidays").
+				Where("EXTRACT(YEAR FROM date) = ? AND date::text NOT IN ?", year, dates).
+				Delete(&models.Holiday{})
+			if removed.Error != nil {
+				return removed.Error
+			}
+			result.Removed = int(removed.RowsAffected)
+		}
+		return tx.Table("common.holidays").Where("EXTRACT(YEAR FROM date) = ?", year).Order("date").Find(&result.Holidays).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// WorkingDaysHandler menghitung hari kerja di antara start dan end (YYYY-MM-DD, inklusif)
+func WorkingDaysHandler(c *gin.Context) {
+	start, err := parseHolidayDate(c.Query("start"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "start harus berformat YYYY-MM-DD"})
+		return
+	}
+	end, err := parseHolidayDate(c.Query("end"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "end harus berformat YYYY-MM-DD"})
+		return
+	}
+	if end.Before(start) || end.Sub(start) > maxWorkingDaysRange*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "end harus setelah start dengan rentang maksimal 10 tahun"})
+		return
+	}
+	holidays, err := LoadHolidays(start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"start":        start.Format(EventDateLayout),
+		"end":          end.Format(EventDateLayout),
+		"working_days": CountWorkingDays(start, end, holidays),
+		"holidays":     holidays,
+	})
+}
+
+// AddWorkingDaysHandler mengembalikan tanggal setelah menambah days hari kerja ke date
+func AddWorkingDaysHandler(c *gin.Context) {
+	date, err := parseHolidayDate(c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "date harus berformat YYYY-MM-DD"})
+		return
+	}
+	days, err := strconv.Atoi(c.Query("days"))
+	if err != nil || abs(days) > maxWorkingDaysRange {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "days harus berupa bilangan bulat"})
+		return
+	}
+	result, err := AddWorkingDays(date, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"date":   date.Format(EventDateLayout),
+		"days":   days,
+		"result": result.Format(EventDateLayout),
+	})
+}
+
+// bindHoliday membaca HolidayRequest ke holiday dan menolak tanggal yang sudah dipakai hari libur lain
+func bindHoliday(c *gin.Context, holiday *models.Holiday) bool {
+	var request HolidayRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return false
+	}
+	date, err := parseHolidayDate(request.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "date harus berformat YYYY-MM-DD"})
+		return false
+	}
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "name harus diisi"})
+		return false
+	}
+
+	var count int64
+	if err := initializers.DB.Table("common.holidays").
+		Where("date = ? AND id <> ?", date.Format(EventDateLayout), holiday.ID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return false
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"message": "Tanggal tersebut sudah terdaftar sebagai hari libur"})
+		return false
+	}
+	holiday.Date, holiday.Name = date, name
+	return true
+}
+
+func parseHolidayDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range holidayDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("format tanggal %q tidak dikenali", value)
+}
+
+// parseHolidayCSV membaca baris "tanggal,nama". Baris pertama yang tanggalnya tidak terbaca dianggap judul.
+func parseHolidayCSV(r io.Reader, year int, result *HolidayImportResult) (map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	holidays := make(map[string]string)
+	for i, record := range records {
+		line := i + 1
+		if len(record) < 2 {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("baris %d: kolom tanggal dan nama harus diisi", line))
+			continue
+		}
+		date, err := parseHolidayDate(strings.TrimPrefix(record[0], "\ufeff"))
+		if err != nil {
+			if i > 0 {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("baris %d: %v", line, err))
+			}
+			continue
+		}
+		name := strings.TrimSpace(record[1])
+		switch {
+		case name == "":
+			result.Skipped = append(result.Skipped, fmt.Sprintf("baris %d: nama kosong", line))
+		case date.Year() != year:
+			result.Skipped = append(result.Skipped, fmt.Sprintf("baris %d: %s bukan tahun %d", line, date.Format(EventDateLayout), year))
+		default:
+			addImportedHoliday(holidays, date.Format(EventDateLayout), name)
+		}
+	}
+	return holidays, nil
+}
+
+// parseHolidayICS mengambil tanggal event dari file .ics, event beberapa hari dicatat untuk setiap tanggalnya
+func parseHolidayICS(r io.Reader, year int, result *HolidayImportResult) (map[string]string, error) {
+	period := YearPeriod(year)
+	events, err := ParseICS(r, period.Start, period.End)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays := make(map[string]string)
+	for _, event := range events {
+		name := strings.TrimSpace(event.Title)
+		if name == "" {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("event %s: nama kosong", event.UID))
+			continue
+		}
+		first, last := EventDays(icsHolidayEvent{event})
+		for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+			if day.Year() != year {
+				continue
+			}
+			addImportedHoliday(holidays, day.Format(EventDateLayout), name)
+		}
+	}
+	return holidays, nil
+}
+
+// addImportedHoliday menggabungkan nama jika satu tanggal muncul lebih dari sekali di file
+func addImportedHoliday(holidays map[string]string, date, name string) {
+	if existing, ok := holidays[date]; ok && existing != name {
+		name = existing + " / " + name
+	}
+	holidays[date] = name
+}
+
+// icsHolidayEvent membungkus ICSImportEvent agar bisa dipakai EventDays
+type icsHolidayEvent struct {
+	ICSImportEvent
+}
+
+func (e icsHolidayEvent) GetTitle() string    { return e.Title }
+func (e icsHolidayEvent) GetStart() time.Time { return e.Start }
+func (e icsHolidayEvent) GetEnd() time.Time   { return e.End }
+func (e icsHolidayEvent) GetColor() string    { return "" }
+func (e icsHolidayEvent) GetAllDay() bool     { return e.AllDay }
+func (e icsHolidayEvent) GetResourceID() uint { return 0 }
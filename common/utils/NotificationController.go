@@ -3,83 +3,311 @@ package utils
 import (
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/arkaramadhan/its-vo/common/initializers"
 	"github.com/arkaramadhan/its-vo/common/models"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func SetNotification(title string, startTime time.Time, category string) {
-	// Set lokasi ke WIB
-	loc, err := time.LoadLocation("Asia/Jakarta")
+// SetNotification menjadwalkan pengingat event baru (default 24 jam dan 1 jam sebelum mulai) untuk penerima
+// dari event sumber. Pengingat disimpan di common.notification_jobs sehingga tetap terkirim setelah restart,
+// dan masuk inbox penerima lewat channel inapp.
+func SetNotification(title string, startTime time.Time, category string, sourceID uint) {
+	if _, ok := notificationSource(category); ok {
+		SyncNotifications(category, sourceID)
+		return
+	}
+	if err := ScheduleNotifications(category, sourceID, NotificationSource{Title: title, Start: startTime}); err != nil {
+		log.Printf("Gagal menjadwalkan pengingat %s %d: %v", category, sourceID, err)
+	}
+}
+
+// NotifyUsers mencatat notifikasi ke inbox setiap penerima (username). Penerima kosong dan duplikat dibuang.
+func NotifyUsers(notification models.Notification, usernames []string) error {
+	seen := map[string]bool{}
+	var recipients []models.NotificationRecipient
+	for _, username := range usernames {
+		username = strings.TrimSpace(username)
+		if username == "" || seen[strings.ToLower(username)] {
+			continue
+		}
+		seen[strings.ToLower(username)] = true
+		recipients = append(recipients, models.NotificationRecipient{Username: username})
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("common.notifications").Create(&notification).Error; err != nil {
+			return err
+		}
+		for i := range recipients {
+			recipients[i].NotificationID = notification.ID
+		}
+		return tx.Table("common.notification_recipients").Create(&recipients).Error
+	})
 	if err != nil {
-		log.Printf("Error loading location: %v", err)
+		return err
+	}
+
+	usernames = usernames[:0]
+	for _, recipient := range recipients {
+		usernames = append(usernames, recipient.Username)
+	}
+	PublishEvent(RealtimeEvent{
+		Type:      RealtimeNotification,
+		Action:    "created",
+		Category:  notification.Category,
+		IDs:       []uint{notification.ID},
+		Data:      RealtimeData(notification),
+		Usernames: usernames,
+	})
+	return nil
+}
+
+// InboxNotification adalah notifikasi di inbox seorang pengguna
+type InboxNotification struct {
+	models.Notification
+	ReadAt *time.Time `json:"read_at"`
+	Read   bool       `json:"read" gorm:"-"`
+}
+
+// inboxQuery memilih notifikasi milik pengguna yang login dan belum di-dismiss
+func inboxQuery(c *gin.Context) (*gorm.DB, bool) {
+	username := c.GetString("username")
+	if username == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Pengguna tidak dikenali"})
+		return nil, false
+	}
+	return initializers.DB.Table("common.notification_recipients AS r").
+		Joins("JOIN common.notifications AS n ON n.id = r.notification_id").
+		Where("r.username = ? AND r.dismissed_at IS NULL", username), true
+}
+
+// GetNotifications mengembalikan inbox pengguna yang login, terbaru dulu. Query: page (default 1),
+// limit (default 20, maks 100), status (unread/read), dan category.
+func GetNotifications(c *gin.Context) {
+	query, ok := inboxQuery(c)
+	if !ok {
 		return
 	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	switch c.Query("status") {
+	case "unread":
+		query = query.Where("r.read_at IS NULL")
+	case "read":
+		query = query.Where("r.read_at IS NOT NULL")
+	}
+	if category := c.Query("category"); category != "" {
+		query = query.Where("n.category = ?", category)
+	}
 
-	// Parse waktu mulai event ke WIB
-	startTime, err = time.ParseInLocation(time.RFC3339, startTime.Format(time.RFC3339), loc) // Ubah ini
+	var total, unread int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	if err := unreadQuery(c).Count(&unread).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	notifications := []InboxNotification{}
+	err := query.Select("n.*, r.read_at").Order("n.created_at DESC, n.id DESC").
+		Offset((page - 1) * limit).Limit(limit).Find(&notifications).Error
 	if err != nil {
-		log.Printf("Error parsing start time: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
-	log.Printf("Parsed start time in WIB: %v", startTime)
+	for i := range notifications {
+		notifications[i].Read = notifications[i].ReadAt != nil
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   notifications,
+		"page":   page,
+		"limit":  limit,
+		"total":  total,
+		"unread": unread,
+	})
+}
 
-	// Tentukan waktu notifikasi 24 jam sebelum event
-	notificationTime24 := startTime.Add(-24 * time.Hour)
-	log.Printf("Notifikasi 24 jam dijadwalkan untuk %s", notificationTime24)
+func unreadQuery(c *gin.Context) *gorm.DB {
+	return initializers.DB.Table("common.notification_recipients").
+		Where("username = ? AND read_at IS NULL AND dismissed_at IS NULL", c.GetString("username"))
+}
+
+// GetUnreadNotificationCount mengembalikan jumlah notifikasi yang belum dibaca pengguna yang login
+func GetUnreadNotificationCount(c *gin.Context) {
+	if _, ok := inboxQuery(c); !ok {
+		return
+	}
+	var unread int64
+	if err := unreadQuery(c).Count(&unread).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread": unread})
+}
 
-	// Tentukan waktu notifikasi 1 jam sebelum event
-	notificationTime1 := startTime.Add(-1 * time.Hour)
-	log.Printf("Notifikasi 1 jam dijadwalkan untuk %s", notificationTime1)
+// updateInboxNotification mengubah status satu notifikasi milik pengguna yang login, lalu memberi tahu tab
+// lain pengguna yang sama
+func updateInboxNotification(c *gin.Context, action string, updates map[string]interface{}) {
+	username := c.GetString("username")
+	if username == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Pengguna tidak dikenali"})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+	result := initializers.DB.Table("common.notification_recipients").
+		Where("notification_id = ? AND username = ? AND dismissed_at IS NULL", id, username).
+		Updates(updates)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Notifikasi tidak ditemukan"})
+		return
+	}
+	PublishEvent(RealtimeEvent{Type: RealtimeNotification, Action: action, IDs: []uint{uint(id)}, Usernames: []string{username}})
+	c.Status(http.StatusNoContent)
+}
 
-	// Simulasi pengiriman notifikasi 24 jam sebelum event
-	go func() {
-		time.Sleep(time.Until(notificationTime24))
-		log.Printf("Notifikasi 24 jam dikirim untuk event %s pada %s", title, notificationTime24)
-	}()
+// MarkNotificationRead menandai notifikasi sudah dibaca
+func MarkNotificationRead(c *gin.Context) {
+	updateInboxNotification(c, "read", map[string]interface{}{"read_at": gorm.Expr("COALESCE(read_at, ?)", time.Now())})
+}
 
-	// Simulasi pengiriman notifikasi 1 jam sebelum event
-	go func() {
-		time.Sleep(time.Until(notificationTime1))
-		log.Printf("Notifikasi 1 jam dikirim untuk event %s pada %s", title, notificationTime1)
-	}()
+// MarkNotificationUnread menandai notifikasi belum dibaca
+func MarkNotificationUnread(c *gin.Context) {
+	updateInboxNotification(c, "unread", map[string]interface{}{"read_at": nil})
+}
 
-	notification := models.Notification{
-		Title:    title,
-		Start:    startTime,
-		Category: category,
+// MarkAllNotificationsRead menandai semua notifikasi pengguna sudah dibaca, bisa dibatasi dengan ?category
+func MarkAllNotificationsRead(c *gin.Context) {
+	if _, ok := inboxQuery(c); !ok {
+		return
 	}
-	if err := initializers.DB.Table("common.notifications").Create(&notification).Error; err != nil {
-		log.Printf("Error creating notification: %v", err)
+	query := unreadQuery(c)
+	if category := c.Query("category"); category != "" {
+		query = query.Where("notification_id IN (?)",
+			initializers.DB.Table("common.notifications").Select("id").Where("category = ?", category))
+	}
+	result := query.Update("read_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": result.Error.Error()})
+		return
 	}
-	log.Printf("Notification created with category: %s, title: %s", category, title) // Tambahkan log ini
+	PublishEvent(RealtimeEvent{Type: RealtimeNotification, Action: "read_all", Category: c.Query("category"),
+		Usernames: []string{c.GetString("username")}})
+	c.JSON(http.StatusOK, gin.H{"updated": result.RowsAffected})
+}
 
+// DeleteNotification menghapus notifikasi dari inbox pengguna yang login saja, penerima lain tidak terpengaruh
+func DeleteNotification(c *gin.Context) {
+	updateInboxNotification(c, "dismissed", map[string]interface{}{"dismissed_at": time.Now()})
 }
 
-func GetNotifications(c *gin.Context) {
-	var notifications []models.Notification
-	if err := initializers.DB.Table("common.notifications").Find(&notifications).Error; err != nil {
+// NotificationJobIndex menampilkan job pengingat terbaru, bisa difilter dengan status, category, dan source_id
+func NotificationJobIndex(c *gin.Context) {
+	query := initializers.DB.Table("common.notification_jobs").Order("run_at DESC").Limit(200)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if category := c.Query("category"); category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if sourceID := c.Query("source_id"); sourceID != "" {
+		query = query.Where("source_id = ?", sourceID)
+	}
+	var jobs []models.NotificationJob
+	if err := query.Find(&jobs).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, notifications)
+	c.JSON(http.StatusOK, jobs)
 }
 
-func DeleteNotification(c *gin.Context) {
-	id := c.Param("id")
-	log.Printf("ID yang diterima untuk dihapus: %s", id) // Tambahkan log ini
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "ID harus disertakan"})
+// RetryNotificationJob menjadwalkan ulang job yang gagal, dibatalkan, atau dilewati untuk dikirim segera
+func RetryNotificationJob(c *gin.Context) {
+	result := initializers.DB.Table("common.notification_jobs").
+		Where("id = ? AND status IN ?", c.Param("id"),
+			[]string{models.NotificationJobFailed, models.NotificationJobCancelled, models.NotificationJobSkipped}).
+		Updates(map[string]interface{}{
+			"status":     models.NotificationJobPending,
+			"attempts":   0,
+			"run_at":     time.Now(),
+			"last_error": "",
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"message": "Job tidak ditemukan atau masih menunggu/sudah terkirim"})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"message": "Job dijadwalkan ulang"})
+}
 
-	// Menghapus notifikasi berdasarkan ID
-	if err := initializers.DB.Table("common.notifications").Where("id = ?", id).Delete(&models.Notification{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+// DocumentAssignment adalah dokumen yang PIC-nya baru diisi atau diganti
+type DocumentAssignment struct {
+	Type        string // Jenis dokumen untuk template, misal "memo"
+	ID          uint
+	Number      *string
+	Perihal     *string
+	Tanggal     *time.Time
+	Pic         *string
+	PreviousPic string // PIC sebelum diubah, tidak dikirimi ulang
+	AssignedBy  string
+}
+
+// NotifyDocumentAssignment mengantrekan pesan penugasan untuk PIC dokumen yang baru ditunjuk
+func NotifyDocumentAssignment(doc DocumentAssignment) {
+	previous := SplitRecipients(doc.PreviousPic)
+	var recipients []string
+	for _, pic := range SplitRecipients(GetValue(doc.Pic)) {
+		if !containsString(previous, pic) && !strings.EqualFold(pic, doc.AssignedBy) {
+			recipients = append(recipients, pic)
+		}
+	}
+	if len(recipients) == 0 {
 		return
 	}
 
-	c.Status(http.StatusNoContent) // Mengembalikan status 204 No Content
+	title := GetValue(doc.Perihal)
+	if title == "" {
+		title = GetValue(doc.Number)
+	}
+	data := map[string]string{
+		"type":        doc.Type,
+		"number":      GetValue(doc.Number),
+		"actor":       doc.AssignedBy,
+		"source_type": doc.Type,
+		"link":        NotificationLink(doc.Type, doc.ID),
+	}
+	start := time.Now()
+	if doc.Tanggal != nil {
+		start = *doc.Tanggal
+		data["date"] = doc.Tanggal.Format(EventDateLayout)
+	}
+	if err := QueueNotificationMessage("DocumentAssignment", doc.ID, title, start, recipients, data); err != nil {
+		log.Printf("Gagal mengantrekan penugasan %s %d: %v", doc.Type, doc.ID, err)
+	}
 }
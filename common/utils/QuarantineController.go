@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	"github.com/arkaramadhan/its-vo/common/models"
+	"github.com/gin-gonic/gin"
+)
+
+func saveScanResult(file *models.File) error {
+	return initializers.DB.Table("common.files").Where("id = ?", file.ID).Updates(map[string]interface{}{
+		"scan_status":     file.ScanStatus,
+		"scan_result":     file.ScanResult,
+		"scanned_at":      file.ScannedAt,
+		"quarantine_path": file.QuarantinePath,
+	}).Error
+}
+
+func findFile(c *gin.Context) (*models.File, bool) {
+	var file models.File
+	if err := initializers.DB.Table("common.files").First(&file, c.Param("id")).Error; err != nil {
+		RespondError(c, http.StatusNotFound, "File tidak ditemukan")
+		return nil, false
+	}
+	return &file, true
+}
+
+// QuarantineIndex menampilkan file yang belum dinyatakan bersih
+func QuarantineIndex(c *gin.Context) {
+	status := c.Query("status")
+	query := initializers.DB.Table("common.files")
+	if status != "" {
+		query = query.Where("scan_status = ?", status)
+	} else {
+		query = query.Where("scan_status IN ?", []string{models.ScanInfected, models.ScanError, models.ScanPending, models.ScanLegacy})
+	}
+
+	var files []models.File
+	if err := query.Order("id desc").Find(&files).Error; err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal mengambil data karantina: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, files)
+}
+
+// ScanPendingFiles memindai semua file yang belum pernah dipindai (termasuk file legacy) atau gagal dipindai
+func ScanPendingFiles(c *gin.Context) {
+	var files []models.File
+	if err := initializers.DB.Table("common.files").Where("scan_status IN ?", []string{models.ScanPending, models.ScanError, models.ScanLegacy}).Find(&files).Error; err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal mengambil data file: "+err.Error())
+		return
+	}
+
+	summary := make(map[string]int)
+	for i := range files {
+		ScanStoredFile(&files[i])
+		if err := saveScanResult(&files[i]); err != nil {
+			log.Printf("Error menyimpan hasil scan file %d: %v", files[i].ID, err)
+		}
+		summary[files[i].ScanStatus]++
+	}
+
+	c.JSON(http.StatusOK, gin.H{"scanned": len(files), "summary": summary})
+}
+
+func RescanFile(c *gin.Context) {
+	file, ok := findFile(c)
+	if !ok {
+		return
+	}
+
+	ScanStoredFile(file)
+	if err := saveScanResult(file); err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal menyimpan hasil scan: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, file)
+}
+
+// ReleaseQuarantinedFile mengembalikan file dari karantina setelah diperiksa admin
+func ReleaseQuarantinedFile(c *gin.Context) {
+	file, ok := findFile(c)
+	if !ok {
+		return
+	}
+	if file.QuarantinePath == "" {
+		RespondError(c, http.StatusBadRequest, "File tidak berada di karantina")
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file.FilePath), os.ModePerm); err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal menyiapkan direktori: "+err.Error())
+		return
+	}
+	// Link tidak menimpa file yang sudah ada di tujuan, misal salinan bersih yang diunggah ulang dengan nama sama.
+	// Salinan karantina baru dihapus setelah metadata tersimpan.
+	quarantinePath := file.QuarantinePath
+	if err := os.Link(quarantinePath, file.FilePath); err != nil {
+		if os.IsExist(err) {
+			RespondError(c, http.StatusConflict, "Sudah ada file lain di "+file.FilePath+", hapus atau ganti namanya terlebih dahulu")
+			return
+		}
+		RespondError(c, http.StatusInternalServerError, "Gagal mengembalikan file: "+err.Error())
+		return
+	}
+	os.Chmod(file.FilePath, 0o644)
+
+	file.ScanStatus = models.ScanClean
+	file.ScanResult = "dirilis oleh " + c.MustGet("username").(string)
+	file.QuarantinePath = ""
+	if err := saveScanResult(file); err != nil {
+		if removeErr := os.Remove(file.FilePath); removeErr != nil {
+			log.Printf("Error membatalkan rilis file %d: %v", file.ID, removeErr)
+		}
+		os.Chmod(quarantinePath, 0o400)
+		RespondError(c, http.StatusInternalServerError, "Gagal menyimpan status file: "+err.Error())
+		return
+	}
+	if err := os.Remove(quarantinePath); err != nil {
+		log.Printf("Error menghapus salinan karantina %s: %v", quarantinePath, err)
+	}
+	c.JSON(http.StatusOK, file)
+}
+
+// DeleteQuarantinedFile menghapus permanen file karantina beserta metadatanya
+func DeleteQuarantinedFile(c *gin.Context) {
+	file, ok := findFile(c)
+	if !ok {
+		return
+	}
+	if file.ScanStatus == models.ScanClean {
+		RespondError(c, http.StatusBadRequest, "File bersih, hapus melalui halaman resource")
+		return
+	}
+
+	path := file.FilePath
+	if file.QuarantinePath != "" {
+		path = file.QuarantinePath
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		RespondError(c, http.StatusInternalServerError, "Gagal menghapus file: "+err.Error())
+		return
+	}
+	if err := initializers.DB.Table("common.files").Where("id = ?", file.ID).Delete(&models.File{}).Error; err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal menghapus metadata file: "+err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,290 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	"github.com/arkaramadhan/its-vo/common/models"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultShareLinkHours = 24
+	maxShareLinkHours     = 24 * 30
+)
+
+type ShareLinkRequest struct {
+	FileName       string `json:"file_name"` // Kosongkan untuk membagikan ZIP seluruh lampiran
+	ExpiresInHours int    `json:"expires_in_hours"`
+	Password       string `json:"password"`
+}
+
+// shareRecord memenuhi ZipData untuk record yang sudah disimpan di share link
+type shareRecord struct {
+	id        uint
+	docNumber string
+}
+
+func (r shareRecord) GetID() uint          { return r.id }
+func (r shareRecord) GetDocNumber() string { return r.docNumber }
+
+func shareLinkSecret() ([]byte, error) {
+	secret := os.Getenv("SHARE_LINK_SECRET")
+	if secret == "" {
+		return nil, errors.New("SHARE_LINK_SECRET is not set in the environment variables")
+	}
+	return []byte(secret), nil
+}
+
+// signShareToken menandatangani token beserta waktu kedaluwarsanya sehingga keduanya tidak bisa diubah
+func signShareToken(token string, expiresAt time.Time) (string, error) {
+	secret, err := shareLinkSecret()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(token + "." + strconv.FormatInt(expiresAt.Unix(), 10)))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func generateShareToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CreateShareLink membuat link publik untuk satu lampiran atau ZIP lampiran dari sebuah record
+func CreateShareLink(c *gin.Context, db *gorm.DB, schema, baseDir string, record ZipData, modelName string) {
+	var requestBody ShareLinkRequest
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		RespondError(c, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	id := c.Param("id")
+	if err := db.Table(schema).First(record, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": modelName + " tidak ditemukan"})
+		return
+	}
+
+	if requestBody.FileName != "" {
+		fullPath := filepath.Join(baseDir, id, requestBody.FileName)
+		var file models.File
+		if err := initializers.DB.Table("common.files").Where("file_path = ?", fullPath).First(&file).Error; err != nil {
+			RespondError(c, http.StatusNotFound, "File tidak ditemukan")
+			return
+		}
+	}
+
+	hours := requestBody.ExpiresInHours
+	if hours <= 0 {
+		hours = defaultShareLinkHours
+	}
+	if hours > maxShareLinkHours {
+		RespondError(c, http.StatusBadRequest, fmt.Sprintf("masa berlaku maksimal %d jam", maxShareLinkHours))
+		return
+	}
+
+	token, err := generateShareToken()
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal membuat token")
+		return
+	}
+
+	link := models.ShareLink{
+		Token:     token,
+		Resource:  modelName,
+		BaseDir:   baseDir,
+		RecordID:  record.GetID(),
+		DocNumber: record.GetDocNumber(),
+		FileName:  requestBody.FileName,
+		ExpiresAt: time.Now().Add(time.Duration(hours) * time.Hour).Truncate(time.Second),
+		CreateBy:  c.MustGet("username").(string),
+	}
+
+	if requestBody.Password != "" {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(requestBody.Password), bcrypt.DefaultCost)
+		if err != nil {
+			RespondError(c, http.StatusInternalServerError, "Gagal mengenkripsi kata sandi")
+			return
+		}
+		link.PasswordHash = string(hashedPassword)
+		link.HasPassword = true
+	}
+
+	signature, err := signShareToken(link.Token, link.ExpiresAt)
+	if err != nil {
+		log.Printf("Error signing share link: %v", err)
+		RespondError(c, http.StatusInternalServerError, "Gagal menandatangani link")
+		return
+	}
+
+	if err := initializers.DB.Table("common.share_links").Create(&link).Error; err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal menyimpan share link: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "share link berhasil dibuat",
+		"url":     fmt.Sprintf("/share/%s.%s", link.Token, signature),
+		"link":    link,
+	})
+}
+
+func ShareLinkIndex(c *gin.Context) {
+	var links []models.ShareLink
+	if err := initializers.DB.Table("common.share_links").Order("id desc").Find(&links).Error; err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal mengambil data share link: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, links)
+}
+
+func RevokeShareLink(c *gin.Context) {
+	id := c.Param("id")
+	result := initializers.DB.Table("common.share_links").
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal mencabut share link: "+result.Error.Error())
+		return
+	}
+	if result.RowsAffected == 0 {
+		RespondError(c, http.StatusNotFound, "share link tidak ditemukan atau sudah dicabut")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "share link berhasil dicabut"})
+}
+
+func GetShareLinkAccessLog(c *gin.Context) {
+	id := c.Param("id")
+	var accesses []models.ShareLinkAccess
+	if err := initializers.DB.Table("common.share_link_accesses").Where("share_link_id = ?", id).Order("id desc").Find(&accesses).Error; err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal mengambil log akses: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, accesses)
+}
+
+func logShareAccess(c *gin.Context, linkID uint, success bool, reason string) {
+	access := models.ShareLinkAccess{
+		ShareLinkID: linkID,
+		IP:          c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		Success:     success,
+		Reason:      reason,
+	}
+	if err := initializers.DB.Table("common.share_link_accesses").Create(&access).Error; err != nil {
+		log.Printf("Error logging share link access: %v", err)
+	}
+}
+
+// DownloadShareLink melayani link publik, dipasang sebelum TokenAuthMiddleware.
+// Password hanya diterima dari body POST (form field atau JSON "password") agar tidak tercatat di log URL.
+func DownloadShareLink(c *gin.Context) {
+	token, signature, found := strings.Cut(c.Param("token"), ".")
+	if !found {
+		RespondError(c, http.StatusNotFound, "Link tidak valid")
+		return
+	}
+
+	var link models.ShareLink
+	if err := initializers.DB.Table("common.share_links").Where("token = ?", token).First(&link).Error; err != nil {
+		RespondError(c, http.StatusNotFound, "Link tidak valid")
+		return
+	}
+
+	expected, err := signShareToken(link.Token, link.ExpiresAt)
+	if err != nil {
+		log.Printf("Error signing share link: %v", err)
+		RespondError(c, http.StatusInternalServerError, "Gagal memverifikasi link")
+		return
+	}
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		logShareAccess(c, link.ID, false, "signature tidak valid")
+		RespondError(c, http.StatusNotFound, "Link tidak valid")
+		return
+	}
+
+	if link.RevokedAt != nil {
+		logShareAccess(c, link.ID, false, "link sudah dicabut")
+		RespondError(c, http.StatusGone, "Link sudah dicabut")
+		return
+	}
+	if time.Now().After(link.ExpiresAt) {
+		logShareAccess(c, link.ID, false, "link kedaluwarsa")
+		RespondError(c, http.StatusGone, "Link sudah kedaluwarsa")
+		return
+	}
+
+	if link.HasPassword {
+		if err := bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(shareLinkPassword(c))); err != nil {
+			logShareAccess(c, link.ID, false, "password salah")
+			RespondError(c, http.StatusUnauthorized, "Password diperlukan atau salah")
+			return
+		}
+	}
+
+	if link.FileName != "" {
+		fullPath := filepath.Join(link.BaseDir, strconv.FormatUint(uint64(link.RecordID), 10), link.FileName)
+		var file models.File
+		if err := initializers.DB.Table("common.files").Where("file_path = ?", fullPath).First(&file).Error; err != nil || !file.Downloadable() {
+			logShareAccess(c, link.ID, false, "file belum dipindai atau terinfeksi")
+			RespondError(c, http.StatusForbidden, "File belum dipindai atau terinfeksi virus")
+			return
+		}
+		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+			logShareAccess(c, link.ID, false, "file tidak ditemukan")
+			RespondError(c, http.StatusNotFound, "File tidak ditemukan di sistem file")
+			return
+		}
+		incrementShareDownload(link.ID)
+		logShareAccess(c, link.ID, true, "")
+		c.FileAttachment(fullPath, link.FileName)
+		return
+	}
+
+	incrementShareDownload(link.ID)
+	logShareAccess(c, link.ID, true, "")
+	record := shareRecord{id: link.RecordID, docNumber: link.DocNumber}
+	StreamZip(c, link.BaseDir, []ZipData{record}, fmt.Sprintf("%s_%s", link.Resource, ZipFolderName(record)))
+}
+
+// shareLinkPassword membaca password dari body POST, GET selalu dianggap tanpa password
+func shareLinkPassword(c *gin.Context) string {
+	if c.Request.Method != http.MethodPost {
+		return ""
+	}
+	if c.ContentType() == gin.MIMEJSON {
+		var body struct {
+			Password string `json:"password"`
+		}
+		c.ShouldBindJSON(&body)
+		return body.Password
+	}
+	return c.PostForm("password")
+}
+
+func incrementShareDownload(id uint) {
+	if err := initializers.DB.Table("common.share_links").Where("id = ?", id).
+		UpdateColumn("download_count", gorm.Expr("download_count + 1")).Error; err != nil {
+		log.Printf("Error incrementing download count: %v", err)
+	}
+}
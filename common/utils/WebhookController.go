@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	"github.com/arkaramadhan/its-vo/common/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// webhookRequest adalah body pembuatan dan perubahan WebhookSubscription
+type webhookRequest struct {
+	Name         *string  `json:"name"`
+	URL          *string  `json:"url"`
+	EventTypes   []string `json:"event_types"` // Misal ["surat_masuk.created", "project.*"] atau ["*"]
+	Secret       *string  `json:"secret"`      // Kosong saat dibuat berarti dibuatkan otomatis
+	Active       *bool    `json:"active"`
+	RotateSecret bool     `json:"rotate_secret"`
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// apply mengisi subscription dari body, field yang tidak dikirim tidak diubah
+func (body webhookRequest) apply(subscription *models.WebhookSubscription) error {
+	if body.Name != nil {
+		subscription.Name = strings.TrimSpace(*body.Name)
+	}
+	if body.URL != nil {
+		subscription.URL = strings.TrimSpace(*body.URL)
+	}
+	if u, err := url.Parse(subscription.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("URL webhook harus http atau https")
+	}
+	if body.EventTypes != nil {
+		var types []string
+		for _, eventType := range body.EventTypes {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				types = append(types, eventType)
+			}
+		}
+		subscription.EventTypes = strings.Join(types, ",")
+	}
+	if subscription.EventTypes == "" {
+		return errors.New("event_types harus diisi, gunakan \"*\" untuk semua event")
+	}
+	if body.Active != nil {
+		subscription.Active = *body.Active
+	}
+	if body.Secret != nil {
+		subscription.Secret = strings.TrimSpace(*body.Secret)
+	}
+	if subscription.Secret == "" || body.RotateSecret {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return err
+		}
+		subscription.Secret = secret
+	}
+	return nil
+}
+
+// WebhookIndex menampilkan semua subscription webhook
+func WebhookIndex(c *gin.Context) {
+	var subscriptions []models.WebhookSubscription
+	if err := initializers.DB.Table("common.webhook_subscriptions").Order("id").Find(&subscriptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// CreateWebhook mendaftarkan subscription webhook. Secret hanya dikembalikan di respons ini.
+func CreateWebhook(c *gin.Context) {
+	var body webhookRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	subscription := models.WebhookSubscription{Active: true, CreateBy: c.GetString("username")}
+	if err := body.apply(&subscription); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	if err := initializers.DB.Table("common.webhook_subscriptions").Create(&subscription).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"webhook": subscription, "secret": subscription.Secret})
+}
+
+// UpdateWebhook mengubah subscription webhook. Kirim rotate_secret true untuk membuat secret baru.
+func UpdateWebhook(c *gin.Context) {
+	var subscription models.WebhookSubscription
+	if err := initializers.DB.Table("common.webhook_subscriptions").First(&subscription, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Webhook tidak ditemukan"})
+		return
+	}
+	var body webhookRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	previousSecret := subscription.Secret
+	if err := body.apply(&subscription); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	if err := initializers.DB.Table("common.webhook_subscriptions").Save(&subscription).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	response := gin.H{"webhook": subscription}
+	if subscription.Secret != previousSecret {
+		response["secret"] = subscription.Secret
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteWebhook menghapus subscription webhook, delivery yang belum terkirim ditandai gagal
+func DeleteWebhook(c *gin.Context) {
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Table("common.webhook_subscriptions").Where("id = ?", c.Param("id")).Delete(&models.WebhookSubscription{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Table("common.webhook_deliveries").
+			Where("subscription_id = ? AND status = ?", c.Param("id"), models.WebhookDeliveryPending).
+			Updates(map[string]interface{}{"status": models.WebhookDeliveryFailed, "last_error": "Webhook dihapus"}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Webhook tidak ditemukan"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook dihapus"})
+}
+
+// PingWebhook mengirim event webhook.ping ke satu subscription untuk menguji URL dan verifikasi signature
+func PingWebhook(c *gin.Context) {
+	var subscription models.WebhookSubscription
+	if err := initializers.DB.Table("common.webhook_subscriptions").First(&subscription, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Webhook tidak ditemukan"})
+		return
+	}
+	event, err := newDomainEvent("common", "webhook", "ping", "", gin.H{"webhook_id": subscription.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	event.CreatedAt = time.Now()
+	payload, err := json.Marshal(newWebhookPayload(event))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	delivery := models.WebhookDelivery{
+		SubscriptionID: subscription.ID,
+		EventID:        event.EventID,
+		EventType:      event.Type,
+		Payload:        string(payload),
+		Status:         models.WebhookDeliveryPending,
+		MaxAttempts:    1,
+		NextAttemptAt:  time.Now(),
+	}
+	if err := initializers.DB.Table("common.webhook_deliveries").Create(&delivery).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	wakeWebhookWorker()
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// WebhookDeliveryIndex menampilkan log delivery terbaru sebuah subscription, bisa difilter dengan status dan event_type
+func WebhookDeliveryIndex(c *gin.Context) {
+	query := initializers.DB.Table("common.webhook_deliveries").
+		Where("subscription_id = ?", c.Param("id")).Order("id DESC").Limit(200)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if eventType := c.Query("event_type"); eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+	var deliveries []models.WebhookDelivery
+	if err := query.Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// RedeliverWebhook mengirim ulang payload delivery sebagai delivery baru dengan percobaan dari awal.
+// EventID tetap sama sehingga penerima bisa mengenali duplikat.
+func RedeliverWebhook(c *gin.Context) {
+	var original models.WebhookDelivery
+	if err := initializers.DB.Table("common.webhook_deliveries").First(&original, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Delivery tidak ditemukan"})
+		return
+	}
+	var subscription models.WebhookSubscription
+	if err := initializers.DB.Table("common.webhook_subscriptions").First(&subscription, original.SubscriptionID).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"message": "Webhook delivery ini sudah dihapus"})
+		return
+	}
+	delivery := models.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         models.WebhookDeliveryPending,
+		MaxAttempts:    notificationEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		NextAttemptAt:  time.Now(),
+		RedeliveryOf:   &original.ID,
+	}
+	if err := initializers.DB.Table("common.webhook_deliveries").Create(&delivery).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	wakeWebhookWorker()
+	c.JSON(http.StatusAccepted, delivery)
+}
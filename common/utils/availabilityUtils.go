@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TimeSlot adalah rentang waktu [Start, End)
+type TimeSlot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// FreeSlots mengembalikan slot kosong per hari di [from, to) di dalam jam kerja dayStart–dayEnd
+// (durasi sejak 00:00 WIB) setelah dikurangi busy. Slot yang lebih pendek dari minDuration dibuang.
+func FreeSlots(busy []TimeSlot, from, to time.Time, dayStart, dayEnd, minDuration time.Duration) []TimeSlot {
+	loc := jakartaLocation()
+	from, to = from.In(loc), to.In(loc)
+	busy = append([]TimeSlot(nil), busy...)
+	sort.Slice(busy, func(i, j int) bool { return busy[i].Start.Before(busy[j].Start) })
+
+	var free []TimeSlot
+	add := func(start, end time.Time) {
+		if end.Sub(start) >= minDuration && end.After(start) {
+			free = append(free, TimeSlot{Start: start, End: end})
+		}
+	}
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		windowStart, windowEnd := day.Add(dayStart), day.Add(dayEnd)
+		if windowStart.Before(from) {
+			windowStart = from
+		}
+		if windowEnd.After(to) {
+			windowEnd = to
+		}
+		cursor := windowStart
+		for _, slot := range busy {
+			if !slot.End.After(cursor) || !slot.Start.Before(windowEnd) {
+				continue
+			}
+			if slot.Start.After(cursor) {
+				add(cursor, slot.Start.In(loc))
+			}
+			cursor = slot.End.In(loc)
+			if !cursor.Before(windowEnd) {
+				break
+			}
+		}
+		if cursor.Before(windowEnd) {
+			add(cursor, windowEnd)
+		}
+	}
+	return free
+}
+
+// ParseClock membaca jam "15:04" sebagai durasi sejak tengah malam, nilai kosong memakai fallback
+func ParseClock(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("format jam %q tidak valid, gunakan HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,398 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// CalendarLayout menentukan bentuk export kalender
+type CalendarLayout string
+
+const (
+	LayoutMonth  CalendarLayout = "month"  // Grid bulanan, tiga bulan per baris
+	LayoutWeek   CalendarLayout = "week"   // Per minggu, baris jam dan kolom hari
+	LayoutAgenda CalendarLayout = "agenda" // Daftar kegiatan per hari
+)
+
+const (
+	weekDayStartHour = 7
+	weekDayEndHour   = 18
+	weekBlockGap     = 2
+)
+
+// ParseCalendarLayout membaca query parameter layout (month, week, atau agenda)
+func ParseCalendarLayout(c *gin.Context) (CalendarLayout, error) {
+	switch layout := CalendarLayout(c.Query("layout")); layout {
+	case "", LayoutMonth:
+		return LayoutMonth, nil
+	case LayoutWeek, LayoutAgenda:
+		return layout, nil
+	default:
+		return "", fmt.Errorf("layout tidak valid, gunakan month, week, atau agenda")
+	}
+}
+
+// renderCalendar memilih renderer sesuai layout pada config
+func renderCalendar(f *excelize.File, config CalenderConfig) error {
+	if config.Period.IsZero() {
+		config.Period = YearPeriod(time.Now().In(jakartaLocation()).Year())
+	}
+
+	if config.Holidays == nil {
+		holidays, err := LoadHolidays(config.Period.Start, config.Period.End.AddDate(0, 0, -1))
+		if err != nil {
+			// Export tetap dibuat tanpa arsiran hari libur
+			log.Printf("Gagal membaca hari libur untuk export kalender: %v", err)
+			holidays = HolidaySet{}
+		}
+		config.Holidays = holidays
+	}
+
+	switch config.Layout {
+	case LayoutWeek:
+		return setCalendarWeeks(f, config)
+	case LayoutAgenda:
+		return setCalendarAgenda(f, config)
+	default:
+		return setCalenderMonths(f, config)
+	}
+}
+
+// calendarOccurrence adalah bagian event yang jatuh pada satu hari
+type calendarOccurrence struct {
+	event  ExcelEvent
+	start  time.Time
+	end    time.Time
+	allDay bool // true juga untuk hari tengah dari event non-AllDay yang melewati beberapa hari
+}
+
+// occurrenceOnDay memotong event ke hari day (00:00 WIB), ok false jika event tidak jatuh pada hari itu
+func occurrenceOnDay(event ExcelEvent, day time.Time) (calendarOccurrence, bool) {
+	startDay, endDay := EventDays(event)
+	if day.Before(startDay) || day.After(endDay) {
+		return calendarOccurrence{}, false
+	}
+
+	occurrence := calendarOccurrence{event: event, start: day, end: day.AddDate(0, 0, 1), allDay: true}
+	if event.GetAllDay() {
+		return occurrence, true
+	}
+
+	start := eventWallTime(event.GetStart())
+	end := eventWallTime(event.GetEnd())
+	if start.After(occurrence.start) {
+		occurrence.start = start
+		occurrence.allDay = false
+	}
+	if end.Before(occurrence.end) {
+		occurrence.end = end
+		occurrence.allDay = false
+	}
+	if !occurrence.end.After(occurrence.start) {
+		occurrence.end = occurrence.start
+	}
+	return occurrence, true
+}
+
+// occurrencesOnDay mengembalikan event pada satu hari, AllDay dulu lalu urut jam mulai
+func occurrencesOnDay(events []ExcelEvent, day time.Time) []calendarOccurrence {
+	var occurrences []calendarOccurrence
+	for _, event := range events {
+		if occurrence, ok := occurrenceOnDay(event, day); ok {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		if occurrences[i].allDay != occurrences[j].allDay {
+			return occurrences[i].allDay
+		}
+		return occurrences[i].start.Before(occurrences[j].start)
+	})
+	return occurrences
+}
+
+func occurrenceTitle(occurrence calendarOccurrence, config CalenderConfig) string {
+	title := occurrence.event.GetTitle()
+	if config.UseResource {
+		if name := config.ResourceMap[occurrence.event.GetResourceID()]; name != "" {
+			title = fmt.Sprintf("%s - %s", name, title)
+		}
+	}
+	return title
+}
+
+func occurrenceTime(occurrence calendarOccurrence) string {
+	if occurrence.allDay {
+		return "Sepanjang hari"
+	}
+	return fmt.Sprintf("%s - %s", occurrence.start.Format("15:04"), occurrence.end.Format("15:04"))
+}
+
+// mondayOf mengembalikan hari Senin pada minggu yang sama
+func mondayOf(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// setCalendarWeeks membuat satu blok per minggu: kolom Senin-Minggu, baris jam ditambah baris sepanjang hari
+func setCalendarWeeks(f *excelize.File, config CalenderConfig) error {
+	sheet := config.SheetName
+	events := FilterEventsByPeriod(config.Events, config.Period)
+	styles := newCalendarStyles(f)
+	firstCol := 2 + config.ColOffset
+
+	titleStyle, err := styles.title()
+	if err != nil {
+		return err
+	}
+	weekdayStyle, err := styles.weekday()
+	if err != nil {
+		return err
+	}
+	laneStyle, err := styles.lane(true, false)
+	if err != nil {
+		return err
+	}
+	holidayStyle, err := styles.lane(true, true)
+	if err != nil {
+		return err
+	}
+
+	hourCol, _ := excelize.ColumnNumberToName(firstCol)
+	firstDayCol, _ := excelize.ColumnNumberToName(firstCol + 1)
+	lastDayCol, _ := excelize.ColumnNumberToName(firstCol + 7)
+	f.SetColWidth(sheet, hourCol, hourCol, 14)
+	f.SetColWidth(sheet, firstDayCol, lastDayCol, 22)
+
+	row := 1 + config.RowOffset
+	for monday := mondayOf(config.Period.Start); monday.Before(config.Period.End); monday = monday.AddDate(0, 0, 7) {
+		var days [7][]calendarOccurrence
+		startHour, endHour := weekDayStartHour, weekDayEndHour
+		for d := 0; d < 7; d++ {
+			day := monday.AddDate(0, 0, d)
+			if !config.Period.Contains(day) {
+				continue
+			}
+			days[d] = occurrencesOnDay(events, day)
+			for _, occurrence := range days[d] {
+				if occurrence.allDay {
+					continue
+				}
+				startHour = min(startHour, occurrence.start.Hour())
+				endHour = max(endHour, int(math.Ceil(occurrence.end.Sub(day).Hours())))
+			}
+		}
+		endHour = min(endHour, 24)
+
+		sunday := monday.AddDate(0, 0, 6)
+		title := fmt.Sprintf("%s - %s", monday.Format("02 Jan 2006"), sunday.Format("02 Jan 2006"))
+		if err := setCell(f, sheet, firstCol, row, title, titleStyle); err != nil {
+			return err
+		}
+		f.SetRowHeight(sheet, row, 35)
+
+		header := row + 2
+		if err := setCell(f, sheet, firstCol, header, "JAM", weekdayStyle); err != nil {
+			return err
+		}
+		for d := 0; d < 7; d++ {
+			day := monday.AddDate(0, 0, d)
+			label := fmt.Sprintf("%s %s", calendarWeekdays[day.Weekday()], day.Format("02/01"))
+			if err := setCell(f, sheet, firstCol+1+d, header, label, weekdayStyle); err != nil {
+				return err
+			}
+		}
+		f.SetRowHeight(sheet, header, 22)
+
+		// Baris event sepanjang hari
+		allDayRow := header + 1
+		if err := setCell(f, sheet, firstCol, allDayRow, "Sepanjang hari", laneStyle); err != nil {
+			return err
+		}
+		for d := 0; d < 7; d++ {
+			var titles []string
+			var color string
+			if holiday, ok := config.Holidays[monday.AddDate(0, 0, d).Format(EventDateLayout)]; ok {
+				titles = append(titles, "Libur: "+holiday)
+			}
+			for _, occurrence := range days[d] {
+				if !occurrence.allDay {
+					continue
+				}
+				if color == "" {
+					color = normalizeColor(occurrence.event.GetColor())
+				}
+				titles = append(titles, occurrenceTitle(occurrence, config))
+			}
+			if err := setWeekCell(f, styles, sheet, firstCol+1+d, allDayRow, titles, color, weekEmptyStyle(config, monday.AddDate(0, 0, d), laneStyle, holidayStyle)); err != nil {
+				return err
+			}
+		}
+		f.SetRowHeight(sheet, allDayRow, 30)
+
+		// Baris per jam; teks ditulis pada jam mulai, warna mengisi semua jam yang terpakai
+		for hour := startHour; hour < endHour; hour++ {
+			hourRow := allDayRow + 1 + hour - startHour
+			if err := setCell(f, sheet, firstCol, hourRow, fmt.Sprintf("%02d:00", hour), laneStyle); err != nil {
+				return err
+			}
+			for d := 0; d < 7; d++ {
+				slotStart := monday.AddDate(0, 0, d).Add(time.Duration(hour) * time.Hour)
+				slotEnd := slotStart.Add(time.Hour)
+
+				var titles []string
+				var color string
+				for _, occurrence := range days[d] {
+					if occurrence.allDay {
+						continue
+					}
+					overlaps := occurrence.start.Before(slotEnd) && (occurrence.end.After(slotStart) || occurrence.start.Equal(slotStart))
+					if !overlaps {
+						continue
+					}
+					if color == "" {
+						color = normalizeColor(occurrence.event.GetColor())
+					}
+					if !occurrence.start.Before(slotStart) || hour == startHour {
+						titles = append(titles, fmt.Sprintf("%s\n%s", occurrenceTitle(occurrence, config), occurrenceTime(occurrence)))
+					}
+				}
+				if err := setWeekCell(f, styles, sheet, firstCol+1+d, hourRow, titles, color, weekEmptyStyle(config, monday.AddDate(0, 0, d), laneStyle, holidayStyle)); err != nil {
+					return err
+				}
+			}
+			f.SetRowHeight(sheet, hourRow, 30)
+		}
+
+		row = allDayRow + 1 + (endHour - startHour) + weekBlockGap
+	}
+
+	disable := false
+	return f.SetSheetView(sheet, 0, &excelize.ViewOptions{ShowGridLines: &disable})
+}
+
+// weekEmptyStyle mengarsir sel kosong pada kolom hari libur
+func weekEmptyStyle(config CalenderConfig, day time.Time, laneStyle, holidayStyle int) int {
+	if config.Holidays.IsHoliday(day) {
+		return holidayStyle
+	}
+	return laneStyle
+}
+
+func setWeekCell(f *excelize.File, styles *calendarStyles, sheet string, col, row int, titles []string, color string, emptyStyle int) error {
+	style := emptyStyle
+	if color != "" {
+		barStyle, err := styles.bar(color)
+		if err != nil {
+			return err
+		}
+		style = barStyle
+	}
+	var value interface{}
+	if len(titles) > 0 {
+		value = strings.Join(titles, "\n")
+	}
+	return setCell(f, sheet, col, row, value, style)
+}
+
+// setCalendarAgenda membuat daftar kegiatan per hari dalam periode, hari tanpa kegiatan dan bukan hari libur dilewati
+func setCalendarAgenda(f *excelize.File, config CalenderConfig) error {
+	sheet := config.SheetName
+	events := FilterEventsByPeriod(config.Events, config.Period)
+	styles := newCalendarStyles(f)
+	firstCol := 2 + config.ColOffset
+
+	titleStyle, err := styles.title()
+	if err != nil {
+		return err
+	}
+	headerStyle, err := styles.weekday()
+	if err != nil {
+		return err
+	}
+	rowStyle, err := styles.lane(true, false)
+	if err != nil {
+		return err
+	}
+	holidayStyle, err := styles.lane(true, true)
+	if err != nil {
+		return err
+	}
+
+	row := 1 + config.RowOffset
+	if err := setCell(f, sheet, firstCol, row, "AGENDA "+config.Period.Label, titleStyle); err != nil {
+		return err
+	}
+	f.SetRowHeight(sheet, row, 35)
+
+	columns := []ExcelColumn{
+		{Header: "TANGGAL", Width: 14},
+		{Header: "HARI", Width: 10},
+		{Header: "WAKTU", Width: 16},
+		{Header: "KEGIATAN", Width: 50},
+	}
+	header := row + 2
+	for i, column := range columns {
+		if err := setCell(f, sheet, firstCol+i, header, column.Header, headerStyle); err != nil {
+			return err
+		}
+		colName, _ := excelize.ColumnNumberToName(firstCol + i)
+		f.SetColWidth(sheet, colName, colName, column.Width)
+	}
+	f.SetRowHeight(sheet, header, 22)
+
+	row = header + 1
+	for day := config.Period.Start; day.Before(config.Period.End); day = day.AddDate(0, 0, 1) {
+		occurrences := occurrencesOnDay(events, day)
+		// Hari libur selalu ditampilkan sebagai baris pertama hari tersebut
+		holiday, isHoliday := config.Holidays[day.Format(EventDateLayout)]
+		if isHoliday {
+			values := []interface{}{day.Format("02/01/2006"), calendarWeekdays[day.Weekday()], "Sepanjang hari", "Libur: " + holiday}
+			for i, value := range values {
+				if err := setCell(f, sheet, firstCol+i, row, value, holidayStyle); err != nil {
+					return err
+				}
+			}
+			row++
+		}
+		for i, occurrence := range occurrences {
+			var date, weekday interface{}
+			if i == 0 && !isHoliday {
+				date = day.Format("02/01/2006")
+				weekday = calendarWeekdays[day.Weekday()]
+			}
+			if err := setCell(f, sheet, firstCol, row, date, rowStyle); err != nil {
+				return err
+			}
+			if err := setCell(f, sheet, firstCol+1, row, weekday, rowStyle); err != nil {
+				return err
+			}
+			if err := setCell(f, sheet, firstCol+2, row, occurrenceTime(occurrence), rowStyle); err != nil {
+				return err
+			}
+			barStyle, err := styles.bar(occurrence.event.GetColor())
+			if err != nil {
+				return err
+			}
+			if err := setCell(f, sheet, firstCol+3, row, occurrenceTitle(occurrence, config), barStyle); err != nil {
+				return err
+			}
+			row++
+		}
+	}
+
+	// Header tetap terlihat saat daftar di-scroll
+	return f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      header,
+		TopLeftCell: fmt.Sprintf("A%d", header+1),
+		ActivePane:  "bottomLeft",
+	})
+}
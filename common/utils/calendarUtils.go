@@ -0,0 +1,658 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// maxCalendarMonths membatasi jumlah blok bulan dalam satu export
+const maxCalendarMonths = 60
+
+// CalendarPeriod adalah rentang tanggal export kalender, Start inklusif dan End eksklusif (00:00 WIB)
+type CalendarPeriod struct {
+	Start time.Time
+	End   time.Time
+	Label string // Dipakai sebagai akhiran nama file, misal "2025" atau "2025-03_2025-06"
+}
+
+func jakartaLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return loc
+}
+
+// YearPeriod mengembalikan periode satu tahun penuh
+func YearPeriod(year int) CalendarPeriod {
+	loc := jakartaLocation()
+	return CalendarPeriod{
+		Start: time.Date(year, time.January, 1, 0, 0, 0, 0, loc),
+		End:   time.Date(year+1, time.January, 1, 0, 0, 0, 0, loc),
+		Label: strconv.Itoa(year),
+	}
+}
+
+// ParseCalendarPeriod membaca periode export dari query parameter, dengan prioritas:
+//   - start & end (YYYY-MM-DD), rentang tanggal inklusif
+//   - start_month & end_month (YYYY-MM), rentang bulan inklusif
+//   - year (YYYY)
+//
+// Tanpa parameter, tahun berjalan yang dipakai.
+func ParseCalendarPeriod(c *gin.Context) (CalendarPeriod, error) {
+	loc := jakartaLocation()
+	var period CalendarPeriod
+
+	switch {
+	case c.Query("start") != "" || c.Query("end") != "":
+		start, err := time.ParseInLocation("2006-01-02", c.Query("start"), loc)
+		if err != nil {
+			return period, fmt.Errorf("format start tidak valid, gunakan YYYY-MM-DD")
+		}
+		end, err := time.ParseInLocation("2006-01-02", c.Query("end"), loc)
+		if err != nil {
+			return period, fmt.Errorf("format end tidak valid, gunakan YYYY-MM-DD")
+		}
+		period = CalendarPeriod{
+			Start: start,
+			End:   end.AddDate(0, 0, 1),
+			Label: start.Format("2006-01-02") + "_" + end.Format("2006-01-02"),
+		}
+
+	case c.Query("start_month") != "" || c.Query("end_month") != "":
+		start, err := time.ParseInLocation("2006-01", c.Query("start_month"), loc)
+		if err != nil {
+			return period, fmt.Errorf("format start_month tidak valid, gunakan YYYY-MM")
+		}
+		end, err := time.ParseInLocation("2006-01", c.Query("end_month"), loc)
+		if err != nil {
+			return period, fmt.Errorf("format end_month tidak valid, gunakan YYYY-MM")
+		}
+		period = CalendarPeriod{
+			Start: start,
+			End:   end.AddDate(0, 1, 0),
+			Label: start.Format("2006-01") + "_" + end.Format("2006-01"),
+		}
+		if start.Equal(end) {
+			period.Label = start.Format("2006-01")
+		}
+
+	case c.Query("year") != "":
+		year, err := strconv.Atoi(c.Query("year"))
+		if err != nil || year < 1900 || year > 9999 {
+			return period, fmt.Errorf("year tidak valid")
+		}
+		period = YearPeriod(year)
+
+	default:
+		period = YearPeriod(time.Now().In(loc).Year())
+	}
+
+	if !period.End.After(period.Start) {
+		return period, fmt.Errorf("akhir periode harus setelah awal periode")
+	}
+	if len(period.Months()) > maxCalendarMonths {
+		return period, fmt.Errorf("periode maksimal %d bulan", maxCalendarMonths)
+	}
+	return period, nil
+}
+
+// IsZero bernilai true jika periode belum diisi
+func (p CalendarPeriod) IsZero() bool {
+	return p.Start.IsZero() && p.End.IsZero()
+}
+
+// Contains memeriksa apakah tanggal berada di dalam periode
+func (p CalendarPeriod) Contains(date time.Time) bool {
+	return !date.Before(p.Start) && date.Before(p.End)
+}
+
+// Months mengembalikan tanggal 1 dari setiap bulan yang tercakup periode
+func (p CalendarPeriod) Months() []time.Time {
+	var months []time.Time
+	month := time.Date(p.Start.Year(), p.Start.Month(), 1, 0, 0, 0, 0, p.Start.Location())
+	for month.Before(p.End) {
+		months = append(months, month)
+		month = month.AddDate(0, 1, 0)
+	}
+	return months
+}
+
+// eventWallTime mengembalikan waktu event dalam WIB. Start/End model disimpan sebagai timestamptz
+// (lihat EventTime), sehingga event dengan offset lain ditampilkan pada jam WIB yang sesuai.
+func eventWallTime(t time.Time) time.Time {
+	return t.In(jakartaLocation())
+}
+
+// EventDays mengembalikan hari pertama dan terakhir (inklusif) sebuah event.
+// Event AllDay menyimpan akhir eksklusif sehingga dikurangi satu hari.
+func EventDays(event ExcelEvent) (time.Time, time.Time) {
+	loc := jakartaLocation()
+	start := eventWallTime(event.GetStart())
+	end := eventWallTime(event.GetEnd())
+	startDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	endDay := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, loc)
+	if event.GetAllDay() && endDay.After(startDay) {
+		endDay = endDay.AddDate(0, 0, -1)
+	}
+	if endDay.Before(startDay) {
+		endDay = startDay
+	}
+	return startDay, endDay
+}
+
+// FilterEventsByPeriod hanya menyisakan event yang beririsan dengan periode
+func FilterEventsByPeriod[T ExcelEvent](events []T, period CalendarPeriod) []T {
+	var filtered []T
+	for _, event := range events {
+		startDay, endDay := EventDays(event)
+		if startDay.Before(period.End) && !endDay.Before(period.Start) {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+const (
+	calendarBlockCols    = 9 // 7 kolom hari + 2 kolom jarak antar bulan
+	calendarMonthsPerRow = 3
+	calendarBlockGap     = 3 // Baris kosong antar baris blok bulan
+	defaultEventColor    = "3788D8"
+	holidayFillColor     = "FDE2E1" // Arsiran hari libur
+	holidayFontColor     = "C0392B"
+)
+
+var calendarWeekdays = []interface{}{"MINGGU", "SENIN", "SELASA", "RABU", "KAMIS", "JUMAT", "SABTU"}
+
+// calendarSegment adalah potongan event dalam satu minggu, digambar sebagai bar dari startCol sampai endCol
+type calendarSegment struct {
+	event           ExcelEvent
+	startCol        int
+	endCol          int
+	lane            int
+	continuesBefore bool
+	continuesAfter  bool
+}
+
+type calendarWeek struct {
+	days     [7]time.Time // Nol untuk sel di luar bulan
+	lanes    int
+	segments []calendarSegment
+}
+
+type calendarMonth struct {
+	month time.Time
+	weeks []calendarWeek
+}
+
+// height mengembalikan jumlah baris blok bulan: judul, jarak, header hari, lalu tiap minggu (tanggal + lane)
+func (m calendarMonth) height() int {
+	h := 3
+	for _, week := range m.weeks {
+		h += 1 + max(week.lanes, 1)
+	}
+	return h
+}
+
+// calendarStyles menyimpan style yang sudah dibuat agar tidak membuat style baru per sel
+type calendarStyles struct {
+	f     *excelize.File
+	cache map[string]int
+}
+
+func newCalendarStyles(f *excelize.File) *calendarStyles {
+	return &calendarStyles{f: f, cache: make(map[string]int)}
+}
+
+func (s *calendarStyles) get(key string, style *excelize.Style) (int, error) {
+	if id, ok := s.cache[key]; ok {
+		return id, nil
+	}
+	id, err := s.f.NewStyle(style)
+	if err != nil {
+		return 0, err
+	}
+	s.cache[key] = id
+	return id, nil
+}
+
+func normalizeColor(color string) string {
+	color = strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(color), "#"))
+	if len(color) != 6 {
+		return defaultEventColor
+	}
+	return color
+}
+
+func (s *calendarStyles) bar(color string) (int, error) {
+	color = normalizeColor(color)
+	return s.get("bar:"+color, &excelize.Style{
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{color}, Pattern: 1},
+		Font:      &excelize.Font{Size: 9, Color: "FFFFFF", Bold: true},
+		Alignment: &excelize.Alignment{Vertical: "center", WrapText: true},
+		Border: []excelize.Border{
+			{Type: "top", Color: "FFFFFF", Style: 1},
+			{Type: "bottom", Color: "FFFFFF", Style: 1},
+		},
+	})
+}
+
+func (s *calendarStyles) title() (int, error) {
+	return s.get("title", &excelize.Style{
+		Font: &excelize.Font{Color: "1f7f3b", Bold: true, Size: 22, Family: "Arial"},
+	})
+}
+
+func (s *calendarStyles) weekday() (int, error) {
+	return s.get("weekday", &excelize.Style{
+		Font:      &excelize.Font{Color: "1f7f3b", Size: 10, Bold: true, Family: "Arial"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"E6F4EA"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Vertical: "center", Horizontal: "center"},
+		Border:    []excelize.Border{{Type: "top", Style: 2, Color: "1f7f3b"}},
+	})
+}
+
+// date memberi border atas pada sel tanggal; tanggal di luar periode ditampilkan abu-abu, hari libur diarsir merah muda
+func (s *calendarStyles) date(inPeriod, holiday bool) (int, error) {
+	fontColor := "000000"
+	if holiday {
+		fontColor = holidayFontColor
+	}
+	if !inPeriod {
+		fontColor = "B0B0B0"
+	}
+	style := &excelize.Style{
+		Font: &excelize.Font{Color: fontColor, Size: 10},
+		Border: []excelize.Border{
+			{Type: "top", Style: 1, Color: "DADEE0"},
+			{Type: "left", Style: 1, Color: "DADEE0"},
+			{Type: "right", Style: 1, Color: "DADEE0"},
+		},
+	}
+	key := "date:" + fontColor
+	if holiday {
+		style.Fill = excelize.Fill{Type: "pattern", Color: []string{holidayFillColor}, Pattern: 1}
+		key += ":holiday"
+	}
+	return s.get(key, style)
+}
+
+// lane memberi border samping pada baris event, baris terakhir minggu juga diberi border bawah
+func (s *calendarStyles) lane(last, holiday bool) (int, error) {
+	borders := []excelize.Border{
+		{Type: "left", Style: 1, Color: "DADEE0"},
+		{Type: "right", Style: 1, Color: "DADEE0"},
+	}
+	key := "lane"
+	if last {
+		borders = append(borders, excelize.Border{Type: "bottom", Style: 1, Color: "DADEE0"})
+		key = "lane:last"
+	}
+	style := &excelize.Style{
+		Border:    borders,
+		Font:      &excelize.Font{Size: 9},
+		Alignment: &excelize.Alignment{WrapText: true},
+	}
+	if holiday {
+		style.Fill = excelize.Fill{Type: "pattern", Color: []string{holidayFillColor}, Pattern: 1}
+		style.Font.Color = holidayFontColor
+		key += ":holiday"
+	}
+	return s.get(key, style)
+}
+
+func (s *calendarStyles) legendTitle() (int, error) {
+	return s.get("legend", &excelize.Style{
+		Font: &excelize.Font{Color: "1f7f3b", Bold: true, Size: 12, Family: "Arial"},
+	})
+}
+
+func maxDate(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minDate(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// buildCalendarMonth memotong event per minggu lalu menyusunnya ke lane agar event yang tumpang tindih tidak saling menimpa
+func buildCalendarMonth(month time.Time, events []ExcelEvent, period CalendarPeriod) calendarMonth {
+	result := calendarMonth{month: month}
+	lastDay := month.AddDate(0, 1, -1)
+	periodLast := period.End.AddDate(0, 0, -1)
+
+	day := month
+	for !day.After(lastDay) {
+		var week calendarWeek
+		for d := int(day.Weekday()); d < 7 && !day.After(lastDay); d++ {
+			week.days[d] = day
+			day = day.AddDate(0, 0, 1)
+		}
+		result.weeks = append(result.weeks, week)
+	}
+
+	for w := range result.weeks {
+		week := &result.weeks[w]
+		var weekFirst, weekLast time.Time
+		for _, d := range week.days {
+			if d.IsZero() {
+				continue
+			}
+			if weekFirst.IsZero() {
+				weekFirst = d
+			}
+			weekLast = d
+		}
+
+		for _, event := range events {
+			startDay, endDay := EventDays(event)
+			visibleStart := maxDate(maxDate(startDay, weekFirst), period.Start)
+			visibleEnd := minDate(minDate(endDay, weekLast), periodLast)
+			if visibleStart.After(visibleEnd) {
+				continue
+			}
+			week.segments = append(week.segments, calendarSegment{
+				event:           event,
+				startCol:        int(visibleStart.Weekday()),
+				endCol:          int(visibleEnd.Weekday()),
+				continuesBefore: startDay.Before(visibleStart),
+				continuesAfter:  endDay.After(visibleEnd),
+			})
+		}
+
+		// Event yang lebih awal dan lebih panjang diletakkan di lane atas
+		sort.SliceStable(week.segments, func(i, j int) bool {
+			a, b := week.segments[i], week.segments[j]
+			if a.startCol != b.startCol {
+				return a.startCol < b.startCol
+			}
+			if a.endCol-a.startCol != b.endCol-b.startCol {
+				return a.endCol-a.startCol > b.endCol-b.startCol
+			}
+			return a.event.GetStart().Before(b.event.GetStart())
+		})
+
+		var laneEnds []int
+		for i := range week.segments {
+			segment := &week.segments[i]
+			segment.lane = -1
+			for lane, end := range laneEnds {
+				if end < segment.startCol {
+					segment.lane = lane
+					laneEnds[lane] = segment.endCol
+					break
+				}
+			}
+			if segment.lane == -1 {
+				segment.lane = len(laneEnds)
+				laneEnds = append(laneEnds, segment.endCol)
+			}
+		}
+		week.lanes = len(laneEnds)
+	}
+
+	return result
+}
+
+func calendarEventText(event ExcelEvent, config CalenderConfig) string {
+	text := event.GetTitle()
+	if config.UseResource {
+		if name := config.ResourceMap[event.GetResourceID()]; name != "" {
+			text = fmt.Sprintf("%s - %s", name, text)
+		}
+	} else if !event.GetAllDay() {
+		text = fmt.Sprintf("%s (%s - %s)", text, eventWallTime(event.GetStart()).Format("15:04"), eventWallTime(event.GetEnd()).Format("15:04"))
+	}
+	return text
+}
+
+func setCell(f *excelize.File, sheet string, col, row int, value interface{}, style int) error {
+	cell, err := excelize.CoordinatesToCellName(col, row)
+	if err != nil {
+		return err
+	}
+	if value != nil {
+		if err := f.SetCellValue(sheet, cell, value); err != nil {
+			return err
+		}
+	}
+	return f.SetCellStyle(sheet, cell, cell, style)
+}
+
+// renderCalendarMonth menggambar satu blok bulan mulai dari kolom B + colOffset
+func renderCalendarMonth(f *excelize.File, styles *calendarStyles, month calendarMonth, rowOffset, colOffset int, config CalenderConfig) error {
+	sheet := config.SheetName
+	firstCol := 2 + colOffset
+
+	titleStyle, err := styles.title()
+	if err != nil {
+		return err
+	}
+	if err := setCell(f, sheet, firstCol, 1+rowOffset, month.month.Format("January 2006"), titleStyle); err != nil {
+		return err
+	}
+	titleStart, _ := excelize.CoordinatesToCellName(firstCol, 1+rowOffset)
+	titleEnd, _ := excelize.CoordinatesToCellName(firstCol+2, 1+rowOffset)
+	if err := f.MergeCell(sheet, titleStart, titleEnd); err != nil {
+		return err
+	}
+	f.SetRowHeight(sheet, 1+rowOffset, 45)
+
+	weekdayStyle, err := styles.weekday()
+	if err != nil {
+		return err
+	}
+	for d, name := range calendarWeekdays {
+		if err := setCell(f, sheet, firstCol+d, 3+rowOffset, name, weekdayStyle); err != nil {
+			return err
+		}
+	}
+	f.SetRowHeight(sheet, 3+rowOffset, 22)
+
+	startColName, _ := excelize.ColumnNumberToName(firstCol)
+	endColName, _ := excelize.ColumnNumberToName(firstCol + 6)
+	if err := f.SetColWidth(sheet, startColName, endColName, 15); err != nil {
+		return err
+	}
+
+	row := 4 + rowOffset
+	for _, week := range month.weeks {
+		for d, date := range week.days {
+			holiday, isHoliday := config.Holidays[date.Format(EventDateLayout)]
+			isHoliday = isHoliday && !date.IsZero()
+			style, err := styles.date(date.IsZero() || config.Period.Contains(date), isHoliday)
+			if err != nil {
+				return err
+			}
+			var value interface{}
+			if isHoliday {
+				value = fmt.Sprintf("%d  %s", date.Day(), holiday)
+			} else if !date.IsZero() {
+				value = date.Day()
+			}
+			if err := setCell(f, sheet, firstCol+d, row, value, style); err != nil {
+				return err
+			}
+		}
+
+		lanes := max(week.lanes, 1)
+		for lane := 0; lane < lanes; lane++ {
+			for d, date := range week.days {
+				style, err := styles.lane(lane == lanes-1, !date.IsZero() && config.Holidays.IsHoliday(date))
+				if err != nil {
+					return err
+				}
+				if err := setCell(f, sheet, firstCol+d, row+1+lane, nil, style); err != nil {
+					return err
+				}
+			}
+			f.SetRowHeight(sheet, row+1+lane, 30)
+		}
+
+		for _, segment := range week.segments {
+			text := calendarEventText(segment.event, config)
+			if segment.continuesBefore {
+				text = "« " + text
+			}
+			if segment.continuesAfter {
+				text += " »"
+			}
+
+			style, err := styles.bar(segment.event.GetColor())
+			if err != nil {
+				return err
+			}
+			barRow := row + 1 + segment.lane
+			start, _ := excelize.CoordinatesToCellName(firstCol+segment.startCol, barRow)
+			end, _ := excelize.CoordinatesToCellName(firstCol+segment.endCol, barRow)
+			if segment.endCol > segment.startCol {
+				if err := f.MergeCell(sheet, start, end); err != nil {
+					return err
+				}
+			}
+			if err := f.SetCellValue(sheet, start, text); err != nil {
+				return err
+			}
+			if err := f.SetCellStyle(sheet, start, end, style); err != nil {
+				return err
+			}
+		}
+
+		row += 1 + lanes
+	}
+
+	return nil
+}
+
+type calendarLegendEntry struct {
+	color  string
+	label  string
+	titles []string
+}
+
+// calendarLegend mengelompokkan event berdasarkan kategori dan warna.
+// Tanpa kategori, nama resource atau judul event dipakai sebagai label.
+func calendarLegend(events []ExcelEvent, config CalenderConfig) []calendarLegendEntry {
+	var entries []calendarLegendEntry
+	index := make(map[string]int)
+
+	for _, event := range events {
+		color := normalizeColor(event.GetColor())
+		category := ""
+		if categorized, ok := event.(ExcelEventCategory); ok {
+			category = categorized.GetCategory()
+		} else if config.UseResource {
+			category = config.ResourceMap[event.GetResourceID()]
+		}
+
+		key := category + "|" + color
+		i, ok := index[key]
+		if !ok {
+			i = len(entries)
+			index[key] = i
+			entries = append(entries, calendarLegendEntry{color: color, label: category})
+		}
+		if category == "" && len(entries[i].titles) < 4 {
+			entries[i].titles = append(entries[i].titles, event.GetTitle())
+		}
+	}
+
+	for i := range entries {
+		if entries[i].label != "" {
+			continue
+		}
+		titles := entries[i].titles
+		if len(titles) > 3 {
+			titles = append(titles[:3:3], "...")
+		}
+		entries[i].label = strings.Join(titles, ", ")
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].label < entries[j].label })
+	return entries
+}
+
+func renderCalendarLegend(f *excelize.File, styles *calendarStyles, entries []calendarLegendEntry, rowOffset, col int, sheet string) error {
+	titleStyle, err := styles.legendTitle()
+	if err != nil {
+		return err
+	}
+	if err := setCell(f, sheet, col, 1+rowOffset, "KETERANGAN", titleStyle); err != nil {
+		return err
+	}
+
+	labelStyle, err := styles.lane(false, false)
+	if err != nil {
+		return err
+	}
+	for i, entry := range entries {
+		row := 3 + rowOffset + i
+		barStyle, err := styles.bar(entry.color)
+		if err != nil {
+			return err
+		}
+		if err := setCell(f, sheet, col, row, nil, barStyle); err != nil {
+			return err
+		}
+		if err := setCell(f, sheet, col+1, row, entry.label, labelStyle); err != nil {
+			return err
+		}
+	}
+
+	swatchCol, _ := excelize.ColumnNumberToName(col)
+	labelCol, _ := excelize.ColumnNumberToName(col + 1)
+	f.SetColWidth(sheet, swatchCol, swatchCol, 4)
+	f.SetColWidth(sheet, labelCol, labelCol, 35)
+	return nil
+}
+
+// setCalenderMonths menyusun blok bulan sesuai periode, tiga bulan per baris.
+// Tinggi tiap baris blok mengikuti bulan dengan lane terbanyak.
+func setCalenderMonths(f *excelize.File, config CalenderConfig) error {
+	if config.Period.IsZero() {
+		config.Period = YearPeriod(time.Now().In(jakartaLocation()).Year())
+	}
+	events := FilterEventsByPeriod(config.Events, config.Period)
+	styles := newCalendarStyles(f)
+
+	var months []calendarMonth
+	for _, month := range config.Period.Months() {
+		months = append(months, buildCalendarMonth(month, events, config.Period))
+	}
+
+	rowOffset := config.RowOffset
+	for i := 0; i < len(months); i += calendarMonthsPerRow {
+		blockHeight := 0
+		for j := i; j < i+calendarMonthsPerRow && j < len(months); j++ {
+			colOffset := config.ColOffset + (j-i)*calendarBlockCols
+			if err := renderCalendarMonth(f, styles, months[j], rowOffset, colOffset, config); err != nil {
+				return err
+			}
+			blockHeight = max(blockHeight, months[j].height())
+		}
+		rowOffset += blockHeight + calendarBlockGap
+	}
+
+	if config.ShowLegend {
+		legendCol := 2 + config.ColOffset + calendarMonthsPerRow*calendarBlockCols
+		if err := renderCalendarLegend(f, styles, calendarLegend(events, config), config.RowOffset, legendCol, config.SheetName); err != nil {
+			return err
+		}
+	}
+
+	// Sembunyikan gridlines agar kalender lebih mudah dibaca
+	disable := false
+	return f.SetSheetView(config.SheetName, 0, &excelize.ViewOptions{ShowGridLines: &disable})
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// testEvent adalah ExcelEvent sederhana untuk pengujian helper kalender
+type testEvent struct {
+	Title      string
+	Start, End time.Time
+	AllDay     bool
+}
+
+func (e testEvent) GetTitle() string    { return e.Title }
+func (e testEvent) GetStart() time.Time { return e.Start }
+func (e testEvent) GetEnd() time.Time   { return e.End }
+func (e testEvent) GetColor() string    { return "" }
+func (e testEvent) GetAllDay() bool     { return e.AllDay }
+func (e testEvent) GetResourceID() uint { return 0 }
+
+func wib(year int, month time.Month, day, hour, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, jakartaLocation())
+}
+
+func TestEventDays(t *testing.T) {
+	tests := []struct {
+		name        string
+		event       testEvent
+		first, last time.Time
+	}{
+		{
+			name:  "timed event within one day",
+			event: testEvent{Start: wib(2026, 3, 2, 9, 0), End: wib(2026, 3, 2, 10, 0)},
+			first: wib(2026, 3, 2, 0, 0), last: wib(2026, 3, 2, 0, 0),
+		},
+		{
+			name:  "timed event spanning days",
+			event: testEvent{Start: wib(2026, 3, 2, 22, 0), End: wib(2026, 3, 4, 1, 0)},
+			first: wib(2026, 3, 2, 0, 0), last: wib(2026, 3, 4, 0, 0),
+		},
+		{
+			// TimelineProject menyimpan tanggal sebagai YYYY-MM-DD 00:00:00 dengan akhir inklusif
+			name:  "timed event ending at midnight keeps its end day",
+			event: testEvent{Start: wib(2026, 3, 2, 0, 0), End: wib(2026, 3, 5, 0, 0)},
+			first: wib(2026, 3, 2, 0, 0), last: wib(2026, 3, 5, 0, 0),
+		},
+		{
+			name:  "all-day event has an exclusive end",
+			event: testEvent{Start: wib(2026, 3, 2, 0, 0), End: wib(2026, 3, 5, 0, 0), AllDay: true},
+			first: wib(2026, 3, 2, 0, 0), last: wib(2026, 3, 4, 0, 0),
+		},
+		{
+			name:  "single all-day event",
+			event: testEvent{Start: wib(2026, 3, 2, 0, 0), End: wib(2026, 3, 3, 0, 0), AllDay: true},
+			first: wib(2026, 3, 2, 0, 0), last: wib(2026, 3, 2, 0, 0),
+		},
+		{
+			name:  "end before start is clamped to the start day",
+			event: testEvent{Start: wib(2026, 3, 2, 9, 0), End: wib(2026, 3, 1, 9, 0)},
+			first: wib(2026, 3, 2, 0, 0), last: wib(2026, 3, 2, 0, 0),
+		},
+		{
+			name:  "times in another zone are read in WIB",
+			event: testEvent{Start: time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC), End: time.Date(2026, 3, 1, 21, 0, 0, 0, time.UTC)},
+			first: wib(2026, 3, 2, 0, 0), last: wib(2026, 3, 2, 0, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, last := EventDays(tt.event)
+			if !first.Equal(tt.first) || !last.Equal(tt.last) {
+				t.Errorf("EventDays() = %s, %s; want %s, %s", first.Format("2006-01-02"), last.Format("2006-01-02"),
+					tt.first.Format("2006-01-02"), tt.last.Format("2006-01-02"))
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/arkaramadhan/its-vo/common/models"
+	"github.com/jinzhu/inflection"
+	"gorm.io/gorm"
+)
+
+const (
+	// domainEventIDsKey menyimpan ID baris yang akan diubah/dihapus, dibaca sebelum query dijalankan
+	domainEventIDsKey = "domain_events:ids"
+	// maxDomainEventsPerStatement membatasi event dari satu query update/delete massal
+	maxDomainEventsPerStatement = 1000
+)
+
+// domainEventSkipTables tidak menghasilkan domain event karena isinya rahasia
+var domainEventSkipTables = map[string]bool{
+	"user.user_tokens": true,
+}
+
+// domainEventSecretFields dibuang dari data event, dicocokkan dengan nama field JSON tanpa membedakan huruf
+var domainEventSecretFields = []string{"password", "token", "secret"}
+
+// domainEventRecorder mencatat DomainEvent untuk setiap create, update, dan delete di satu service
+type domainEventRecorder struct {
+	service string // Juga schema default untuk table tanpa nama schema
+}
+
+// RegisterDomainEvents memasang callback gorm yang mencatat domain event <resource>.created, .updated, dan
+// .deleted untuk semua table service di db, kecuali schema common. Event ditulis ke common.domain_events
+// dalam transaksi yang sama sehingga perubahan yang di-rollback tidak menghasilkan event.
+func RegisterDomainEvents(db *gorm.DB, service string) {
+	recorder := domainEventRecorder{service: service}
+	callbacks := db.Callback()
+	errs := []error{
+		callbacks.Create().After("gorm:create").Before("gorm:commit_or_rollback_transaction").
+			Register("domain_events:create", func(tx *gorm.DB) { recorder.record(tx, "created") }),
+		callbacks.Update().Before("gorm:update").Register("domain_events:collect_update", recorder.collect),
+		callbacks.Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").
+			Register("domain_events:update", func(tx *gorm.DB) { recorder.record(tx, "updated") }),
+		callbacks.Delete().Before("gorm:delete").Register("domain_events:collect_delete", recorder.collect),
+		callbacks.Delete().After("gorm:delete").Before("gorm:commit_or_rollback_transaction").
+			Register("domain_events:delete", func(tx *gorm.DB) { recorder.record(tx, "deleted") }),
+	}
+	for _, err := range errs {
+		if err != nil {
+			log.Fatalf("Gagal memasang callback domain event: %v", err)
+		}
+	}
+}
+
+// RecordDomainEvent mencatat domain event di luar create/update/delete biasa, misal booking_rapat.approved.
+// Gunakan tx transaksi perubahan agar event ikut di-rollback.
+func RecordDomainEvent(tx *gorm.DB, service, resource, action string, resourceID uint, data interface{}) error {
+	event, err := newDomainEvent(service, resource, action, fmt.Sprint(resourceID), data)
+	if err != nil {
+		return err
+	}
+	return tx.Session(&gorm.Session{NewDB: true}).Table("common.domain_events").Create(&event).Error
+}
+
+// table mengembalikan nama table lengkap dengan schema
+func (r domainEventRecorder) table(stmt *gorm.Statement) string {
+	if stmt.TableExpr != nil && strings.Contains(stmt.TableExpr.SQL, ".") {
+		return strings.ReplaceAll(stmt.TableExpr.SQL, `"`, "")
+	}
+	if stmt.Table == "" || strings.Contains(stmt.Table, ".") {
+		return stmt.Table
+	}
+	return r.service + "." + stmt.Table
+}
+
+func (r domainEventRecorder) skip(table string) bool {
+	return table == "" || strings.HasPrefix(table, "common.") || domainEventSkipTables[table]
+}
+
+// collect membaca ID baris yang akan diubah atau dihapus dengan kondisi WHERE yang sama, untuk query yang
+// tidak membawa primary key di model, misal Where("id = ?", id).Delete(&models.X{})
+func (r domainEventRecorder) collect(tx *gorm.DB) {
+	stmt := tx.Statement
+	if tx.Error != nil || r.skip(r.table(stmt)) || len(domainEventIDs(stmt)) > 0 {
+		return
+	}
+	where, ok := stmt.Clauses["WHERE"]
+	if !ok || stmt.Schema == nil || stmt.Schema.PrioritizedPrimaryField == nil {
+		return
+	}
+	var ids []string
+	err := tx.Session(&gorm.Session{NewDB: true}).Table(r.table(stmt)).Clauses(where.Expression).
+		Limit(maxDomainEventsPerStatement).Pluck(stmt.Schema.PrioritizedPrimaryField.DBName, &ids).Error
+	if err != nil {
+		tx.AddError(fmt.Errorf("gagal membaca ID untuk domain event: %w", err))
+		return
+	}
+	tx.InstanceSet(domainEventIDsKey, ids)
+}
+
+// record mencatat satu event per baris yang dibuat, diubah, atau dihapus
+func (r domainEventRecorder) record(tx *gorm.DB, action string) {
+	stmt := tx.Statement
+	table := r.table(stmt)
+	if tx.Error != nil || stmt.RowsAffected == 0 || r.skip(table) {
+		return
+	}
+	service, name, _ := strings.Cut(table, ".")
+	resource := inflection.Singular(name)
+
+	var events []models.DomainEvent
+	add := func(id string, data interface{}) bool {
+		event, err := newDomainEvent(service, resource, action, id, data)
+		if err != nil {
+			tx.AddError(err)
+			return false
+		}
+		events = append(events, event)
+		return true
+	}
+
+	value := stmt.ReflectValue
+	switch {
+	case action == "created" && (value.Kind() == reflect.Slice || value.Kind() == reflect.Array):
+		for i := 0; i < value.Len() && i < maxDomainEventsPerStatement; i++ {
+			element := reflect.Indirect(value.Index(i))
+			if !add(domainEventID(stmt, element), element.Interface()) {
+				return
+			}
+		}
+	default:
+		ids, _ := tx.InstanceGet(domainEventIDsKey)
+		collected, _ := ids.([]string)
+		if len(collected) == 0 {
+			collected = domainEventIDs(stmt)
+		}
+		if len(collected) == 0 {
+			collected = []string{""}
+		}
+		// Update dengan map hanya membawa kolom yang diubah, bukan isi baris lengkap
+		var data interface{}
+		switch dest := reflect.Indirect(reflect.ValueOf(stmt.Dest)); {
+		case action == "deleted":
+		case dest.Kind() == reflect.Map:
+			data = map[string]interface{}{"changes": dest.Interface()}
+		case value.Kind() == reflect.Struct:
+			data = value.Interface()
+		}
+		for _, id := range collected {
+			payload := data
+			if payload == nil && id != "" {
+				payload = map[string]string{"id": id}
+			}
+			if !add(id, payload) {
+				return
+			}
+		}
+	}
+
+	if len(events) == 0 {
+		return
+	}
+	if err := tx.Session(&gorm.Session{NewDB: true}).Table("common.domain_events").Create(&events).Error; err != nil {
+		tx.AddError(fmt.Errorf("gagal mencatat domain event %s: %w", events[0].Type, err))
+	}
+}
+
+// domainEventIDs mengambil primary key dari model atau dest statement
+func domainEventIDs(stmt *gorm.Statement) []string {
+	var ids []string
+	for _, value := range []reflect.Value{stmt.ReflectValue, reflect.ValueOf(stmt.Model)} {
+		value = reflect.Indirect(value)
+		switch value.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < value.Len(); i++ {
+				if id := domainEventID(stmt, reflect.Indirect(value.Index(i))); id != "" {
+					ids = append(ids, id)
+				}
+			}
+		case reflect.Struct:
+			if id := domainEventID(stmt, value); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > 0 {
+			return ids
+		}
+	}
+	return nil
+}
+
+func domainEventID(stmt *gorm.Statement, value reflect.Value) string {
+	if stmt.Schema == nil || stmt.Schema.PrioritizedPrimaryField == nil || value.Kind() != reflect.Struct ||
+		value.Type() != stmt.Schema.ModelType {
+		return ""
+	}
+	id, zero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, value)
+	if zero {
+		return ""
+	}
+	return fmt.Sprint(id)
+}
+
+// newDomainEvent menyusun DomainEvent dan membuang field rahasia dari data
+func newDomainEvent(service, resource, action, resourceID string, data interface{}) (models.DomainEvent, error) {
+	eventID, err := newEventID()
+	if err != nil {
+		return models.DomainEvent{}, err
+	}
+	event := models.DomainEvent{
+		EventID:    eventID,
+		Type:       resource + "." + action,
+		Service:    service,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Action:     action,
+	}
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return models.DomainEvent{}, fmt.Errorf("gagal menyusun data domain event %s: %w", event.Type, err)
+		}
+		event.Data = string(redactDomainEventData(raw))
+	}
+	return event, nil
+}
+
+// redactDomainEventData membuang field seperti password dan token dari objek JSON
+func redactDomainEventData(raw []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return raw
+	}
+	for name := range fields {
+		for _, secret := range domainEventSecretFields {
+			if strings.Contains(strings.ToLower(name), secret) {
+				delete(fields, name)
+				break
+			}
+		}
+	}
+	if changes, ok := fields["changes"]; ok {
+		fields["changes"] = redactDomainEventData(changes)
+	}
+	redacted, err := json.Marshal(fields)
+	if err != nil {
+		return raw
+	}
+	return redacted
+}
+
+// newEventID membuat UUID versi 4
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
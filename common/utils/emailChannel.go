@@ -0,0 +1,339 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/models"
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed emailTemplates
+var emailTemplateFS embed.FS
+
+// defaultEmailTemplate dipakai untuk kategori tanpa template sendiri, misal JadwalRapat
+const defaultEmailTemplate = "Reminder"
+
+// EmailChannel mengirim notifikasi lewat SMTP dengan isi HTML dan teks biasa
+type EmailChannel struct {
+	Host        string
+	Port        string
+	Username    string
+	Password    string
+	From        string
+	ImplicitTLS bool // true untuk SMTPS (biasanya port 465), selain itu STARTTLS dipakai jika server mendukung
+	Timeout     time.Duration
+}
+
+// NewEmailChannel membaca SMTP_HOST, SMTP_PORT (default 25), SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM, dan
+// SMTP_TLS. false jika SMTP_HOST kosong. Untuk development arahkan ke SMTP sink lokal, misal mailhog:1025.
+func NewEmailChannel() (EmailChannel, bool) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return EmailChannel{}, false
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "25"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = "ITS VO <noreply@its-vo.local>"
+	}
+	return EmailChannel{
+		Host:        host,
+		Port:        port,
+		Username:    os.Getenv("SMTP_USERNAME"),
+		Password:    os.Getenv("SMTP_PASSWORD"),
+		From:        from,
+		ImplicitTLS: os.Getenv("SMTP_TLS") == "true",
+		Timeout:     30 * time.Second,
+	}, true
+}
+
+func (EmailChannel) Name() string { return "email" }
+
+func (ch EmailChannel) Send(job models.NotificationJob) error {
+	to, err := ResolveEmailRecipient(job.Recipient)
+	if err != nil {
+		return err
+	}
+	message, err := RenderNotificationEmail(job, to.Name)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotificationSkipped, err)
+	}
+	return ch.SendMail(to, message)
+}
+
+// EmailMessage adalah hasil render template email
+type EmailMessage struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// emailTemplateData adalah data yang tersedia di template email
+type emailTemplateData struct {
+	Category      string
+	Title         string
+	Subject       string
+	Start         string
+	Offset        string
+	RecipientName string
+	AppURL        string
+	Data          map[string]string        // Payload job pesan, misal status dan catatan persetujuan
+	Items         []NotificationDigestItem // Isi ringkasan harian
+}
+
+// ResolveEmailRecipient mengubah penerima job menjadi alamat email. Penerima bisa berupa alamat email atau
+// username/PIC yang dicari di tabel user.users.
+func ResolveEmailRecipient(recipient string) (*mail.Address, error) {
+	recipient = strings.TrimSpace(recipient)
+	if recipient == "" {
+		return nil, fmt.Errorf("%w: tidak ada penerima", ErrNotificationSkipped)
+	}
+	if strings.Contains(recipient, "@") {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return nil, fmt.Errorf("%w: alamat email %q tidak valid", ErrNotificationSkipped, recipient)
+		}
+		return address, nil
+	}
+
+	user, err := lookupRecipientUser(recipient)
+	if err != nil {
+		return nil, err
+	}
+	if user.Email == "" {
+		return nil, fmt.Errorf("%w: email untuk %q tidak ditemukan", ErrNotificationSkipped, recipient)
+	}
+	return &mail.Address{Name: user.Username, Address: user.Email}, nil
+}
+
+// RenderNotificationEmail merender subject, teks, dan HTML untuk job dari emailTemplates/<Category>.txt dan
+// .html. Kategori tanpa template memakai template Reminder.
+func RenderNotificationEmail(job models.NotificationJob, recipientName string) (EmailMessage, error) {
+	name := job.Category
+	if _, err := fs.Stat(emailTemplateFS, "emailTemplates/"+name+".txt"); err != nil {
+		name = defaultEmailTemplate
+	}
+
+	data := emailTemplateData{
+		Category:      job.Category,
+		Title:         job.Title,
+		Start:         job.EventStart.In(jakartaLocation()).Format("02-01-2006 15:04") + " WIB",
+		Offset:        formatNotificationOffset(job.OffsetMinutes),
+		RecipientName: recipientName,
+		AppURL:        os.Getenv("APP_URL"),
+		Data:          map[string]string{},
+	}
+	if job.Payload != "" {
+		if err := json.Unmarshal([]byte(job.Payload), &data.Data); err != nil {
+			return EmailMessage{}, err
+		}
+	}
+	if items := data.Data["items"]; items != "" {
+		if err := json.Unmarshal([]byte(items), &data.Items); err != nil {
+			return EmailMessage{}, err
+		}
+	}
+
+	textTemplate, err := template.ParseFS(emailTemplateFS, "emailTemplates/"+name+".txt")
+	if err != nil {
+		return EmailMessage{}, err
+	}
+	var subject, text, html bytes.Buffer
+	if err := textTemplate.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return EmailMessage{}, err
+	}
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return EmailMessage{}, err
+	}
+	data.Subject = strings.TrimSpace(subject.String())
+
+	htmlTemplate, err := htmltemplate.ParseFS(emailTemplateFS, "emailTemplates/layout.html", "emailTemplates/"+name+".html")
+	if err != nil {
+		return EmailMessage{}, err
+	}
+	if err := htmlTemplate.ExecuteTemplate(&html, "layout", data); err != nil {
+		return EmailMessage{}, err
+	}
+	return EmailMessage{Subject: data.Subject, Text: text.String(), HTML: html.String()}, nil
+}
+
+// buildEmail menyusun pesan MIME multipart/alternative berisi teks biasa dan HTML
+func buildEmail(from, to *mail.Address, message EmailMessage) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", message.Text},
+		{"text/html; charset=UTF-8", message.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := io.WriteString(qp, part.content); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	domain := "its-vo.local"
+	if _, host, found := strings.Cut(from.Address, "@"); found {
+		domain = host
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", to.String())
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", message.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// SendMail mengirim satu email ke to
+func (ch EmailChannel) SendMail(to *mail.Address, message EmailMessage) error {
+	from, err := mail.ParseAddress(ch.From)
+	if err != nil {
+		return fmt.Errorf("SMTP_FROM tidak valid: %v", err)
+	}
+	msg, err := buildEmail(from, to, message)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(ch.Host, ch.Port)
+	var conn net.Conn
+	if ch.ImplicitTLS {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: ch.Timeout}, "tcp", addr, &tls.Config{ServerName: ch.Host})
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, ch.Timeout)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(ch.Timeout))
+
+	client, err := smtp.NewClient(conn, ch.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if !ch.ImplicitTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: ch.Host}); err != nil {
+				return err
+			}
+		}
+	}
+	if ch.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", ch.Username, ch.Password, ch.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// SendTestEmail merender template kategori dengan data contoh lalu mengirimnya langsung, untuk memeriksa
+// konfigurasi SMTP dan tampilan template. Body: {"to": "alamat@email", "category": "BookingRapat"}
+func SendTestEmail(c *gin.Context) {
+	var body struct {
+		To       string `json:"to" binding:"required"`
+		Category string `json:"category"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	channel, ok := NewEmailChannel()
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "SMTP_HOST belum diatur"})
+		return
+	}
+	to, err := ResolveEmailRecipient(body.To)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	if body.Category == "" {
+		body.Category = defaultEmailTemplate
+	}
+
+	payload, _ := json.Marshal(map[string]string{
+		"type": "Contoh", "status": "disetujui", "actor": "admin", "comment": "Email uji coba",
+		"number": "001/ITS-SAG/M/2026", "date": time.Now().Format(EventDateLayout), "room": "Ruang Rapat", "resource": "Tim ITS",
+	})
+	job := models.NotificationJob{
+		Category:      body.Category,
+		Title:         "Email uji coba",
+		EventStart:    time.Now().Add(time.Hour),
+		OffsetMinutes: 60,
+		Payload:       string(payload),
+	}
+	message, err := RenderNotificationEmail(job, to.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	if err := channel.SendMail(to, message); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"message": "Gagal mengirim email: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email uji coba dikirim ke " + to.Address, "subject": message.Subject})
+}
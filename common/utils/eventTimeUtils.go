@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	EventDateLayout     = "2006-01-02"          // Start/End event AllDay
+	EventDateTimeLayout = "2006-01-02 15:04:05" // Start/End TimelineProject, tanpa zona (WIB)
+)
+
+// Format masukan yang diterima ParseEventTime. Nilai tanpa zona dibaca sebagai WIB.
+var eventTimeLayouts = []string{
+	time.RFC3339,
+	EventDateTimeLayout,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	EventDateLayout,
+}
+
+// EventTime adalah Start/End event kalender. Disimpan sebagai timestamptz dan selalu dibaca dalam WIB,
+// sedangkan JSON tetap memakai format string lama agar frontend tidak berubah. Layout menentukan format
+// keluaran; diisi dari format masukan saat bind JSON atau oleh hook AfterFind model.
+type EventTime struct {
+	time.Time
+	Layout string
+}
+
+// NewEventTime membuat EventTime dengan format model kegiatan: tanggal untuk AllDay, selain itu RFC3339
+func NewEventTime(t time.Time, allDay bool) EventTime {
+	event := EventTime{Time: t.In(jakartaLocation())}
+	event.SetAllDay(allDay)
+	return event
+}
+
+// ParseEventTime membaca Start/End dalam salah satu format lama
+func ParseEventTime(value string) (EventTime, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range eventTimeLayouts {
+		t, err := time.ParseInLocation(layout, value, jakartaLocation())
+		if err == nil {
+			return EventTime{Time: t.In(jakartaLocation()), Layout: layout}, nil
+		}
+	}
+	return EventTime{}, fmt.Errorf("format waktu tidak dikenali: %q", value)
+}
+
+// SetAllDay menyamakan format JSON dengan flag AllDay model
+func (t *EventTime) SetAllDay(allDay bool) {
+	if allDay {
+		t.Layout = EventDateLayout
+	} else {
+		t.Layout = time.RFC3339
+	}
+}
+
+func (t EventTime) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	layout := t.Layout
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return t.In(jakartaLocation()).Format(layout)
+}
+
+func (t EventTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *EventTime) UnmarshalJSON(data []byte) error {
+	var value string
+	if string(data) == "null" {
+		*t = EventTime{}
+		return nil
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if strings.TrimSpace(value) == "" {
+		*t = EventTime{}
+		return nil
+	}
+	parsed, err := ParseEventTime(value)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+func (t EventTime) Value() (driver.Value, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+	return t.Time, nil
+}
+
+func (t *EventTime) Scan(value interface{}) error {
+	layout := t.Layout
+	switch v := value.(type) {
+	case nil:
+		*t = EventTime{}
+	case time.Time:
+		t.Time = v.In(jakartaLocation())
+	case string:
+		parsed, err := ParseEventTime(v)
+		if err != nil {
+			return err
+		}
+		*t = parsed
+	case []byte:
+		parsed, err := ParseEventTime(string(v))
+		if err != nil {
+			return err
+		}
+		*t = parsed
+	default:
+		return fmt.Errorf("tipe %T tidak bisa dibaca sebagai EventTime", value)
+	}
+	if layout != "" {
+		t.Layout = layout
+	}
+	return nil
+}
+
+func (EventTime) GormDataType() string {
+	return "timestamptz"
+}
+
+// EventTimeMigrationFailure adalah nilai lama yang tidak bisa dibaca saat migrasi kolom waktu
+type EventTimeMigrationFailure struct {
+	Table  string
+	ID     uint
+	Column string
+	Value  string
+}
+
+// MigrateEventTimeColumns mengubah kolom waktu bertipe teks menjadi timestamptz. Nilai lama dibaca dengan
+// ParseEventTime; baris yang gagal dibaca dilaporkan dan kolom barunya dibiarkan NULL. Kolom teks asli
+// disimpan sebagai <kolom>_legacy agar bisa diperbaiki manual. Kolom yang sudah timestamptz dilewati,
+// sehingga migrasi aman dijalankan berulang. Jalankan sebelum AutoMigrate.
+func MigrateEventTimeColumns(db *gorm.DB, table string, columns ...string) ([]EventTimeMigrationFailure, error) {
+	var failures []EventTimeMigrationFailure
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, column := range columns {
+			var dataType string
+			err := tx.Raw(`SELECT data_type FROM information_schema.columns
+				WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?`, table, column).
+				Scan(&dataType).Error
+			if err != nil {
+				return err
+			}
+			if dataType == "" || dataType == "timestamp with time zone" {
+				continue
+			}
+
+			legacy := column + "_legacy"
+			if err := tx.Exec(fmt.Sprintf(`ALTER TABLE %q RENAME COLUMN %q TO %q`, table, column, legacy)).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(fmt.Sprintf(`ALTER TABLE %q ADD COLUMN %q timestamptz`, table, column)).Error; err != nil {
+				return err
+			}
+
+			var rows []struct {
+				ID    uint
+				Value string
+			}
+			err = tx.Raw(fmt.Sprintf(`SELECT id, %q::text AS value FROM %q WHERE %q IS NOT NULL AND %q::text <> ''`,
+				legacy, table, legacy, legacy)).Scan(&rows).Error
+			if err != nil {
+				return err
+			}
+
+			converted := 0
+			for _, row := range rows {
+				parsed, err := ParseEventTime(row.Value)
+				if err != nil {
+					log.Printf("Migrasi %s.%s: baris id %d tidak bisa dibaca (%q)", table, column, row.ID, row.Value)
+					failures = append(failures, EventTimeMigrationFailure{Table: table, ID: row.ID, Column: column, Value: row.Value})
+					continue
+				}
+				err = tx.Exec(fmt.Sprintf(`UPDATE %q SET %q = ? WHERE id = ?`, table, column), parsed.Time, row.ID).Error
+				if err != nil {
+					return err
+				}
+				converted++
+			}
+			log.Printf("Migrasi %s.%s: %d dari %d baris dikonversi ke timestamptz", table, column, converted, len(rows))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return failures, nil
+}
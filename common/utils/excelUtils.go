@@ -481,6 +481,15 @@ type CalenderConfig struct {
 	ResourceMap map[uint]string
 	RowOffset   int
 	ColOffset   int
+	Period      CalendarPeriod // Kosong berarti tahun berjalan
+	ShowLegend  bool           // Tambahkan keterangan warna di sebelah kanan kalender
+	Layout      CalendarLayout // Kosong berarti grid bulanan
+	Holidays    HolidaySet     // Hari libur yang diarsir, nil berarti dibaca dari common.holidays sesuai periode
+}
+
+// ExcelEventCategory opsional diimplementasikan event untuk label pada legend
+type ExcelEventCategory interface {
+	GetCategory() string
 }
 
 func ExportCalenderToExcel(c *gin.Context, config CalenderConfig) error {
@@ -488,19 +497,12 @@ func ExportCalenderToExcel(c *gin.Context, config CalenderConfig) error {
 	sheet := config.SheetName
 	f.NewSheet(sheet)
 
-	months := []string{
-		"January 2024", "February 2024", "March 2024", "April 2024",
-		"May 2024", "June 2024", "July 2024", "August 2024",
-		"September 2024", "October 2024", "November 2024", "December 2024",
+	if config.Period.IsZero() {
+		config.Period = YearPeriod(time.Now().In(jakartaLocation()).Year())
 	}
-
-	for i, month := range months {
-		setMonthData(f, sheet, month, config.RowOffset, config.ColOffset, config.Events, config.ResourceMap, config.UseResource)
-		config.ColOffset += 9
-		if (i+1)%3 == 0 {
-			config.RowOffset += 18
-			config.ColOffset = 0
-		}
+	if err := renderCalendar(f, config); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("Failed to build calendar: %v", err)})
+		return err
 	}
 
 	f.DeleteSheet("Sheet1")
@@ -511,7 +513,13 @@ func ExportCalenderToExcel(c *gin.Context, config CalenderConfig) error {
 		return err
 	}
 
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.xlsx", config.FileName))
+	// Nama file mengikuti layout dan periode, misal jadwal_cuti_2025.xlsx atau jadwal_rapat_week_2025.xlsx
+	fileName := strings.TrimSuffix(config.FileName, ".xlsx")
+	if config.Layout != "" && config.Layout != LayoutMonth {
+		fileName += "_" + string(config.Layout)
+	}
+	fileName = fmt.Sprintf("%s_%s.xlsx", fileName, config.Period.Label)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
 	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
 	c.Header("Content-Length", strconv.Itoa(len(buffer.Bytes())))
 	if _, err := c.Writer.Write(buffer.Bytes()); err != nil {
@@ -526,308 +534,5 @@ func ExportCalenderToSheet(f *excelize.File, config CalenderConfig) error {
 	// Buat sheet baru
 	f.NewSheet(config.SheetName)
 
-	months := []string{
-		"January 2024", "February 2024", "March 2024", "April 2024",
-		"May 2024", "June 2024", "July 2024", "August 2024",
-		"September 2024", "October 2024", "November 2024", "December 2024",
-	}
-	for i, month := range months {
-		err := setMonthData(f, config.SheetName, month, config.RowOffset, config.ColOffset, config.Events, config.ResourceMap, config.UseResource)
-		if err != nil {
-			return err
-		}
-		config.ColOffset += 9
-		if (i+1)%3 == 0 {
-			config.RowOffset += 18
-			config.ColOffset = 0
-		}
-	}
-
-	return nil
-}
-
-func setMonthData(f *excelize.File, sheet, month string, rowOffset, colOffset int, events []ExcelEvent, resourceMap map[uint]string, useResource bool) error {
-	var (
-		monthStyle, titleStyle, dataStyle, blankStyle int
-		err                                           error
-		addr                                          string
-	)
-
-	// Definisikan loc di sini
-	loc, err := time.LoadLocation("Asia/Jakarta")
-	if err != nil {
-		fmt.Printf("Error loading location: %v", err)
-		return err
-	}
-
-	monthTime, err := time.ParseInLocation("January 2006", month, loc)
-	if err != nil {
-		fmt.Println(err)
-		return err
-	}
-	// Get the first day of the month and the number of days in the month
-	firstDay := monthTime.Weekday()
-	daysInMonth := time.Date(monthTime.Year(), monthTime.Month()+1, 0, 0, 0, 0, 0, loc).Day()
-
-	// cell values
-	data := map[int][]interface{}{
-		1 + rowOffset: {month},
-		3 + rowOffset: {"MINGGU", "SENIN", "SELASA", "RABU",
-			"KAMIS", "JUMAT", "SABTU"},
-	}
-
-	// Fill in the dates
-	day := 1
-	for r := 4 + rowOffset; day <= daysInMonth; r += 2 {
-		week := make([]interface{}, 7) // Inisialisasi ulang array week untuk setiap baris baru
-		eventDetails := make([]interface{}, 7)
-		for d := 0; d < 7; d++ { // Mulai loop dari 0 hingga 6 (Minggu hingga Sabtu)
-			if r == 4+rowOffset && d < int(firstDay) {
-				// Jika ini adalah baris pertama dan hari ini sebelum 'firstDay', biarkan kosong
-				continue
-			}
-			if day <= daysInMonth {
-				week[d] = day // Isi tanggal
-
-				// Cek apakah ada event pada hari ini
-				for _, event := range events {
-					var startDate, endDate time.Time
-
-					startDate = time.Date(
-						event.GetStart().Year(),
-						event.GetStart().Month(),
-						event.GetStart().Day(),
-						0, 0, 0, 0,
-						loc,
-					)
-
-					if event.GetAllDay() {
-						// Untuk event AllDay, kurangi 1 hari dari tanggal akhir
-						endDate = time.Date(
-							event.GetEnd().Year(),
-							event.GetEnd().Month(),
-							event.GetEnd().Day(),
-							0, 0, 0, 0,
-							loc,
-						).AddDate(0, 0, -1) // Kurangi 1 hari
-					} else {
-						// Untuk event non-AllDay, gunakan tanggal akhir normal
-						endDate = time.Date(
-							event.GetEnd().Year(),
-							event.GetEnd().Month(),
-							event.GetEnd().Day(),
-							0, 0, 0, 0,
-							loc,
-						)
-					}
-
-					currentDate := time.Date(monthTime.Year(), monthTime.Month(), day, 0, 0, 0, 0, loc) // Pastikan waktu diatur ke 00:00:00
-
-					// Periksa apakah currentDate sama dengan startDate atau berada di antara startDate dan endDate
-					if currentDate.Equal(startDate) || (currentDate.After(startDate) && currentDate.Before(endDate.AddDate(0, 0, 1))) {
-						var eventDetail string
-						if event.GetAllDay() {
-							eventDetail = fmt.Sprintf("%s\nAllDay", event.GetTitle())
-						} else if useResource {
-							displayText := resourceMap[event.GetResourceID()] // Hanya tampilkan nama resource
-							// Cek jika sudah ada teks di sel tersebut, tambahkan dengan koma jika nama resource belum ada
-							if existingText, exists := week[d].(string); exists && existingText != "" {
-								if !strings.Contains(existingText, displayText) { // Cek apakah nama resource sudah ada
-									week[d] = fmt.Sprintf("%s, %s", existingText, displayText)
-								}
-							} else {
-								week[d] = fmt.Sprintf("%d %s", day, displayText) // Tampilkan tanggal hanya pada entri pertama
-							}
-
-							eventDetail = event.GetTitle()
-						} else {
-							startDate = event.GetStart()
-							endDate = event.GetEnd()
-							FormattedStart := startDate.Format("15:04")
-							FormattedEnd := endDate.Format("15:04")
-							// Format hanya jam dan menit dari tanggal
-							eventDetail = fmt.Sprintf("%s\n%s - %s", event.GetTitle(), FormattedStart, FormattedEnd)
-						}
-
-						// Gabungkan detail acara jika sudah ada
-						if eventDetails[d] != nil {
-							eventDetails[d] = fmt.Sprintf("%s\n%s", eventDetails[d], eventDetail)
-						} else {
-							eventDetails[d] = eventDetail
-						}
-						// Hanya terapkan warna untuk event pertama pada hari itu
-						if event.GetTitle() != "" && eventDetails[d] == eventDetail { // Perubahan kondisi di sini
-							cellAddr, _ := excelize.JoinCellName(string('B'+colOffset+int(d)), r+1)
-
-							// Buat gaya baru dengan warna latar belakang
-							style, err := f.NewStyle(&excelize.Style{
-								Fill: excelize.Fill{
-									Type:    "pattern",
-									Color:   []string{event.GetColor()},
-									Pattern: 1,
-								},
-								Font: &excelize.Font{
-									Size:  11,
-									Color: "FFFFFF",
-									Bold:  true,
-								},
-								Alignment: &excelize.Alignment{WrapText: true},
-								Border: []excelize.Border{
-									{Type: "left", Color: "DADEE0", Style: 1},
-									{Type: "right", Color: "DADEE0", Style: 1},
-									{Type: "top", Color: "DADEE0", Style: 1},
-									{Type: "bottom", Color: "DADEE0", Style: 1},
-								},
-							})
-							if err != nil {
-								fmt.Printf("Error membuat gaya untuk sel %s: %v\n", cellAddr, err)
-								continue
-							}
-
-							// Terapkan gaya ke sel
-							if err := f.SetCellStyle(sheet, cellAddr, cellAddr, style); err != nil {
-								fmt.Printf("Error menerapkan gaya ke sel %s: %v\n", cellAddr, err)
-							} else {
-								fmt.Printf("Berhasil menerapkan gaya dengan warna %s ke sel %s\n", event.GetColor(), cellAddr)
-							}
-						}
-					}
-				}
-
-				day++ // Increment day hanya jika hari ini diisi
-			}
-		}
-		data[r] = week
-		data[r+1] = eventDetails
-		if r == 4+rowOffset {
-			firstDay = 0 // Reset firstDay untuk minggu berikutnya
-		}
-	}
-
-	// custom rows height
-	height := map[int]float64{
-		1 + rowOffset: 45, 3 + rowOffset: 22, 5 + rowOffset: 30, 7 + rowOffset: 30,
-		9 + rowOffset: 30, 11 + rowOffset: 30, 13 + rowOffset: 30, 15 + rowOffset: 30,
-	}
-	top := excelize.Border{Type: "top", Style: 1, Color: "DADEE0"}
-	left := excelize.Border{Type: "left", Style: 1, Color: "DADEE0"}
-	right := excelize.Border{Type: "right", Style: 1, Color: "DADEE0"}
-	bottom := excelize.Border{Type: "bottom", Style: 1, Color: "DADEE0"}
-
-	// set each cell value
-	for r, row := range data {
-		if addr, err = excelize.JoinCellName(string('B'+colOffset), r); err != nil {
-			fmt.Println(err)
-			return err
-		}
-		if err = f.SetSheetRow(sheet, addr, &row); err != nil {
-			fmt.Println(err)
-			return err
-		}
-	}
-	// set custom row height
-	for r, ht := range height {
-		if err = f.SetRowHeight(sheet, r, ht); err != nil {
-			fmt.Println(err)
-			return err
-		}
-	}
-
-	// set custom column width
-	if err = f.SetColWidth(sheet, string('B'+colOffset), string('H'+colOffset), 15); err != nil {
-		fmt.Println(err)
-		return err
-	}
-
-	// merge cell for the 'MONTH'
-	if err = f.MergeCell(sheet, fmt.Sprintf("%s%d", string('B'+colOffset), 1+rowOffset), fmt.Sprintf("%s%d", string('D'+colOffset), 1+rowOffset)); err != nil {
-		fmt.Println(err)
-		return err
-	}
-
-	// define font style for the 'MONTH'
-	if monthStyle, err = f.NewStyle(&excelize.Style{
-		Font: &excelize.Font{Color: "1f7f3b", Bold: true, Size: 22, Family: "Arial"},
-	}); err != nil {
-		fmt.Println(err)
-		return err
-	}
-
-	// set font style for the 'MONTH'
-	if err = f.SetCellStyle(sheet, fmt.Sprintf("%s%d", string('B'+colOffset), 1+rowOffset), fmt.Sprintf("%s%d", string('D'+colOffset), 1+rowOffset), monthStyle); err != nil {
-		fmt.Println(err)
-		return err
-	}
-
-	// define style for the 'SUNDAY' to 'SATURDAY'
-	if titleStyle, err = f.NewStyle(&excelize.Style{
-		Font:      &excelize.Font{Color: "1f7f3b", Size: 10, Bold: true, Family: "Arial"},
-		Fill:      excelize.Fill{Type: "pattern", Color: []string{"E6F4EA"}, Pattern: 1},
-		Alignment: &excelize.Alignment{Vertical: "center", Horizontal: "center"},
-		Border:    []excelize.Border{{Type: "top", Style: 2, Color: "1f7f3b"}},
-	}); err != nil {
-		fmt.Println(err)
-		return err
-	}
-
-	// set style for the 'SUNDAY' to 'SATURDAY'
-	if err = f.SetCellStyle(sheet, fmt.Sprintf("%s%d", string('B'+colOffset), 3+rowOffset), fmt.Sprintf("%s%d", string('H'+colOffset), 3+rowOffset), titleStyle); err != nil {
-		fmt.Println(err)
-		return err
-	}
-
-	// define cell border for the date cell in the date range
-	if dataStyle, err = f.NewStyle(&excelize.Style{
-		Border: []excelize.Border{top, left, right},
-	}); err != nil {
-		fmt.Println(err)
-		return err
-	}
-
-	// set cell border for the date cell in the date range
-	for _, r := range []int{4, 6, 8, 10, 12, 14} {
-		if err = f.SetCellStyle(sheet, fmt.Sprintf("%s%d", string('B'+colOffset), r+rowOffset),
-			fmt.Sprintf("%s%d", string('H'+colOffset), r+rowOffset), dataStyle); err != nil {
-			fmt.Println(err)
-			return err
-		}
-	}
-
-	// define cell border for the blank cell in the date range
-	if blankStyle, err = f.NewStyle(&excelize.Style{
-		Border:    []excelize.Border{left, right, bottom},
-		Font:      &excelize.Font{Size: 9},
-		Alignment: &excelize.Alignment{WrapText: true},
-	}); err != nil {
-		fmt.Println(err)
-		return err
-	}
-
-	// set cell border for the blank cell in the date range, but only for cells that don't have a fill color
-	for _, r := range []int{5, 7, 9, 11, 13, 15} {
-		for c := 0; c < 7; c++ {
-			cellAddr, _ := excelize.JoinCellName(string('B'+colOffset+c), r+rowOffset)
-			if styleID, err := f.GetCellStyle(sheet, cellAddr); err != nil {
-				// Handle error
-				fmt.Println("Error mendapatkan gaya sel:", err)
-				return err
-			} else if styleID == 0 {
-				// Jika tidak ada gaya yang diterapkan, maka terapkan blankStyle
-				if err = f.SetCellStyle(sheet, cellAddr, cellAddr, blankStyle); err != nil {
-					fmt.Println("Error menerapkan blankStyle:", err)
-					return err
-				}
-			}
-		}
-	}
-
-	// hide gridlines for the worksheet
-	disable := false
-	if err := f.SetSheetView(sheet, 0, &excelize.ViewOptions{
-		ShowGridLines: &disable,
-	}); err != nil {
-		fmt.Println(err)
-		return err
-	}
-	return nil
+	return renderCalendar(f, config)
 }
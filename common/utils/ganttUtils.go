@@ -0,0 +1,496 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// GanttScale menentukan lebar satu kolom waktu pada Gantt
+type GanttScale string
+
+const (
+	GanttDay  GanttScale = "day"
+	GanttWeek GanttScale = "week"
+)
+
+// ganttAutoDayLimit adalah batas panjang periode (hari) untuk skala harian otomatis
+const ganttAutoDayLimit = 62
+
+const ganttHeaderRows = 3 // Judul, bulan, hari/minggu
+
+// GanttResource adalah baris pada Gantt, ParentID 0 berarti resource induk
+type GanttResource struct {
+	ID       uint
+	Name     string
+	ParentID uint
+}
+
+type GanttConfig struct {
+	SheetName   string
+	FileName    string
+	Events      []ExcelEvent // GetResourceID menunjuk ke GanttResource.ID
+	Resources   []GanttResource
+	Period      CalendarPeriod
+	Scale       GanttScale // Kosong berarti otomatis sesuai panjang periode
+	ShowSummary bool       // Tambahkan sheet ringkasan total hari per resource
+}
+
+// ParseGanttScale membaca query parameter scale (day atau week), kosong berarti otomatis
+func ParseGanttScale(c *gin.Context) (GanttScale, error) {
+	switch scale := GanttScale(c.Query("scale")); scale {
+	case "", GanttDay, GanttWeek:
+		return scale, nil
+	default:
+		return "", fmt.Errorf("scale tidak valid, gunakan day atau week")
+	}
+}
+
+type ganttRow struct {
+	resource GanttResource
+	depth    int
+	segments []calendarSegment // startCol/endCol di sini adalah indeks kolom waktu
+	laneRows int
+}
+
+// ganttColumns mengembalikan tanggal awal setiap kolom waktu
+func ganttColumns(period CalendarPeriod, scale GanttScale) []time.Time {
+	var columns []time.Time
+	if scale == GanttWeek {
+		for week := mondayOf(period.Start); week.Before(period.End); week = week.AddDate(0, 0, 7) {
+			columns = append(columns, week)
+		}
+		return columns
+	}
+	for day := period.Start; day.Before(period.End); day = day.AddDate(0, 0, 1) {
+		columns = append(columns, day)
+	}
+	return columns
+}
+
+func ganttColumnIndex(day time.Time, columns []time.Time, scale GanttScale) int {
+	if scale == GanttWeek {
+		return int(mondayOf(day).Sub(columns[0]).Hours() / 24 / 7)
+	}
+	return int(day.Sub(columns[0]).Hours() / 24)
+}
+
+// orderGanttResources mengurutkan resource secara hierarki: induk lalu anak-anaknya (depth-first)
+func orderGanttResources(resources []GanttResource) []ganttRow {
+	known := make(map[uint]bool, len(resources))
+	for _, resource := range resources {
+		known[resource.ID] = true
+	}
+
+	children := make(map[uint][]GanttResource)
+	for _, resource := range resources {
+		parent := resource.ParentID
+		// Induk yang sudah dihapus atau menunjuk diri sendiri dianggap root
+		if !known[parent] || parent == resource.ID {
+			parent = 0
+		}
+		children[parent] = append(children[parent], resource)
+	}
+	for parent := range children {
+		sort.SliceStable(children[parent], func(i, j int) bool {
+			return children[parent][i].Name < children[parent][j].Name
+		})
+	}
+
+	var rows []ganttRow
+	visited := make(map[uint]bool)
+	var walk func(parent uint, depth int)
+	walk = func(parent uint, depth int) {
+		for _, resource := range children[parent] {
+			if visited[resource.ID] {
+				continue
+			}
+			visited[resource.ID] = true
+			rows = append(rows, ganttRow{resource: resource, depth: depth})
+			walk(resource.ID, depth+1)
+		}
+	}
+	walk(0, 0)
+
+	// Resource dalam siklus induk-anak tetap ditampilkan sebagai root
+	for _, resource := range resources {
+		if !visited[resource.ID] {
+			visited[resource.ID] = true
+			rows = append(rows, ganttRow{resource: resource})
+			walk(resource.ID, 1)
+		}
+	}
+	return rows
+}
+
+// ganttVisibleDays memotong event ke periode, ok false jika tidak beririsan
+func ganttVisibleDays(event ExcelEvent, period CalendarPeriod) (time.Time, time.Time, bool) {
+	startDay, endDay := EventDays(event)
+	startDay = maxDate(startDay, period.Start)
+	endDay = minDate(endDay, period.End.AddDate(0, 0, -1))
+	return startDay, endDay, !startDay.After(endDay)
+}
+
+// assignGanttLanes menyusun bar setiap resource ke lane agar bar yang tumpang tindih tidak saling menimpa
+func assignGanttLanes(rows []ganttRow, events []ExcelEvent, columns []time.Time, config GanttConfig) {
+	index := make(map[uint]int, len(rows))
+	for i, row := range rows {
+		index[row.resource.ID] = i
+	}
+
+	for _, event := range events {
+		i, ok := index[event.GetResourceID()]
+		if !ok {
+			continue
+		}
+		startDay, endDay, ok := ganttVisibleDays(event, config.Period)
+		if !ok {
+			continue
+		}
+		eventStart, eventEnd := EventDays(event)
+		rows[i].segments = append(rows[i].segments, calendarSegment{
+			event:           event,
+			startCol:        ganttColumnIndex(startDay, columns, config.Scale),
+			endCol:          ganttColumnIndex(endDay, columns, config.Scale),
+			continuesBefore: eventStart.Before(startDay),
+			continuesAfter:  eventEnd.After(endDay),
+		})
+	}
+
+	for i := range rows {
+		segments := rows[i].segments
+		sort.SliceStable(segments, func(a, b int) bool {
+			if segments[a].startCol != segments[b].startCol {
+				return segments[a].startCol < segments[b].startCol
+			}
+			return segments[a].endCol > segments[b].endCol
+		})
+		var laneEnds []int
+		for s := range segments {
+			segments[s].lane = -1
+			for lane, end := range laneEnds {
+				if end < segments[s].startCol {
+					segments[s].lane = lane
+					laneEnds[lane] = segments[s].endCol
+					break
+				}
+			}
+			if segments[s].lane == -1 {
+				segments[s].lane = len(laneEnds)
+				laneEnds = append(laneEnds, segments[s].endCol)
+			}
+		}
+		rows[i].laneRows = max(len(laneEnds), 1)
+	}
+}
+
+func (s *calendarStyles) ganttResource(depth int) (int, error) {
+	style := &excelize.Style{
+		Alignment: &excelize.Alignment{Vertical: "center", Indent: depth * 2},
+		Border: []excelize.Border{
+			{Type: "top", Style: 1, Color: "DADEE0"},
+			{Type: "bottom", Style: 1, Color: "DADEE0"},
+			{Type: "right", Style: 1, Color: "DADEE0"},
+		},
+	}
+	if depth == 0 {
+		style.Font = &excelize.Font{Bold: true}
+		style.Fill = excelize.Fill{Type: "pattern", Color: []string{"E6F4EA"}, Pattern: 1}
+	}
+	return s.get("gantt:"+strconv.Itoa(depth), style)
+}
+
+func (s *calendarStyles) ganttGrid(weekend bool) (int, error) {
+	style := &excelize.Style{
+		Border: []excelize.Border{
+			{Type: "left", Style: 1, Color: "F0F0F0"},
+			{Type: "right", Style: 1, Color: "F0F0F0"},
+			{Type: "bottom", Style: 1, Color: "DADEE0"},
+		},
+	}
+	key := "grid"
+	if weekend {
+		style.Fill = excelize.Fill{Type: "pattern", Color: []string{"F5F5F5"}, Pattern: 1}
+		key = "grid:weekend"
+	}
+	return s.get(key, style)
+}
+
+// ExportGanttToSheet menulis Gantt ke sheet baru: satu baris per resource, kolom hari atau minggu
+func ExportGanttToSheet(f *excelize.File, config GanttConfig) error {
+	if config.Period.IsZero() {
+		config.Period = YearPeriod(time.Now().In(jakartaLocation()).Year())
+	}
+	if config.Scale == "" {
+		config.Scale = GanttWeek
+		if config.Period.End.Sub(config.Period.Start).Hours()/24 <= ganttAutoDayLimit {
+			config.Scale = GanttDay
+		}
+	}
+
+	sheet := config.SheetName
+	f.NewSheet(sheet)
+	styles := newCalendarStyles(f)
+
+	events := FilterEventsByPeriod(config.Events, config.Period)
+	columns := ganttColumns(config.Period, config.Scale)
+	rows := orderGanttResources(config.Resources)
+	assignGanttLanes(rows, events, columns, config)
+
+	titleStyle, err := styles.legendTitle()
+	if err != nil {
+		return err
+	}
+	headerStyle, err := styles.weekday()
+	if err != nil {
+		return err
+	}
+	if err := setCell(f, sheet, 1, 1, fmt.Sprintf("%s %s", config.SheetName, config.Period.Label), titleStyle); err != nil {
+		return err
+	}
+	if err := setCell(f, sheet, 1, 2, "RESOURCE", headerStyle); err != nil {
+		return err
+	}
+	if err := setCell(f, sheet, 1, 3, nil, headerStyle); err != nil {
+		return err
+	}
+	if err := f.MergeCell(sheet, "A2", "A3"); err != nil {
+		return err
+	}
+	f.SetColWidth(sheet, "A", "A", 30)
+
+	// Header bulan digabung selebar kolom yang termasuk bulan tersebut
+	monthStart := 0
+	for i, column := range columns {
+		col := 2 + i
+		label := strconv.Itoa(column.Day())
+		if config.Scale == GanttWeek {
+			_, week := column.ISOWeek()
+			label = fmt.Sprintf("W%02d\n%s", week, column.Format("02/01"))
+		}
+		if err := setCell(f, sheet, col, 3, label, headerStyle); err != nil {
+			return err
+		}
+
+		last := i == len(columns)-1
+		if last || columns[i+1].Month() != column.Month() {
+			start, _ := excelize.CoordinatesToCellName(2+monthStart, 2)
+			end, _ := excelize.CoordinatesToCellName(col, 2)
+			if err := f.SetCellValue(sheet, start, columns[monthStart].Format("January 2006")); err != nil {
+				return err
+			}
+			if err := f.SetCellStyle(sheet, start, end, headerStyle); err != nil {
+				return err
+			}
+			if col > 2+monthStart {
+				if err := f.MergeCell(sheet, start, end); err != nil {
+					return err
+				}
+			}
+			monthStart = i + 1
+		}
+	}
+
+	if len(columns) > 0 {
+		firstCol, _ := excelize.ColumnNumberToName(2)
+		lastCol, _ := excelize.ColumnNumberToName(1 + len(columns))
+		width := 4.0
+		if config.Scale == GanttWeek {
+			width = 7
+		}
+		f.SetColWidth(sheet, firstCol, lastCol, width)
+	}
+	f.SetRowHeight(sheet, 3, 28)
+
+	row := ganttHeaderRows + 1
+	for _, ganttRow := range rows {
+		resourceStyle, err := styles.ganttResource(ganttRow.depth)
+		if err != nil {
+			return err
+		}
+		if err := setCell(f, sheet, 1, row, ganttRow.resource.Name, resourceStyle); err != nil {
+			return err
+		}
+		if ganttRow.laneRows > 1 {
+			start, _ := excelize.CoordinatesToCellName(1, row)
+			end, _ := excelize.CoordinatesToCellName(1, row+ganttRow.laneRows-1)
+			f.SetCellStyle(sheet, start, end, resourceStyle)
+			if err := f.MergeCell(sheet, start, end); err != nil {
+				return err
+			}
+		}
+
+		for lane := 0; lane < ganttRow.laneRows; lane++ {
+			for i, column := range columns {
+				weekend := config.Scale == GanttDay && (column.Weekday() == time.Saturday || column.Weekday() == time.Sunday)
+				gridStyle, err := styles.ganttGrid(weekend)
+				if err != nil {
+					return err
+				}
+				if err := setCell(f, sheet, 2+i, row+lane, nil, gridStyle); err != nil {
+					return err
+				}
+			}
+			f.SetRowHeight(sheet, row+lane, 20)
+		}
+
+		for _, segment := range ganttRow.segments {
+			text := segment.event.GetTitle()
+			if segment.continuesBefore {
+				text = "« " + text
+			}
+			if segment.continuesAfter {
+				text += " »"
+			}
+			barStyle, err := styles.bar(segment.event.GetColor())
+			if err != nil {
+				return err
+			}
+			start, _ := excelize.CoordinatesToCellName(2+segment.startCol, row+segment.lane)
+			end, _ := excelize.CoordinatesToCellName(2+segment.endCol, row+segment.lane)
+			if segment.endCol > segment.startCol {
+				if err := f.MergeCell(sheet, start, end); err != nil {
+					return err
+				}
+			}
+			if err := f.SetCellValue(sheet, start, text); err != nil {
+				return err
+			}
+			if err := f.SetCellStyle(sheet, start, end, barStyle); err != nil {
+				return err
+			}
+		}
+
+		row += ganttRow.laneRows
+	}
+
+	// Kolom resource dan header tetap terlihat saat di-scroll
+	if err := f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		XSplit:      1,
+		YSplit:      ganttHeaderRows,
+		TopLeftCell: fmt.Sprintf("B%d", ganttHeaderRows+1),
+		ActivePane:  "bottomRight",
+	}); err != nil {
+		return err
+	}
+
+	if config.ShowSummary {
+		return exportGanttSummary(f, rows, config)
+	}
+	return nil
+}
+
+// GanttSummary adalah baris ringkasan alokasi per resource
+type GanttSummary struct {
+	Resource   string
+	Parent     string
+	Events     int
+	Days       int // Hari unik yang terisi dalam periode, event tumpang tindih tidak dihitung dua kali
+	FirstStart string
+	LastEnd    string
+}
+
+func (s GanttSummary) ToExcelRow() []interface{} {
+	return []interface{}{s.Resource, s.Parent, s.Events, s.Days, s.FirstStart, s.LastEnd}
+}
+
+func (s GanttSummary) GetDocType() string { return "" }
+
+func exportGanttSummary(f *excelize.File, rows []ganttRow, config GanttConfig) error {
+	names := make(map[uint]string, len(rows))
+	for _, row := range rows {
+		names[row.resource.ID] = row.resource.Name
+	}
+
+	var data []ExcelData
+	for _, row := range rows {
+		summary := GanttSummary{
+			Resource: strings.Repeat("  ", row.depth) + row.resource.Name,
+			Parent:   names[row.resource.ParentID],
+			Events:   len(row.segments),
+		}
+
+		days := make(map[time.Time]bool)
+		var first, last time.Time
+		for _, segment := range row.segments {
+			startDay, endDay, _ := ganttVisibleDays(segment.event, config.Period)
+			for day := startDay; !day.After(endDay); day = day.AddDate(0, 0, 1) {
+				days[day] = true
+			}
+			if first.IsZero() || startDay.Before(first) {
+				first = startDay
+			}
+			if endDay.After(last) {
+				last = endDay
+			}
+		}
+		summary.Days = len(days)
+		if !first.IsZero() {
+			summary.FirstStart = first.Format("2006-01-02")
+			summary.LastEnd = last.Format("2006-01-02")
+		}
+		data = append(data, summary)
+	}
+
+	return ExportToSheet(f, ExcelConfig{
+		SheetName: "RINGKASAN " + config.SheetName,
+		Columns: []ExcelColumn{
+			{Header: "Resource", Width: 30},
+			{Header: "Induk", Width: 25},
+			{Header: "Jumlah Kegiatan", Width: 18},
+			{Header: "Total Hari", Width: 12},
+			{Header: "Mulai", Width: 14},
+			{Header: "Selesai", Width: 14},
+		},
+		Data: data,
+		CustomStyles: &CustomStyles{
+			DefaultCellStyle: &excelize.Style{
+				Alignment: WrapAlignment,
+				Border:    BorderBlack,
+			},
+			DataAreaStyle: &excelize.Style{
+				Alignment: WrapAlignment,
+				Border:    BorderBlack,
+			},
+		},
+	})
+}
+
+// ExportGanttToExcel membuat file Gantt mandiri dan mengirimkannya ke response
+func ExportGanttToExcel(c *gin.Context, config GanttConfig) error {
+	if config.Period.IsZero() {
+		config.Period = YearPeriod(time.Now().In(jakartaLocation()).Year())
+	}
+
+	f := excelize.NewFile()
+	if err := ExportGanttToSheet(f, config); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("Failed to build gantt: %v", err)})
+		return err
+	}
+	f.DeleteSheet("Sheet1")
+
+	var buffer bytes.Buffer
+	if err := f.Write(&buffer); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("Failed to write Excel buffer: %v", err)})
+		return err
+	}
+
+	fileName := fmt.Sprintf("%s_%s.xlsx", strings.TrimSuffix(config.FileName, ".xlsx"), config.Period.Label)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Length", strconv.Itoa(buffer.Len()))
+	if _, err := c.Writer.Write(buffer.Bytes()); err != nil {
+		log.Printf("Error sending Excel file: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	"github.com/arkaramadhan/its-vo/common/models"
+)
+
+// HolidaySet memetakan tanggal (YYYY-MM-DD) ke nama hari libur
+type HolidaySet map[string]string
+
+// LoadHolidays membaca hari libur di antara from dan to (inklusif)
+func LoadHolidays(from, to time.Time) (HolidaySet, error) {
+	var holidays []models.Holiday
+	err := initializers.DB.Table("common.holidays").
+		Where("date BETWEEN ? AND ?", from.Format(EventDateLayout), to.Format(EventDateLayout)).
+		Find(&holidays).Error
+	if err != nil {
+		return nil, err
+	}
+	set := make(HolidaySet, len(holidays))
+	for _, holiday := range holidays {
+		set[holiday.Date.Format(EventDateLayout)] = holiday.Name
+	}
+	return set, nil
+}
+
+// IsHoliday mengembalikan true jika day adalah hari libur
+func (h HolidaySet) IsHoliday(day time.Time) bool {
+	_, ok := h[day.Format(EventDateLayout)]
+	return ok
+}
+
+// IsWorkingDay mengembalikan true untuk Senin–Jumat yang bukan hari libur
+func (h HolidaySet) IsWorkingDay(day time.Time) bool {
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return false
+	}
+	return !h.IsHoliday(day)
+}
+
+// CountWorkingDays menghitung hari kerja dari from sampai to (inklusif), hanya bagian tanggal yang dipakai
+func CountWorkingDays(from, to time.Time, holidays HolidaySet) int {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	count := 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if holidays.IsWorkingDay(day) {
+			count++
+		}
+	}
+	return count
+}
+
+// AddWorkingDays mengembalikan tanggal n hari kerja setelah from (n negatif berarti mundur), from sendiri
+// tidak dihitung dan jamnya dipertahankan. n nol mengembalikan from apa adanya.
+func (h HolidaySet) AddWorkingDays(from time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	day := from
+	for n > 0 {
+		day = day.AddDate(0, 0, step)
+		if h.IsWorkingDay(day) {
+			n--
+		}
+	}
+	return day
+}
+
+// AddWorkingDays seperti HolidaySet.AddWorkingDays dengan hari libur dibaca dari database.
+// Dipakai untuk tenggat seperti SLA disposisi atau target tanggal rapat.
+func AddWorkingDays(from time.Time, n int) (time.Time, error) {
+	span := abs(n)*2 + 14
+	for {
+		lo, hi := from, from.AddDate(0, 0, span)
+		if n < 0 {
+			lo, hi = from.AddDate(0, 0, -span), from
+		}
+		holidays, err := LoadHolidays(lo, hi)
+		if err != nil {
+			return time.Time{}, err
+		}
+		// Hasil di luar jendela berarti ada hari libur yang belum dibaca, ulangi dengan jendela lebih lebar
+		if day := holidays.AddWorkingDays(from, n); !day.Before(lo) && !day.After(hi) {
+			return day, nil
+		}
+		span *= 2
+	}
+}
+
+// WorkingDaysBetween menghitung hari kerja dari from sampai to (inklusif) dengan hari libur dari database
+func WorkingDaysBetween(from, to time.Time) (int, error) {
+	if to.Before(from) {
+		return 0, nil
+	}
+	holidays, err := LoadHolidays(from, to)
+	if err != nil {
+		return 0, err
+	}
+	return CountWorkingDays(from, to, holidays), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
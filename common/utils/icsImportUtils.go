@@ -0,0 +1,775 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	"github.com/arkaramadhan/its-vo/common/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	icsImportMaxEvents  = 1000
+	icsImportMaxSize    = 5 << 20 // 5 MB
+	icsImportPreviewTTL = 24 * time.Hour
+)
+
+// ICSImportEvent adalah satu kejadian hasil parsing file .ics, waktu sudah dalam WIB.
+// Event berulang dipecah per kejadian dengan UID "<uid seri>/<waktu mulai asli>".
+type ICSImportEvent struct {
+	UID         string    `json:"uid"`
+	SeriesUID   string    `json:"series_uid"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"` // Eksklusif, untuk AllDay berupa tanggal setelah hari terakhir
+	AllDay      bool      `json:"allDay"`
+	Recurring   bool      `json:"recurring"`
+	Duplicate   bool      `json:"duplicate"`
+	Conflict    bool      `json:"conflict"`
+}
+
+// FormatStart mengikuti format Start pada model kegiatan: "2006-01-02" untuk AllDay, selain itu RFC3339
+func (e ICSImportEvent) FormatStart() string {
+	if e.AllDay {
+		return e.Start.Format("2006-01-02")
+	}
+	return e.Start.Format(time.RFC3339)
+}
+
+func (e ICSImportEvent) FormatEnd() string {
+	if e.AllDay {
+		return e.End.Format("2006-01-02")
+	}
+	return e.End.Format(time.RFC3339)
+}
+
+// ICSImportTarget menghubungkan import .ics dengan modul tujuan (BookingRapat, JadwalRapat, MeetingSchedule)
+type ICSImportTarget struct {
+	Name      string
+	Exists    func(uid string) (bool, error)                             // De-duplikasi berdasarkan UID
+	Conflicts func(event ICSImportEvent) (bool, error)                   // Nil jika modul tidak memeriksa bentrok
+	Save      func(c *gin.Context, event ICSImportEvent) (string, error) // Mengembalikan status record yang dibuat (opsional)
+}
+
+// ICSImportResult adalah hasil konfirmasi import per event
+type ICSImportResult struct {
+	UID     string `json:"uid"`
+	Title   string `json:"title"`
+	Result  string `json:"result"` // imported, duplicate, failed
+	Status  string `json:"status,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type icsProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// unfoldICS membaca baris ICS dan menggabungkan baris lanjutan (diawali spasi/tab)
+func unfoldICS(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// splitICS memisahkan teks dengan sep yang berada di luar tanda kutip
+func splitICS(text string, sep rune) []string {
+	var parts []string
+	inQuote, last := false, 0
+	for i, ch := range text {
+		if ch == '"' {
+			inQuote = !inQuote
+		}
+		if ch == sep && !inQuote {
+			parts = append(parts, text[last:i])
+			last = i + 1
+		}
+	}
+	return append(parts, text[last:])
+}
+
+func parseICSProperty(line string) (icsProperty, bool) {
+	parts := splitICS(line, ':')
+	if len(parts) < 2 {
+		return icsProperty{}, false
+	}
+	head := splitICS(parts[0], ';')
+	prop := icsProperty{
+		Name:   strings.ToUpper(head[0]),
+		Params: map[string]string{},
+		Value:  strings.Join(parts[1:], ":"),
+	}
+	for _, param := range head[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return prop, true
+}
+
+// icsUnescape kebalikan dari icsEscape
+func icsUnescape(text string) string {
+	var b strings.Builder
+	escaped := false
+	for _, ch := range text {
+		switch {
+		case escaped && (ch == 'n' || ch == 'N'):
+			b.WriteRune('\n')
+			escaped = false
+		case escaped:
+			b.WriteRune(ch)
+			escaped = false
+		case ch == '\\':
+			escaped = true
+		default:
+			b.WriteRune(ch)
+		}
+	}
+	return b.String()
+}
+
+// windowsTimeZones memetakan nama zona Windows yang dipakai Outlook ke nama IANA
+var windowsTimeZones = map[string]string{
+	"SE Asia Standard Time":          "Asia/Jakarta",
+	"Singapore Standard Time":        "Asia/Singapore",
+	"China Standard Time":            "Asia/Shanghai",
+	"Taipei Standard Time":           "Asia/Taipei",
+	"Tokyo Standard Time":            "Asia/Tokyo",
+	"Korea Standard Time":            "Asia/Seoul",
+	"India Standard Time":            "Asia/Kolkata",
+	"Arabian Standard Time":          "Asia/Dubai",
+	"W. Australia Standard Time":     "Australia/Perth",
+	"AUS Eastern Standard Time":      "Australia/Sydney",
+	"UTC":                            "UTC",
+	"Greenwich Standard Time":        "Atlantic/Reykjavik",
+	"GMT Standard Time":              "Europe/London",
+	"W. Europe Standard Time":        "Europe/Berlin",
+	"Romance Standard Time":          "Europe/Paris",
+	"Central Europe Standard Time":   "Europe/Budapest",
+	"Eastern Standard Time":          "America/New_York",
+	"Central Standard Time":          "America/Chicago",
+	"Mountain Standard Time":         "America/Denver",
+	"Pacific Standard Time":          "America/Los_Angeles",
+	"E. South America Standard Time": "America/Sao_Paulo",
+}
+
+// utcOffsetTZID menangkap TZID gaya Outlook seperti "(UTC+07:00) Bangkok, Hanoi, Jakarta"
+var utcOffsetTZID = regexp.MustCompile(`^\((?:UTC|GMT)([+-])(\d{2}):(\d{2})\)`)
+
+// icsTimezones berisi zona dari komponen VTIMEZONE (hanya offset STANDARD) sebagai cadangan
+type icsTimezones map[string]*time.Location
+
+func (tz icsTimezones) location(tzid string) *time.Location {
+	tzid = strings.TrimPrefix(strings.Trim(tzid, `"`), "/")
+	if tzid == "" || tzid == "Local" {
+		return jakartaLocation()
+	}
+	if loc, err := time.LoadLocation(tzid); err == nil {
+		return loc
+	}
+	if name, ok := windowsTimeZones[tzid]; ok {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	if loc, ok := tz[tzid]; ok {
+		return loc
+	}
+	if m := utcOffsetTZID.FindStringSubmatch(tzid); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3])
+		offset := hours*3600 + minutes*60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(tzid, offset)
+	}
+	log.Printf("TZID %q tidak dikenal, dianggap Asia/Jakarta", tzid)
+	return jakartaLocation()
+}
+
+// parseTime membaca nilai DATE atau DATE-TIME. Waktu dikembalikan di zona aslinya agar RRULE
+// mempertahankan jam dinding zona tersebut, konversi ke WIB dilakukan setelah ekspansi.
+func (tz icsTimezones) parseTime(value string, params map[string]string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, jakartaLocation())
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, tz.location(params["TZID"]))
+	return t, false, err
+}
+
+// parseTimeList membaca EXDATE/RDATE yang bisa berisi beberapa nilai dipisah koma
+func (tz icsTimezones) parseTimeList(prop icsProperty) ([]time.Time, error) {
+	var result []time.Time
+	for _, value := range strings.Split(prop.Value, ",") {
+		if prop.Params["VALUE"] == "PERIOD" {
+			value, _, _ = strings.Cut(value, "/")
+		}
+		t, _, err := tz.parseTime(value, prop.Params)
+		if err != nil {
+			return nil, fmt.Errorf("%s %q tidak valid", prop.Name, value)
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+var icsDurationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseICSDuration membaca DURATION, hari dipisah agar event AllDay tetap jatuh pada tanggal yang benar
+func parseICSDuration(value string) (int, time.Duration, error) {
+	m := icsDurationPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return 0, 0, fmt.Errorf("DURATION %q tidak valid", value)
+	}
+	n := func(s string) int {
+		v, _ := strconv.Atoi(s)
+		return v
+	}
+	days := n(m[2])*7 + n(m[3])
+	duration := time.Duration(n(m[4]))*time.Hour + time.Duration(n(m[5]))*time.Minute + time.Duration(n(m[6]))*time.Second
+	if m[1] == "-" {
+		return -days, -duration, nil
+	}
+	return days, duration, nil
+}
+
+type icsRawEvent struct {
+	uid, summary, description, location string
+	cancelled                           bool
+	start, end                          time.Time
+	allDay                              bool
+	rrule                               string
+	rdates, exdates                     []time.Time
+	recurrenceID                        time.Time
+}
+
+func (tz icsTimezones) buildEvent(props []icsProperty) (*icsRawEvent, error) {
+	event := &icsRawEvent{}
+	var endProp, durationProp *icsProperty
+	hasStart := false
+	for i := range props {
+		prop := props[i]
+		var err error
+		switch prop.Name {
+		case "UID":
+			event.uid = strings.TrimSpace(prop.Value)
+		case "SUMMARY":
+			event.summary = icsUnescape(prop.Value)
+		case "DESCRIPTION":
+			event.description = icsUnescape(prop.Value)
+		case "LOCATION":
+			event.location = icsUnescape(prop.Value)
+		case "STATUS":
+			event.cancelled = strings.EqualFold(prop.Value, "CANCELLED")
+		case "DTSTART":
+			if event.start, event.allDay, err = tz.parseTime(prop.Value, prop.Params); err != nil {
+				return nil, fmt.Errorf("DTSTART %q tidak valid", prop.Value)
+			}
+			hasStart = true
+		case "DTEND":
+			endProp = &props[i]
+		case "DURATION":
+			durationProp = &props[i]
+		case "RRULE":
+			event.rrule = prop.Value
+		case "RDATE":
+			dates, err := tz.parseTimeList(prop)
+			if err != nil {
+				return nil, err
+			}
+			event.rdates = append(event.rdates, dates...)
+		case "EXDATE":
+			dates, err := tz.parseTimeList(prop)
+			if err != nil {
+				return nil, err
+			}
+			event.exdates = append(event.exdates, dates...)
+		case "RECURRENCE-ID":
+			if event.recurrenceID, _, err = tz.parseTime(prop.Value, prop.Params); err != nil {
+				return nil, fmt.Errorf("RECURRENCE-ID %q tidak valid", prop.Value)
+			}
+		}
+	}
+	if !hasStart {
+		return nil, fmt.Errorf("event %q tidak memiliki DTSTART", event.summary)
+	}
+	if event.uid == "" {
+		sum := sha1.Sum([]byte(event.summary + "|" + event.start.UTC().Format(time.RFC3339)))
+		event.uid = hex.EncodeToString(sum[:8]) + "@import"
+	}
+
+	switch {
+	case endProp != nil:
+		end, _, err := tz.parseTime(endProp.Value, endProp.Params)
+		if err != nil {
+			return nil, fmt.Errorf("DTEND %q tidak valid", endProp.Value)
+		}
+		event.end = end
+	case durationProp != nil:
+		days, duration, err := parseICSDuration(durationProp.Value)
+		if err != nil {
+			return nil, err
+		}
+		event.end = event.start.AddDate(0, 0, days).Add(duration)
+	case event.allDay:
+		event.end = event.start.AddDate(0, 0, 1)
+	default:
+		event.end = event.start
+	}
+	if event.end.Before(event.start) {
+		event.end = event.start
+	}
+	return event, nil
+}
+
+// occurrenceKey menandai satu kejadian dari sebuah seri berdasarkan waktu mulai aslinya
+func occurrenceKey(t time.Time, allDay bool) string {
+	if allDay {
+		return t.Format("20060102")
+	}
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// toImportEvent mengonversi kejadian ke WIB. Untuk AllDay tanggal dipertahankan apa adanya.
+func (e *icsRawEvent) toImportEvent(uid, seriesUID string, start, end time.Time, recurring bool) ICSImportEvent {
+	loc := jakartaLocation()
+	if e.allDay {
+		start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+		end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, loc)
+		if !end.After(start) {
+			end = start.AddDate(0, 0, 1)
+		}
+	} else {
+		start, end = start.In(loc), end.In(loc)
+	}
+	return ICSImportEvent{
+		UID:         uid,
+		SeriesUID:   seriesUID,
+		Title:       e.summary,
+		Description: e.description,
+		Location:    e.location,
+		Start:       start,
+		End:         end,
+		AllDay:      e.allDay,
+		Recurring:   recurring,
+	}
+}
+
+// expand memecah satu event master menjadi kejadian di dalam [from, to) dengan menerapkan
+// RRULE, RDATE, EXDATE dan override (event dengan RECURRENCE-ID yang sama)
+func (e *icsRawEvent) expand(overrides map[string]*icsRawEvent, from, to time.Time) ([]ICSImportEvent, error) {
+	if e.rrule == "" && len(e.rdates) == 0 {
+		if e.cancelled {
+			return nil, nil
+		}
+		return []ICSImportEvent{e.toImportEvent(e.uid, e.uid, e.start, e.end, false)}, nil
+	}
+
+	var starts []time.Time
+	if e.rrule != "" {
+		rule, err := ParseRRule(e.rrule, e.start.Location())
+		if err != nil {
+			return nil, fmt.Errorf("RRULE event %q: %v", e.summary, err)
+		}
+		starts = rule.Between(e.start, from, to, icsImportMaxEvents+1)
+	} else if !e.start.Before(from) && e.start.Before(to) {
+		starts = append(starts, e.start)
+	}
+	for _, rdate := range e.rdates {
+		if !rdate.Before(from) && rdate.Before(to) {
+			starts = append(starts, rdate)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	excluded := make(map[string]bool, len(e.exdates))
+	for _, exdate := range e.exdates {
+		excluded[occurrenceKey(exdate, e.allDay)] = true
+	}
+
+	days := dayDiff(e.start, e.end)
+	duration := e.end.Sub(e.start)
+	var result []ICSImportEvent
+	for _, start := range starts {
+		key := occurrenceKey(start, e.allDay)
+		if excluded[key] {
+			continue
+		}
+		excluded[key] = true // RDATE yang sama dengan hasil RRULE tidak digandakan
+		uid := e.uid + "/" + key
+
+		if override, ok := overrides[key]; ok {
+			if !override.cancelled {
+				result = append(result, override.toImportEvent(uid, e.uid, override.start, override.end, true))
+			}
+			continue
+		}
+		end := start.Add(duration)
+		if e.allDay {
+			end = start.AddDate(0, 0, days)
+		}
+		result = append(result, e.toImportEvent(uid, e.uid, start, end, true))
+	}
+	return result, nil
+}
+
+// ParseICS membaca VEVENT dari file .ics. Event berulang hanya dipecah untuk kejadian di [from, to),
+// event tunggal selalu diikutkan. Semua waktu dikonversi ke WIB.
+func ParseICS(r io.Reader, from, to time.Time) ([]ICSImportEvent, error) {
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, fmt.Errorf("bukan file iCalendar")
+	}
+
+	tz := icsTimezones{}
+	var stack []string
+	var tzid string
+	var current []icsProperty
+	var rawEvents [][]icsProperty
+	for _, line := range lines {
+		prop, ok := parseICSProperty(line)
+		if !ok {
+			continue
+		}
+		switch prop.Name {
+		case "BEGIN":
+			component := strings.ToUpper(prop.Value)
+			stack = append(stack, component)
+			if component == "VEVENT" {
+				current = nil
+			}
+			continue
+		case "END":
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("END:%s tanpa BEGIN", prop.Value)
+			}
+			if stack[len(stack)-1] == "VEVENT" {
+				rawEvents = append(rawEvents, current)
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		if len(stack) == 0 {
+			continue
+		}
+
+		switch stack[len(stack)-1] {
+		case "VEVENT":
+			current = append(current, prop)
+		case "VTIMEZONE":
+			if prop.Name == "TZID" {
+				tzid = prop.Value
+			}
+		case "STANDARD":
+			if _, seen := tz[tzid]; prop.Name == "TZOFFSETTO" && tzid != "" && !seen {
+				if offset, err := time.Parse("-0700", strings.TrimSpace(prop.Value)); err == nil {
+					_, seconds := offset.Zone()
+					tz[tzid] = time.FixedZone(tzid, seconds)
+				}
+			}
+		}
+	}
+
+	// Pisahkan event master dan override kejadian (RECURRENCE-ID)
+	var order []string
+	masters := map[string]*icsRawEvent{}
+	overrides := map[string]map[string]*icsRawEvent{}
+	for _, props := range rawEvents {
+		event, err := tz.buildEvent(props)
+		if err != nil {
+			return nil, err
+		}
+		if event.recurrenceID.IsZero() {
+			if _, exists := masters[event.uid]; !exists {
+				order = append(order, event.uid)
+			}
+			masters[event.uid] = event
+			continue
+		}
+		if overrides[event.uid] == nil {
+			overrides[event.uid] = map[string]*icsRawEvent{}
+		}
+		overrides[event.uid][occurrenceKey(event.recurrenceID, event.allDay)] = event
+	}
+
+	var events []ICSImportEvent
+	for _, uid := range order {
+		expanded, err := masters[uid].expand(overrides[uid], from, to)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, expanded...)
+		if len(events) > icsImportMaxEvents {
+			return nil, fmt.Errorf("file berisi lebih dari %d kejadian, persempit rentang tanggal import", icsImportMaxEvents)
+		}
+	}
+	// Override tanpa master (misal undangan satu kejadian dari seri orang lain) diimport sebagai event tunggal
+	for uid, byKey := range overrides {
+		if _, ok := masters[uid]; ok {
+			continue
+		}
+		for key, event := range byKey {
+			if !event.cancelled {
+				events = append(events, event.toImportEvent(uid+"/"+key, uid, event.start, event.end, true))
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	return events, nil
+}
+
+// icsImportWindow membaca rentang ekspansi event berulang dari form "from" dan "until" (YYYY-MM-DD, inklusif).
+// Default dari hari ini sampai satu tahun ke depan, maksimal tiga tahun.
+func icsImportWindow(c *gin.Context) (time.Time, time.Time, error) {
+	loc := jakartaLocation()
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if value := c.PostForm("from"); value != "" {
+		t, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return from, from, fmt.Errorf("format from harus YYYY-MM-DD")
+		}
+		from = t
+	}
+	to := from.AddDate(1, 0, 0)
+	if value := c.PostForm("until"); value != "" {
+		t, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return from, to, fmt.Errorf("format until harus YYYY-MM-DD")
+		}
+		to = t.AddDate(0, 0, 1)
+	}
+	if !to.After(from) {
+		return from, to, fmt.Errorf("until harus setelah from")
+	}
+	if to.After(from.AddDate(3, 0, 0)) {
+		return from, to, fmt.Errorf("rentang import maksimal 3 tahun")
+	}
+	return from, to, nil
+}
+
+// PreviewICSImport mem-parsing file .ics dari form "file", menandai event duplikat dan bentrok,
+// lalu menyimpan preview yang bisa dikonfirmasi lewat ConfirmICSImport dalam 24 jam
+func PreviewICSImport(c *gin.Context, target ICSImportTarget) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "File .ics wajib diunggah pada field file")
+		return
+	}
+	defer file.Close()
+
+	if !strings.EqualFold(filepath.Ext(header.Filename), ".ics") {
+		RespondError(c, http.StatusBadRequest, "File harus berekstensi .ics")
+		return
+	}
+	if header.Size > icsImportMaxSize {
+		RespondError(c, http.StatusRequestEntityTooLarge, "Ukuran file .ics maksimal 5 MB")
+		return
+	}
+
+	from, to, err := icsImportWindow(c)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, err := ParseICS(io.LimitReader(file, icsImportMaxSize), from, to)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "File .ics tidak valid: "+err.Error())
+		return
+	}
+	if len(events) == 0 {
+		RespondError(c, http.StatusBadRequest, "Tidak ada event yang bisa diimport dari file ini")
+		return
+	}
+
+	duplicates, conflicts := 0, 0
+	for i := range events {
+		if events[i].Duplicate, err = target.Exists(events[i].UID); err != nil {
+			RespondError(c, http.StatusInternalServerError, "Gagal memeriksa duplikat: "+err.Error())
+			return
+		}
+		if events[i].Duplicate {
+			duplicates++
+			continue
+		}
+		if target.Conflicts != nil {
+			if events[i].Conflict, err = target.Conflicts(events[i]); err != nil {
+				RespondError(c, http.StatusInternalServerError, "Gagal memeriksa bentrok: "+err.Error())
+				return
+			}
+			if events[i].Conflict {
+				conflicts++
+			}
+		}
+	}
+
+	payload, err := json.Marshal(events)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal menyimpan preview: "+err.Error())
+		return
+	}
+
+	// Bersihkan preview lama yang tidak pernah dikonfirmasi
+	if err := initializers.DB.Table("common.ics_imports").
+		Where("status = ? AND created_at < ?", "preview", time.Now().Add(-icsImportPreviewTTL)).
+		Delete(&models.ICSImport{}).Error; err != nil {
+		log.Printf("Error deleting expired ics imports: %v", err)
+	}
+
+	preview := models.ICSImport{
+		Target:   target.Name,
+		FileName: header.Filename,
+		Events:   string(payload),
+		Status:   "preview",
+		CreateBy: c.GetString("username"),
+	}
+	if err := initializers.DB.Table("common.ics_imports").Create(&preview).Error; err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal menyimpan preview: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "preview import berhasil dibuat, konfirmasi untuk menyimpan event",
+		"import":  preview,
+		"events":  events,
+		"summary": gin.H{
+			"total":     len(events),
+			"duplicate": duplicates,
+			"conflict":  conflicts,
+			"from":      from.Format("2006-01-02"),
+			"until":     to.AddDate(0, 0, -1).Format("2006-01-02"),
+		},
+	})
+}
+
+// ConfirmICSImport menyimpan event dari preview :id. Body opsional {"uids": [...]} untuk memilih event,
+// tanpa body semua event non-duplikat diimport. Duplikat diperiksa ulang saat konfirmasi.
+func ConfirmICSImport(c *gin.Context, target ICSImportTarget) {
+	var requestBody struct {
+		UIDs []string `json:"uids"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			RespondError(c, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+	}
+
+	var preview models.ICSImport
+	if err := initializers.DB.Table("common.ics_imports").
+		Where("id = ? AND target = ?", c.Param("id"), target.Name).
+		First(&preview).Error; err != nil {
+		RespondError(c, http.StatusNotFound, "preview import tidak ditemukan")
+		return
+	}
+	if preview.CreateBy != c.GetString("username") && c.GetString("role") != "admin" {
+		RespondError(c, http.StatusForbidden, "preview import milik user lain")
+		return
+	}
+	if time.Since(preview.CreatedAt) > icsImportPreviewTTL {
+		RespondError(c, http.StatusGone, "preview import sudah kedaluwarsa, unggah ulang file .ics")
+		return
+	}
+
+	// Preview dibaca sebelum diklaim agar kegagalan di sini tidak meninggalkan status importing
+	var events []ICSImportEvent
+	if err := json.Unmarshal([]byte(preview.Events), &events); err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal membaca preview: "+err.Error())
+		return
+	}
+
+	// Klaim preview agar konfirmasi ganda tidak mengimport dua kali
+	claim := initializers.DB.Table("common.ics_imports").
+		Where("id = ? AND status = ?", preview.ID, "preview").
+		Update("status", "importing")
+	if claim.Error != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal memproses import: "+claim.Error.Error())
+		return
+	}
+	if claim.RowsAffected == 0 {
+		RespondError(c, http.StatusConflict, "preview import sudah dikonfirmasi")
+		return
+	}
+
+	selected := make(map[string]bool, len(requestBody.UIDs))
+	for _, uid := range requestBody.UIDs {
+		selected[uid] = true
+	}
+
+	var results []ICSImportResult
+	for _, event := range events {
+		if len(selected) > 0 && !selected[event.UID] {
+			continue
+		}
+		result := ICSImportResult{UID: event.UID, Title: event.Title}
+		duplicate, err := target.Exists(event.UID)
+		switch {
+		case err != nil:
+			result.Result, result.Message = "failed", err.Error()
+		case duplicate:
+			result.Result = "duplicate"
+		default:
+			if result.Status, err = target.Save(c, event); err != nil {
+				log.Printf("Error importing event %s: %v", event.UID, err)
+				result.Result, result.Message = "failed", err.Error()
+			} else {
+				result.Result = "imported"
+				preview.Imported++
+			}
+		}
+		if result.Result != "imported" {
+			preview.Skipped++
+		}
+		results = append(results, result)
+	}
+
+	preview.Status = "imported"
+	if err := initializers.DB.Table("common.ics_imports").Where("id = ?", preview.ID).
+		Updates(map[string]interface{}{"status": preview.Status, "imported": preview.Imported, "skipped": preview.Skipped}).Error; err != nil {
+		log.Printf("Error updating ics import %d: %v", preview.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("%d event berhasil diimport, %d dilewati", preview.Imported, preview.Skipped),
+		"import":  preview,
+		"results": results,
+	})
+}
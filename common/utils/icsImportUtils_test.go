@@ -0,0 +1,439 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// icsFixture menyusun isi file .ics dengan akhir baris CRLF seperti yang dikirim klien kalender
+func icsFixture(lines ...string) string {
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+// importedEvents meringkas hasil ParseICS menjadi "UID | judul | start - end" per baris
+func importedEvents(events []ICSImportEvent) []string {
+	result := make([]string, len(events))
+	for i, e := range events {
+		result[i] = fmt.Sprintf("%s | %s | %s - %s", e.UID, e.Title, e.FormatStart(), e.FormatEnd())
+	}
+	return result
+}
+
+func assertImported(t *testing.T, events []ICSImportEvent, want []string) {
+	t.Helper()
+	got := importedEvents(events)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ParseICS() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func parseFixture(t *testing.T, content string) []ICSImportEvent {
+	t.Helper()
+	events, err := ParseICS(strings.NewReader(content), wib(2026, 1, 1, 0, 0), wib(2027, 1, 1, 0, 0))
+	if err != nil {
+		t.Fatalf("ParseICS: %v", err)
+	}
+	return events
+}
+
+func TestParseICSOutlook(t *testing.T) {
+	content := icsFixture(
+		"BEGIN:VCALENDAR",
+		"PRODID:-//Microsoft Corporation//Outlook 16.0 MIMEDIR//EN",
+		"VERSION:2.0",
+		"METHOD:REQUEST",
+		"BEGIN:VTIMEZONE",
+		"TZID:SE Asia Standard Time",
+		"BEGIN:STANDARD",
+		"DTSTART:16010101T000000",
+		"TZOFFSETFROM:+0700",
+		"TZOFFSETTO:+0700",
+		"END:STANDARD",
+		"END:VTIMEZONE",
+		"BEGIN:VTIMEZONE",
+		"TZID:Zona Kantor Cabang",
+		"BEGIN:STANDARD",
+		"DTSTART:16010101T000000",
+		"TZOFFSETFROM:+0800",
+		"TZOFFSETTO:+0800",
+		"END:STANDARD",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:040000008200E00074C5B7101A82E00800000000A1",
+		"SUMMARY;LANGUAGE=en-US:Rapat Koordinasi\\, Divisi TI",
+		"DTSTART;TZID=SE Asia Standard Time:20260302T090000",
+		"DTEND;TZID=SE Asia Standard Time:20260302T103000",
+		"LOCATION;LANGUAGE=en-US:Ruang Rapat Lt. 3",
+		"DESCRIPTION;LANGUAGE=en-US:Agenda:\\nLaporan bulanan dan rencana kerja yang cu",
+		" kup panjang sehingga dilipat",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:040000008200E00074C5B7101A82E00800000000A2",
+		"SUMMARY:Sinkronisasi dengan Vendor",
+		"DTSTART;TZID=Pacific Standard Time:20260310T100000",
+		"DTEND;TZID=Pacific Standard Time:20260310T110000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:040000008200E00074C5B7101A82E00800000000A3",
+		"SUMMARY:Kunjungan Cabang",
+		"DTSTART;TZID=Zona Kantor Cabang:20260311T090000",
+		"DURATION:PT2H",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:040000008200E00074C5B7101A82E00800000000A4",
+		"SUMMARY:Review Anggaran",
+		`DTSTART;TZID="(UTC+07:00) Bangkok, Hanoi, Jakarta":20260312T140000`,
+		`DTEND;TZID="(UTC+07:00) Bangkok, Hanoi, Jakarta":20260312T150000`,
+		"END:VEVENT",
+		"END:VCALENDAR",
+	)
+
+	events := parseFixture(t, content)
+	assertImported(t, events, []string{
+		"040000008200E00074C5B7101A82E00800000000A1 | Rapat Koordinasi, Divisi TI | 2026-03-02T09:00:00+07:00 - 2026-03-02T10:30:00+07:00",
+		// 10.00 PDT (sudah DST sejak 8 Maret) = 17.00 UTC = 00.00 WIB keesokan harinya
+		"040000008200E00074C5B7101A82E00800000000A2 | Sinkronisasi dengan Vendor | 2026-03-11T00:00:00+07:00 - 2026-03-11T01:00:00+07:00",
+		// TZID tidak dikenal memakai offset dari VTIMEZONE
+		"040000008200E00074C5B7101A82E00800000000A3 | Kunjungan Cabang | 2026-03-11T08:00:00+07:00 - 2026-03-11T10:00:00+07:00",
+		"040000008200E00074C5B7101A82E00800000000A4 | Review Anggaran | 2026-03-12T14:00:00+07:00 - 2026-03-12T15:00:00+07:00",
+	})
+	if len(events) == 0 {
+		return
+	}
+	first := events[0]
+	if first.Location != "Ruang Rapat Lt. 3" {
+		t.Errorf("Location = %q", first.Location)
+	}
+	if want := "Agenda:\nLaporan bulanan dan rencana kerja yang cukup panjang sehingga dilipat"; first.Description != want {
+		t.Errorf("Description = %q, want %q", first.Description, want)
+	}
+	if first.Recurring || first.SeriesUID != first.UID {
+		t.Errorf("event tunggal: Recurring = %v, SeriesUID = %q", first.Recurring, first.SeriesUID)
+	}
+}
+
+func TestParseICSGoogle(t *testing.T) {
+	content := icsFixture(
+		"BEGIN:VCALENDAR",
+		"PRODID:-//Google Inc//Google Calendar 70.9054//EN",
+		"VERSION:2.0",
+		"CALSCALE:GREGORIAN",
+		"X-WR-TIMEZONE:Asia/Jakarta",
+		// Google menulis override sebelum master
+		"BEGIN:VEVENT",
+		"DTSTART:20260119T040000Z",
+		"DTEND:20260119T050000Z",
+		"RECURRENCE-ID:20260119T020000Z",
+		"UID:7kukuqrfedlm2f9t0vr3m0rfc0@google.com",
+		"SUMMARY:Weekly Sync (dipindah)",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20260126T020000Z",
+		"DTEND:20260126T030000Z",
+		"RECURRENCE-ID:20260126T020000Z",
+		"UID:7kukuqrfedlm2f9t0vr3m0rfc0@google.com",
+		"STATUS:CANCELLED",
+		"SUMMARY:Weekly Sync",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20260105T020000Z",
+		"DTEND:20260105T030000Z",
+		"RRULE:FREQ=WEEKLY;WKST=MO;COUNT=5;BYDAY=MO",
+		"EXDATE:20260112T020000Z",
+		"DTSTAMP:20251220T080000Z",
+		"UID:7kukuqrfedlm2f9t0vr3m0rfc0@google.com",
+		"SUMMARY:Weekly Sync",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;TZID=America/New_York:20260302T090000",
+		"DTEND;TZID=America/New_York:20260302T093000",
+		"RRULE:FREQ=WEEKLY;COUNT=2",
+		"UID:3q0d6b7ivbk1rj0s2pn0c3g9bk@google.com",
+		"SUMMARY:Standup Tim US",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	)
+
+	events := parseFixture(t, content)
+	assertImported(t, events, []string{
+		"7kukuqrfedlm2f9t0vr3m0rfc0@google.com/20260105T020000Z | Weekly Sync | 2026-01-05T09:00:00+07:00 - 2026-01-05T10:00:00+07:00",
+		"7kukuqrfedlm2f9t0vr3m0rfc0@google.com/20260119T020000Z | Weekly Sync (dipindah) | 2026-01-19T11:00:00+07:00 - 2026-01-19T12:00:00+07:00",
+		"7kukuqrfedlm2f9t0vr3m0rfc0@google.com/20260202T020000Z | Weekly Sync | 2026-02-02T09:00:00+07:00 - 2026-02-02T10:00:00+07:00",
+		// RRULE mempertahankan jam dinding New York saat DST mulai 8 Maret
+		"3q0d6b7ivbk1rj0s2pn0c3g9bk@google.com/20260302T140000Z | Standup Tim US | 2026-03-02T21:00:00+07:00 - 2026-03-02T21:30:00+07:00",
+		"3q0d6b7ivbk1rj0s2pn0c3g9bk@google.com/20260309T130000Z | Standup Tim US | 2026-03-09T20:00:00+07:00 - 2026-03-09T20:30:00+07:00",
+	})
+	for _, event := range events {
+		if !event.Recurring || !strings.HasPrefix(event.UID, event.SeriesUID+"/") {
+			t.Errorf("%s: Recurring = %v, SeriesUID = %q", event.UID, event.Recurring, event.SeriesUID)
+		}
+	}
+}
+
+func TestParseICSAllDay(t *testing.T) {
+	content := icsFixture(
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:cuti-bersama@example.com",
+		"SUMMARY:Cuti Bersama",
+		"DTSTART;VALUE=DATE:20260316",
+		"DTEND;VALUE=DATE:20260319",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:tanpa-dtend@example.com",
+		"SUMMARY:Hari Jadi Kantor",
+		"DTSTART;VALUE=DATE:20260401",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:durasi@example.com",
+		"SUMMARY:Pelatihan",
+		"DTSTART;VALUE=DATE:20260504",
+		"DURATION:P2D",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:hut-ri@example.com",
+		"SUMMARY:HUT RI",
+		"DTSTART;VALUE=DATE:20250817",
+		"DTEND;VALUE=DATE:20250818",
+		"RRULE:FREQ=YEARLY",
+		"EXDATE;VALUE=DATE:20270817",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	)
+
+	events, err := ParseICS(strings.NewReader(content), wib(2026, 1, 1, 0, 0), wib(2029, 1, 1, 0, 0))
+	if err != nil {
+		t.Fatalf("ParseICS: %v", err)
+	}
+	assertImported(t, events, []string{
+		"cuti-bersama@example.com | Cuti Bersama | 2026-03-16 - 2026-03-19",
+		"tanpa-dtend@example.com | Hari Jadi Kantor | 2026-04-01 - 2026-04-02",
+		"durasi@example.com | Pelatihan | 2026-05-04 - 2026-05-06",
+		"hut-ri@example.com/20260817 | HUT RI | 2026-08-17 - 2026-08-18",
+		"hut-ri@example.com/20280817 | HUT RI | 2028-08-17 - 2028-08-18",
+	})
+	for _, event := range events {
+		if !event.AllDay {
+			t.Errorf("%s: AllDay = false", event.UID)
+		}
+	}
+}
+
+func TestParseICSOverrides(t *testing.T) {
+	content := icsFixture(
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:seri@example.com",
+		"SUMMARY:Rapat Harian",
+		"DTSTART;TZID=Asia/Jakarta:20260302T080000",
+		"DTEND;TZID=Asia/Jakarta:20260302T083000",
+		"RRULE:FREQ=DAILY;COUNT=4",
+		"RDATE;TZID=Asia/Jakarta:20260302T080000,20260310T080000",
+		"END:VEVENT",
+		// Override dengan RECURRENCE-ID dalam zona lain tetap cocok dengan kejadian aslinya
+		"BEGIN:VEVENT",
+		"UID:seri@example.com",
+		"RECURRENCE-ID:20260303T010000Z",
+		"SUMMARY:Rapat Harian (diundur)",
+		"DTSTART;TZID=Asia/Jakarta:20260303T100000",
+		"DTEND;TZID=Asia/Jakarta:20260303T110000",
+		"LOCATION:Ruang Garuda",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:seri@example.com",
+		"RECURRENCE-ID;TZID=Asia/Jakarta:20260304T080000",
+		"STATUS:CANCELLED",
+		"DTSTART;TZID=Asia/Jakarta:20260304T080000",
+		"END:VEVENT",
+		// Undangan satu kejadian dari seri milik orang lain, tanpa master
+		"BEGIN:VEVENT",
+		"UID:seri-lain@example.com",
+		"RECURRENCE-ID:20260306T020000Z",
+		"SUMMARY:Undangan Satu Kejadian",
+		"DTSTART:20260306T030000Z",
+		"DTEND:20260306T040000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	)
+
+	events := parseFixture(t, content)
+	assertImported(t, events, []string{
+		"seri@example.com/20260302T010000Z | Rapat Harian | 2026-03-02T08:00:00+07:00 - 2026-03-02T08:30:00+07:00",
+		"seri@example.com/20260303T010000Z | Rapat Harian (diundur) | 2026-03-03T10:00:00+07:00 - 2026-03-03T11:00:00+07:00",
+		"seri@example.com/20260305T010000Z | Rapat Harian | 2026-03-05T08:00:00+07:00 - 2026-03-05T08:30:00+07:00",
+		"seri-lain@example.com/20260306T020000Z | Undangan Satu Kejadian | 2026-03-06T10:00:00+07:00 - 2026-03-06T11:00:00+07:00",
+		"seri@example.com/20260310T010000Z | Rapat Harian | 2026-03-10T08:00:00+07:00 - 2026-03-10T08:30:00+07:00",
+	})
+	if len(events) > 1 && events[1].Location != "Ruang Garuda" {
+		t.Errorf("override Location = %q", events[1].Location)
+	}
+}
+
+func TestParseICSWindow(t *testing.T) {
+	content := icsFixture(
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:lama@example.com",
+		"SUMMARY:Event Lama",
+		"DTSTART:20240105T020000Z",
+		"DTEND:20240105T030000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:harian@example.com",
+		"SUMMARY:Harian",
+		"DTSTART:20240101T020000Z",
+		"DTEND:20240101T030000Z",
+		"RRULE:FREQ=DAILY",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	)
+
+	events, err := ParseICS(strings.NewReader(content), wib(2026, 1, 1, 0, 0), wib(2026, 1, 3, 0, 0))
+	if err != nil {
+		t.Fatalf("ParseICS: %v", err)
+	}
+	// Event tunggal selalu diikutkan, event berulang hanya kejadian di dalam rentang
+	assertImported(t, events, []string{
+		"lama@example.com | Event Lama | 2024-01-05T09:00:00+07:00 - 2024-01-05T10:00:00+07:00",
+		"harian@example.com/20260101T020000Z | Harian | 2026-01-01T09:00:00+07:00 - 2026-01-01T10:00:00+07:00",
+		"harian@example.com/20260102T020000Z | Harian | 2026-01-02T09:00:00+07:00 - 2026-01-02T10:00:00+07:00",
+	})
+
+	// Rentang tiga tahun untuk seri harian melebihi batas jumlah kejadian
+	if _, err := ParseICS(strings.NewReader(content), wib(2026, 1, 1, 0, 0), wib(2029, 1, 1, 0, 0)); err == nil ||
+		!strings.Contains(err.Error(), "persempit rentang") {
+		t.Errorf("ParseICS melebihi batas error = %v", err)
+	}
+}
+
+func TestParseICSGeneratesStableUID(t *testing.T) {
+	content := icsFixture(
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"SUMMARY:Tanpa UID",
+		"DTSTART:20260105T020000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	)
+	first := parseFixture(t, content)
+	second := parseFixture(t, content)
+	if len(first) != 1 || len(second) != 1 {
+		t.Fatalf("ParseICS() = %d dan %d event, want 1", len(first), len(second))
+	}
+	if !strings.HasSuffix(first[0].UID, "@import") || first[0].UID != second[0].UID {
+		t.Errorf("UID = %q dan %q", first[0].UID, second[0].UID)
+	}
+	if !first[0].End.Equal(first[0].Start) {
+		t.Errorf("event tanpa DTEND: End = %s, want %s", first[0].End, first[0].Start)
+	}
+}
+
+func TestParseICSRejectsInvalidFiles(t *testing.T) {
+	event := func(lines ...string) string {
+		return icsFixture(append(append([]string{"BEGIN:VCALENDAR", "BEGIN:VEVENT", "UID:x@example.com"}, lines...),
+			"END:VEVENT", "END:VCALENDAR")...)
+	}
+	tests := []struct {
+		name, content, want string
+	}{
+		{"empty file", "", "bukan file iCalendar"},
+		{"not a calendar", "BEGIN:VCARD\r\nFN:Budi\r\nEND:VCARD\r\n", "bukan file iCalendar"},
+		{"missing DTSTART", event("SUMMARY:Tanpa Mulai"), "tidak memiliki DTSTART"},
+		{"invalid DTSTART", event("DTSTART:2026-01-05"), "DTSTART"},
+		{"invalid DURATION", event("DTSTART:20260105T020000Z", "DURATION:1 jam"), "DURATION"},
+		{"invalid EXDATE", event("DTSTART:20260105T020000Z", "RRULE:FREQ=DAILY", "EXDATE:kemarin"), "EXDATE"},
+		{"invalid RRULE", event("DTSTART:20260105T020000Z", "RRULE:FREQ=HOURLY"), "RRULE"},
+		{"END without BEGIN", "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\nEND:VEVENT\r\n", "tanpa BEGIN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseICS(strings.NewReader(tt.content), wib(2026, 1, 1, 0, 0), wib(2027, 1, 1, 0, 0))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseICS() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// icsTestEvent adalah ICSEvent berulang dengan LOCATION dan DESCRIPTION, UID mengikuti model kegiatan
+type icsTestEvent struct {
+	recurringTestEvent
+	Location, Description string
+}
+
+func (e icsTestEvent) GetLocation() string    { return e.Location }
+func (e icsTestEvent) GetDescription() string { return e.Description }
+
+func (e icsTestEvent) GetUID() string {
+	id := e.ID
+	if e.ParentID != nil {
+		id = *e.ParentID
+	}
+	return fmt.Sprintf("test-%d@its-vo", id)
+}
+
+func TestWriteICSRoundTrip(t *testing.T) {
+	masterID := uint(3)
+	weekly := timedEvent(masterID, "Rapat Mingguan", wib(2026, 1, 5, 9, 0), time.Hour, "FREQ=WEEKLY;BYDAY=MO;COUNT=4")
+	weekly.ExDates = "2026-01-12T09:00:00+07:00"
+	moved := timedEvent(4, "Rapat Mingguan (dipindah)", wib(2026, 1, 19, 13, 0), time.Hour, "")
+	moved.Recurrence = Recurrence{ParentID: &masterID, OriginalStart: "2026-01-19T09:00:00+07:00"}
+
+	events := []ICSEvent{
+		icsTestEvent{
+			recurringTestEvent: timedEvent(1, "Rapat Evaluasi Kinerja Triwulan Pertama Divisi Teknologi Informasi; ruang 3, lantai 2",
+				wib(2026, 2, 3, 13, 30), 90*time.Minute, ""),
+			Location:    "Ruang Rapat Utama, Gedung A",
+			Description: "Agenda:\n1. Laporan\\realisasi\n2. Tindak lanjut",
+		},
+		icsTestEvent{recurringTestEvent: recurringTestEvent{
+			ID:     2,
+			Title:  "Cuti Bersama",
+			Start:  NewEventTime(wib(2026, 3, 18, 0, 0), true),
+			End:    NewEventTime(wib(2026, 3, 21, 0, 0), true),
+			AllDay: true,
+		}},
+		icsTestEvent{recurringTestEvent: weekly, Location: "Ruang Garuda"},
+		icsTestEvent{recurringTestEvent: moved, Location: "Ruang Garuda"},
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteICS(&buffer, "Kalender Uji", events); err != nil {
+		t.Fatalf("WriteICS: %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buffer.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("baris lebih dari 75 oktet: %q", line)
+		}
+	}
+
+	parsed := parseFixture(t, buffer.String())
+	assertImported(t, parsed, []string{
+		"test-3@its-vo/20260105T020000Z | Rapat Mingguan | 2026-01-05T09:00:00+07:00 - 2026-01-05T10:00:00+07:00",
+		"test-3@its-vo/20260119T020000Z | Rapat Mingguan (dipindah) | 2026-01-19T13:00:00+07:00 - 2026-01-19T14:00:00+07:00",
+		"test-3@its-vo/20260126T020000Z | Rapat Mingguan | 2026-01-26T09:00:00+07:00 - 2026-01-26T10:00:00+07:00",
+		"test-1@its-vo | Rapat Evaluasi Kinerja Triwulan Pertama Divisi Teknologi Informasi; ruang 3, lantai 2 | 2026-02-03T13:30:00+07:00 - 2026-02-03T15:00:00+07:00",
+		"test-2@its-vo | Cuti Bersama | 2026-03-18 - 2026-03-21",
+	})
+	for _, event := range parsed {
+		switch event.SeriesUID {
+		case "test-1@its-vo":
+			if event.Location != "Ruang Rapat Utama, Gedung A" || event.Description != "Agenda:\n1. Laporan\\realisasi\n2. Tindak lanjut" {
+				t.Errorf("detail tidak sama setelah round trip: %q, %q", event.Location, event.Description)
+			}
+		case "test-2@its-vo":
+			if !event.AllDay {
+				t.Errorf("Cuti Bersama: AllDay = false")
+			}
+		case "test-3@its-vo":
+			if event.Location != "Ruang Garuda" || !event.Recurring {
+				t.Errorf("%s: Location = %q, Recurring = %v", event.UID, event.Location, event.Recurring)
+			}
+		}
+	}
+}
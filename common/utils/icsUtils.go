@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const icsProdID = "-//ITS VO//Kalender//ID"
+
+// ICSEvent adalah event kalender yang bisa diekspor ke iCalendar (RFC 5545)
+type ICSEvent interface {
+	ExcelEvent
+	GetUID() string // Unik dan tetap untuk event yang sama, misal "bookingrapat-12@its-vo"
+}
+
+// ICSEventDetail opsional diimplementasikan event untuk mengisi LOCATION dan DESCRIPTION
+type ICSEventDetail interface {
+	GetLocation() string
+	GetDescription() string
+}
+
+// ICSRecurrence opsional diimplementasikan event berulang (lewat Recurrence yang di-embed)
+// untuk menulis RRULE, EXDATE dan RECURRENCE-ID
+type ICSRecurrence interface {
+	GetRecurrence() Recurrence
+}
+
+// icsEscape meng-escape teks sesuai RFC 5545 bagian 3.3.11
+func icsEscape(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(text)
+}
+
+// icsWriter menulis baris ICS dengan CRLF dan melipat baris yang lebih dari 75 oktet
+type icsWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (iw *icsWriter) line(content string) {
+	if iw.err != nil {
+		return
+	}
+	limit := 75
+	for len(content) > limit {
+		cut := limit
+		// Jangan memotong karakter UTF-8 multi-byte
+		for cut > 0 && content[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		if _, iw.err = iw.w.WriteString(content[:cut] + "\r\n "); iw.err != nil {
+			return
+		}
+		content = content[cut:]
+		limit = 74 // Baris lanjutan diawali satu spasi
+	}
+	_, iw.err = iw.w.WriteString(content + "\r\n")
+}
+
+// icsDates mengembalikan DTSTART dan DTEND serta apakah ditulis sebagai tanggal. Event sepanjang hari memakai
+// VALUE=DATE dengan DTEND eksklusif, event berjam memakai jam dinding WIB dengan TZID=Asia/Jakarta.
+func icsDates(event ExcelEvent) (string, string, bool) {
+	start := eventWallTime(event.GetStart())
+	end := eventWallTime(event.GetEnd())
+	midnight := func(t time.Time) bool { return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 }
+
+	// Event tanpa jam (misal timeline dari 00:00 sampai 00:00) diperlakukan sebagai sepanjang hari
+	if event.GetAllDay() || (midnight(start) && midnight(end) && end.After(start)) {
+		startDay, endDay := EventDays(event)
+		return "DTSTART;VALUE=DATE:" + startDay.Format("20060102"),
+			"DTEND;VALUE=DATE:" + endDay.AddDate(0, 0, 1).Format("20060102"), true
+	}
+
+	if !end.After(start) {
+		end = start
+	}
+	return "DTSTART;TZID=Asia/Jakarta:" + start.Format("20060102T150405"),
+		"DTEND;TZID=Asia/Jakarta:" + end.Format("20060102T150405"), false
+}
+
+// icsTimeValue menulis Start dalam format model (lihat FormatEventTime) dengan tipe nilai yang sama seperti DTSTART
+func icsTimeValue(name, value string, date bool) (string, bool) {
+	t, err := parseEventTime(value)
+	if err != nil {
+		return "", false
+	}
+	if date {
+		return name + ";VALUE=DATE:" + t.Format("20060102"), true
+	}
+	return name + ";TZID=Asia/Jakarta:" + t.Format("20060102T150405"), true
+}
+
+// icsRecurrenceLines menulis RRULE dan EXDATE untuk master, atau RECURRENCE-ID untuk override
+func icsRecurrenceLines(iw *icsWriter, rec Recurrence, date bool) {
+	if rec.ParentID != nil {
+		if line, ok := icsTimeValue("RECURRENCE-ID", rec.OriginalStart, date); ok {
+			iw.line(line)
+		}
+		return
+	}
+	if rec.RRule == "" {
+		return
+	}
+	rule, err := ParseRRule(rec.RRule, jakartaLocation())
+	if err != nil {
+		return
+	}
+	value := rule.String()
+	if date && !rule.Until.IsZero() {
+		// UNTIL harus bertipe DATE jika DTSTART bertipe DATE
+		value = strings.Replace(value, "UNTIL="+rule.Until.UTC().Format("20060102T150405Z"),
+			"UNTIL="+rule.Until.In(jakartaLocation()).Format("20060102"), 1)
+	}
+	iw.line("RRULE:" + value)
+	for _, exdate := range rec.exDates() {
+		if line, ok := icsTimeValue("EXDATE", exdate, date); ok {
+			iw.line(line)
+		}
+	}
+}
+
+// WriteICS menulis VCALENDAR berisi semua event. Asia/Jakarta tidak memakai DST,
+// sehingga VTIMEZONE cukup satu komponen STANDARD +0700.
+func WriteICS(w io.Writer, calendarName string, events []ICSEvent) error {
+	iw := &icsWriter{w: bufio.NewWriter(w)}
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	iw.line("BEGIN:VCALENDAR")
+	iw.line("VERSION:2.0")
+	iw.line("PRODID:" + icsProdID)
+	iw.line("CALSCALE:GREGORIAN")
+	iw.line("METHOD:PUBLISH")
+	iw.line("X-WR-CALNAME:" + icsEscape(calendarName))
+	iw.line("X-WR-TIMEZONE:Asia/Jakarta")
+	iw.line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	iw.line("X-PUBLISHED-TTL:PT1H")
+
+	iw.line("BEGIN:VTIMEZONE")
+	iw.line("TZID:Asia/Jakarta")
+	iw.line("BEGIN:STANDARD")
+	iw.line("DTSTART:19700101T000000")
+	iw.line("TZOFFSETFROM:+0700")
+	iw.line("TZOFFSETTO:+0700")
+	iw.line("TZNAME:WIB")
+	iw.line("END:STANDARD")
+	iw.line("END:VTIMEZONE")
+
+	for _, event := range events {
+		dtStart, dtEnd, date := icsDates(event)
+		iw.line("BEGIN:VEVENT")
+		iw.line("UID:" + event.GetUID())
+		iw.line("DTSTAMP:" + stamp)
+		iw.line(dtStart)
+		iw.line(dtEnd)
+		if recurring, ok := event.(ICSRecurrence); ok {
+			icsRecurrenceLines(iw, recurring.GetRecurrence(), date)
+		}
+		iw.line("SUMMARY:" + icsEscape(event.GetTitle()))
+		if detail, ok := event.(ICSEventDetail); ok {
+			if location := detail.GetLocation(); location != "" {
+				iw.line("LOCATION:" + icsEscape(location))
+			}
+			if description := detail.GetDescription(); description != "" {
+				iw.line("DESCRIPTION:" + icsEscape(description))
+			}
+		}
+		iw.line("END:VEVENT")
+	}
+
+	iw.line("END:VCALENDAR")
+	if iw.err != nil {
+		return iw.err
+	}
+	return iw.w.Flush()
+}
+
+// ServeICS mengirim kalender sebagai file .ics. Permintaan dari feed langganan dikirim inline
+// agar klien kalender bisa melakukan polling, selain itu dikirim sebagai attachment.
+func ServeICS(c *gin.Context, fileName, calendarName string, events []ICSEvent) {
+	disposition := "attachment"
+	if _, isFeed := c.Get("calendarFeed"); isFeed {
+		disposition = "inline"
+	}
+	var buffer bytes.Buffer
+	if err := WriteICS(&buffer, calendarName, events); err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal menulis kalender: "+err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": fileName + ".ics"}))
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", buffer.Bytes())
+}
@@ -0,0 +1,609 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	"github.com/arkaramadhan/its-vo/common/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	notificationJobBatch       = 50
+	notificationJobLockTimeout = 10 * time.Minute // Job processing lebih lama dari ini dianggap worker-nya mati
+	notificationJobMaxBackoff  = time.Hour
+)
+
+// NotificationChannel mengirim pengingat terjadwal, misal log, email, atau webhook
+type NotificationChannel interface {
+	Name() string
+	Send(job models.NotificationJob) error
+}
+
+// LogChannel hanya mencatat pengingat ke log, channel bawaan
+type LogChannel struct{}
+
+func (LogChannel) Name() string { return "log" }
+
+func (LogChannel) Send(job models.NotificationJob) error {
+	if job.Kind == models.NotificationKindMessage {
+		log.Printf("Pesan %s dikirim ke %q: %s", job.Category, job.Recipient, job.Title)
+		return nil
+	}
+	log.Printf("Notifikasi %s dikirim ke %q untuk event %s pada %s", formatNotificationOffset(job.OffsetMinutes), job.Recipient, job.Title, job.RunAt)
+	return nil
+}
+
+// InAppChannel memasukkan notifikasi ke inbox penerima (common.notification_recipients)
+type InAppChannel struct{}
+
+func (InAppChannel) Name() string { return "inapp" }
+
+func (InAppChannel) Send(job models.NotificationJob) error {
+	user, err := lookupRecipientUser(job.Recipient)
+	if err != nil {
+		return err
+	}
+	data := map[string]string{}
+	if job.Payload != "" {
+		if err := json.Unmarshal([]byte(job.Payload), &data); err != nil {
+			return fmt.Errorf("%w: %v", ErrNotificationSkipped, err)
+		}
+	}
+	// Judul inbox memakai subject template email kategori yang sama
+	message, err := RenderNotificationEmail(job, "")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotificationSkipped, err)
+	}
+
+	notification := models.Notification{
+		Title:      message.Subject,
+		Start:      job.EventStart,
+		Category:   job.Category,
+		SourceType: data["source_type"],
+		SourceID:   job.SourceID,
+		Link:       data["link"],
+	}
+	if job.Kind == models.NotificationKindReminder {
+		notification.SourceType = job.Category
+		notification.Link = NotificationLink(job.Category, job.SourceID)
+	}
+	return NotifyUsers(notification, []string{user.Username})
+}
+
+// notificationLinks adalah path API resource sumber per jenis, dipakai sebagai link notifikasi
+var notificationLinks = map[string]string{
+	"BookingRapat": "/booking-rapat/%d/history",
+	"memo":         "/memo/%d",
+	"berita acara": "/beritaAcara/%d",
+	"surat":        "/surat/%d",
+	"SK":           "/sk/%d",
+}
+
+// NotificationLink mengembalikan path resource sumber, kosong jika jenisnya tidak punya endpoint detail
+func NotificationLink(sourceType string, id uint) string {
+	if format, ok := notificationLinks[sourceType]; ok {
+		return fmt.Sprintf(format, id)
+	}
+	return ""
+}
+
+// recipientUser adalah pengguna hasil pencarian penerima notifikasi
+type recipientUser struct {
+	Username string
+	Email    string
+}
+
+// lookupRecipientUser mencari pengguna di tabel user.users berdasarkan username atau email (tanpa membedakan
+// huruf besar/kecil). ErrNotificationSkipped jika tidak ditemukan.
+func lookupRecipientUser(recipient string) (recipientUser, error) {
+	var user recipientUser
+	recipient = strings.TrimSpace(recipient)
+	if recipient == "" {
+		return user, fmt.Errorf("%w: tidak ada penerima", ErrNotificationSkipped)
+	}
+	err := initializers.DB.Table("user.users").Select("username, email").
+		Where("(LOWER(username) = LOWER(?) OR LOWER(email) = LOWER(?)) AND deleted_at IS NULL", recipient, recipient).
+		Take(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, fmt.Errorf("%w: pengguna %q tidak ditemukan", ErrNotificationSkipped, recipient)
+	}
+	return user, err
+}
+
+// AdminUsernames mengembalikan username semua admin, penerima cadangan untuk persetujuan tanpa atasan
+func AdminUsernames() []string {
+	var usernames []string
+	err := initializers.DB.Table("user.users").Where("role = ? AND deleted_at IS NULL", "admin").Pluck("username", &usernames).Error
+	if err != nil {
+		log.Printf("Gagal mengambil daftar admin: %v", err)
+	}
+	return usernames
+}
+
+// ErrNotificationSkipped dikembalikan channel jika job tidak bisa dikirim dan tidak perlu dicoba ulang,
+// misal penerima tidak punya alamat email. Job ditandai skipped.
+var ErrNotificationSkipped = errors.New("notifikasi dilewati")
+
+// NotificationSource adalah keadaan terkini event sumber pengingat
+type NotificationSource struct {
+	Title      string
+	Start      time.Time
+	Recipients []string          // Username atau PIC penerima, dibuat satu job per penerima
+	Data       map[string]string // Data tambahan untuk template, misal nama ruangan
+}
+
+// NotificationSourceFunc membaca event sumber dan mengembalikan kejadian pertama yang dimulai setelah after
+// (event tunggal cukup mengembalikan Start-nya). false berarti event sudah dihapus atau tidak aktif.
+type NotificationSourceFunc func(id uint, after time.Time) (NotificationSource, bool, error)
+
+var (
+	notificationMu       sync.RWMutex
+	notificationChannels = map[string]NotificationChannel{}
+	notificationSources  = map[string]NotificationSourceFunc{}
+
+	// notificationWake membangunkan worker di proses ini tanpa menunggu interval polling
+	notificationWake = make(chan struct{}, 1)
+)
+
+func init() {
+	RegisterNotificationChannel(LogChannel{})
+	RegisterNotificationChannel(InAppChannel{})
+	RegisterNotificationChannel(WebhookChannel{})
+}
+
+// RegisterNotificationChannel mendaftarkan channel yang bisa dikirim worker di proses ini
+func RegisterNotificationChannel(channel NotificationChannel) {
+	notificationMu.Lock()
+	defer notificationMu.Unlock()
+	notificationChannels[channel.Name()] = channel
+}
+
+// RegisterNotificationSource mendaftarkan pembaca event sumber untuk sebuah kategori. Worker memakainya untuk
+// memastikan event belum berubah sebelum mengirim dan untuk menjadwalkan kejadian berikutnya event berulang.
+func RegisterNotificationSource(category string, source NotificationSourceFunc) {
+	notificationMu.Lock()
+	defer notificationMu.Unlock()
+	notificationSources[category] = source
+}
+
+func notificationChannel(name string) (NotificationChannel, bool) {
+	notificationMu.RLock()
+	defer notificationMu.RUnlock()
+	channel, ok := notificationChannels[name]
+	return channel, ok
+}
+
+func notificationSource(category string) (NotificationSourceFunc, bool) {
+	notificationMu.RLock()
+	defer notificationMu.RUnlock()
+	source, ok := notificationSources[category]
+	return source, ok
+}
+
+func registeredNotificationSources() []string {
+	notificationMu.RLock()
+	defer notificationMu.RUnlock()
+	categories := make([]string, 0, len(notificationSources))
+	for category := range notificationSources {
+		categories = append(categories, category)
+	}
+	return categories
+}
+
+func registeredNotificationChannels() []string {
+	notificationMu.RLock()
+	defer notificationMu.RUnlock()
+	names := make([]string, 0, len(notificationChannels))
+	for name := range notificationChannels {
+		names = append(names, name)
+	}
+	return names
+}
+
+// notificationOffsets membaca NOTIFICATION_OFFSETS, misal "24h,1h" (default). Pengguna bisa menimpanya
+// lewat NotificationSetting.ReminderOffsets.
+func notificationOffsets() []time.Duration {
+	value := os.Getenv("NOTIFICATION_OFFSETS")
+	if value == "" {
+		value = "24h,1h"
+	}
+	offsets, err := parseNotificationOffsets(value)
+	if err != nil {
+		log.Printf("NOTIFICATION_OFFSETS: %v, memakai 24h,1h", err)
+		return []time.Duration{24 * time.Hour, time.Hour}
+	}
+	return offsets
+}
+
+// notificationJobChannels membaca NOTIFICATION_CHANNELS, misal "log,inapp,email,webhook". Default log dan inapp.
+func notificationJobChannels() []string {
+	value := os.Getenv("NOTIFICATION_CHANNELS")
+	if value == "" {
+		return []string{LogChannel{}.Name(), InAppChannel{}.Name()}
+	}
+	var channels []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			channels = append(channels, part)
+		}
+	}
+	return channels
+}
+
+// SplitRecipients memecah isian PIC seperti "budi, sari / andi" menjadi daftar penerima
+func SplitRecipients(values ...string) []string {
+	var recipients []string
+	for _, value := range values {
+		for _, part := range strings.FieldsFunc(value, func(r rune) bool { return strings.ContainsRune(",;/&\n", r) }) {
+			if part = strings.TrimSpace(part); part != "" {
+				recipients = append(recipients, part)
+			}
+		}
+	}
+	return recipients
+}
+
+// notificationRecipients membuang penerima kosong dan duplikat. Tanpa penerima tetap dibuat satu job agar
+// channel seperti log tetap berjalan.
+func notificationRecipients(recipients []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, recipient := range recipients {
+		recipient = strings.TrimSpace(recipient)
+		if recipient == "" || seen[strings.ToLower(recipient)] {
+			continue
+		}
+		seen[strings.ToLower(recipient)] = true
+		result = append(result, recipient)
+	}
+	if len(result) == 0 {
+		return []string{""}
+	}
+	return result
+}
+
+func notificationEnvInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func formatNotificationOffset(minutes int) string {
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%d jam", minutes/60)
+	}
+	return fmt.Sprintf("%d menit", minutes)
+}
+
+// ScheduleNotifications membatalkan pengingat event yang belum terkirim lalu membuat ulang untuk start baru,
+// satu job per penerima, offset pengingat penerima, dan channel. Offset yang waktunya sudah lewat dilewati.
+func ScheduleNotifications(category string, sourceID uint, source NotificationSource) error {
+	var payload string
+	if len(source.Data) > 0 {
+		data, err := json.Marshal(source.Data)
+		if err != nil {
+			return err
+		}
+		payload = string(data)
+	}
+	now := time.Now()
+	var jobs []models.NotificationJob
+	for _, recipient := range notificationRecipients(source.Recipients) {
+		for _, offset := range reminderOffsets(recipient) {
+			runAt := source.Start.Add(-offset)
+			if !runAt.After(now) {
+				continue
+			}
+			for _, channel := range notificationJobChannels() {
+				jobs = append(jobs, models.NotificationJob{
+					Kind:          models.NotificationKindReminder,
+					Category:      category,
+					SourceID:      sourceID,
+					Title:         source.Title,
+					EventStart:    source.Start,
+					OffsetMinutes: int(offset / time.Minute),
+					Channel:       channel,
+					Recipient:     recipient,
+					Payload:       payload,
+					RunAt:         runAt,
+					Status:        models.NotificationJobPending,
+					MaxAttempts:   notificationEnvInt("NOTIFICATION_MAX_ATTEMPTS", 5),
+				})
+			}
+		}
+	}
+
+	return initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := cancelNotificationJobs(tx, category, sourceID); err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+		return tx.Table("common.notification_jobs").Create(&jobs).Error
+	})
+}
+
+// QueueNotificationMessage mengantrekan pesan sekali kirim ke setiap penerima lewat semua channel, misal hasil
+// persetujuan atau penugasan dokumen. data diteruskan ke template email.
+func QueueNotificationMessage(category string, sourceID uint, title string, eventStart time.Time, recipients []string, data map[string]string) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var jobs []models.NotificationJob
+	for _, channel := range notificationJobChannels() {
+		for _, recipient := range notificationRecipients(recipients) {
+			jobs = append(jobs, models.NotificationJob{
+				Kind:        models.NotificationKindMessage,
+				Category:    category,
+				SourceID:    sourceID,
+				Title:       title,
+				EventStart:  eventStart,
+				Channel:     channel,
+				Recipient:   recipient,
+				Payload:     string(payload),
+				RunAt:       now,
+				Status:      models.NotificationJobPending,
+				MaxAttempts: notificationEnvInt("NOTIFICATION_MAX_ATTEMPTS", 5),
+			})
+		}
+	}
+	if err := initializers.DB.Table("common.notification_jobs").Create(&jobs).Error; err != nil {
+		return err
+	}
+	select {
+	case notificationWake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// CancelNotifications membatalkan pengingat yang belum terkirim untuk event yang dihapus
+func CancelNotifications(category string, sourceIDs ...uint) error {
+	for _, id := range sourceIDs {
+		if err := cancelNotificationJobs(initializers.DB, category, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func cancelNotificationJobs(tx *gorm.DB, category string, sourceID uint) error {
+	return tx.Table("common.notification_jobs").
+		Where("kind = ? AND category = ? AND source_id = ? AND status IN ?", models.NotificationKindReminder, category, sourceID,
+			[]string{models.NotificationJobPending, models.NotificationJobProcessing}).
+		Updates(map[string]interface{}{"status": models.NotificationJobCancelled, "locked_by": "", "locked_at": nil}).Error
+}
+
+// SyncNotifications menyamakan pengingat dengan keadaan event sumber setelah diubah atau dihapus: dijadwalkan
+// ulang untuk kejadian berikutnya, atau dibatalkan jika event sudah tidak ada. Pengingat kategori tanpa
+// NotificationSource selalu dibatalkan.
+func SyncNotifications(category string, sourceIDs ...uint) {
+	resolve, ok := notificationSource(category)
+	for _, id := range sourceIDs {
+		var err error
+		if !ok {
+			err = CancelNotifications(category, id)
+		} else if source, found, resolveErr := resolve(id, time.Now()); resolveErr != nil {
+			err = resolveErr
+		} else if !found {
+			err = CancelNotifications(category, id)
+		} else {
+			err = ScheduleNotifications(category, id, source)
+		}
+		if err != nil {
+			log.Printf("Gagal menyinkronkan pengingat %s %d: %v", category, id, err)
+		}
+	}
+}
+
+// StartNotificationWorker menjalankan worker pengingat di background. Aman dijalankan di banyak replika:
+// job diklaim dengan FOR UPDATE SKIP LOCKED sehingga satu job hanya dikirim satu worker. Interval polling
+// dari NOTIFICATION_POLL_SECONDS (default 30).
+func StartNotificationWorker() {
+	interval := time.Duration(notificationEnvInt("NOTIFICATION_POLL_SECONDS", 30)) * time.Second
+	hostname, _ := os.Hostname()
+	workerID := fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	if channel, ok := NewEmailChannel(); ok {
+		RegisterNotificationChannel(channel)
+	}
+	log.Printf("Worker notifikasi %s berjalan setiap %s", workerID, interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := runNotificationDigests(); err != nil {
+				log.Printf("Ringkasan notifikasi: %v", err)
+			}
+			for {
+				processed, err := RunDueNotificationJobs(workerID, notificationJobBatch)
+				if err != nil {
+					log.Printf("Worker notifikasi: %v", err)
+				}
+				if err != nil || processed < notificationJobBatch {
+					break
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-notificationWake:
+			}
+		}
+	}()
+}
+
+// RunDueNotificationJobs mengklaim dan mengirim job yang sudah jatuh tempo, mengembalikan jumlah job yang diproses
+func RunDueNotificationJobs(workerID string, limit int) (int, error) {
+	jobs, err := claimNotificationJobs(workerID, limit)
+	if err != nil {
+		return 0, err
+	}
+	for _, job := range jobs {
+		processNotificationJob(workerID, job)
+	}
+	return len(jobs), nil
+}
+
+// claimNotificationJobs menandai job jatuh tempo sebagai processing milik workerID. Job processing yang
+// terkunci lebih lama dari notificationJobLockTimeout diklaim ulang. Hanya channel yang terdaftar di proses
+// ini yang diambil, dan pengingat hanya untuk kategori yang sumbernya terdaftar di proses ini.
+func claimNotificationJobs(workerID string, limit int) ([]models.NotificationJob, error) {
+	var jobs []models.NotificationJob
+	now := time.Now()
+	kinds := initializers.DB.Where("kind = ?", models.NotificationKindMessage)
+	if categories := registeredNotificationSources(); len(categories) > 0 {
+		kinds = kinds.Or("kind = ? AND category IN ?", models.NotificationKindReminder, categories)
+	}
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Table("common.notification_jobs").
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("channel IN ?", registeredNotificationChannels()).
+			Where(kinds).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?)",
+				models.NotificationJobPending, now, models.NotificationJobProcessing, now.Add(-notificationJobLockTimeout)).
+			Order("run_at").
+			Limit(limit).
+			Find(&jobs).Error
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+		ids := make([]uint, len(jobs))
+		for i := range jobs {
+			ids[i] = jobs[i].ID
+			jobs[i].Status, jobs[i].LockedBy, jobs[i].LockedAt = models.NotificationJobProcessing, workerID, &now
+		}
+		return tx.Table("common.notification_jobs").Where("id IN ?", ids).
+			Updates(map[string]interface{}{"status": models.NotificationJobProcessing, "locked_by": workerID, "locked_at": now}).Error
+	})
+	return jobs, err
+}
+
+// processNotificationJob memeriksa event sumber, mengirim job, lalu mencatat hasilnya. Kegagalan dicoba ulang
+// dengan jeda bertambah dua kali lipat sampai MaxAttempts.
+func processNotificationJob(workerID string, job models.NotificationJob) {
+	if job.Kind == models.NotificationKindMessage {
+		sendNotificationJob(workerID, job)
+		return
+	}
+
+	resolve, hasSource := notificationSource(job.Category)
+	if hasSource {
+		source, found, err := resolve(job.SourceID, time.Now())
+		switch {
+		case err != nil:
+			finishNotificationJob(workerID, job, err)
+			return
+		case !found:
+			SyncNotifications(job.Category, job.SourceID)
+			return
+		case !source.Start.Equal(job.EventStart) || source.Title != job.Title ||
+			!containsString(notificationRecipients(source.Recipients), job.Recipient):
+			// Event berubah tanpa sinkronisasi, job lama dibatalkan dan dijadwalkan ulang
+			if err := ScheduleNotifications(job.Category, job.SourceID, source); err != nil {
+				log.Printf("Gagal menjadwalkan ulang pengingat %s %d: %v", job.Category, job.SourceID, err)
+			}
+			return
+		}
+	}
+
+	if !job.EventStart.After(time.Now()) {
+		updateNotificationJob(workerID, job, map[string]interface{}{
+			"status": models.NotificationJobSkipped, "last_error": "Event sudah dimulai",
+		})
+	} else {
+		sendNotificationJob(workerID, job)
+	}
+
+	if hasSource {
+		scheduleNextOccurrence(job, resolve)
+	}
+}
+
+func sendNotificationJob(workerID string, job models.NotificationJob) {
+	channel, ok := notificationChannel(job.Channel)
+	if !ok {
+		finishNotificationJob(workerID, job, fmt.Errorf("channel %s tidak terdaftar", job.Channel))
+		return
+	}
+	if applyNotificationPreference(workerID, job) {
+		return
+	}
+	finishNotificationJob(workerID, job, channel.Send(job))
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleNextOccurrence menjadwalkan kejadian berikutnya event berulang setelah pengingat terakhir kejadian ini diproses
+func scheduleNextOccurrence(job models.NotificationJob, resolve NotificationSourceFunc) {
+	var pending int64
+	err := initializers.DB.Table("common.notification_jobs").
+		Where("kind = ? AND category = ? AND source_id = ? AND status IN ?", models.NotificationKindReminder, job.Category, job.SourceID,
+			[]string{models.NotificationJobPending, models.NotificationJobProcessing}).
+		Count(&pending).Error
+	if err != nil || pending > 0 {
+		return
+	}
+	source, found, err := resolve(job.SourceID, job.EventStart)
+	if err != nil || !found || !source.Start.After(job.EventStart) {
+		return
+	}
+	if err := ScheduleNotifications(job.Category, job.SourceID, source); err != nil {
+		log.Printf("Gagal menjadwalkan kejadian berikutnya %s %d: %v", job.Category, job.SourceID, err)
+	}
+}
+
+func finishNotificationJob(workerID string, job models.NotificationJob, sendErr error) {
+	attempts := job.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts}
+	now := time.Now()
+	switch {
+	case sendErr == nil:
+		updates["status"], updates["sent_at"], updates["last_error"] = models.NotificationJobSent, now, ""
+	case errors.Is(sendErr, ErrNotificationSkipped):
+		updates["status"], updates["last_error"] = models.NotificationJobSkipped, sendErr.Error()
+	case attempts >= job.MaxAttempts:
+		updates["status"], updates["last_error"] = models.NotificationJobFailed, sendErr.Error()
+		log.Printf("Job notifikasi %d gagal setelah %d percobaan: %v", job.ID, attempts, sendErr)
+	default:
+		backoff := time.Minute << (attempts - 1)
+		if backoff > notificationJobMaxBackoff || backoff <= 0 {
+			backoff = notificationJobMaxBackoff
+		}
+		updates["status"], updates["run_at"], updates["last_error"] = models.NotificationJobPending, now.Add(backoff), sendErr.Error()
+	}
+	updateNotificationJob(workerID, job, updates)
+}
+
+// updateNotificationJob hanya mengubah job yang masih diklaim workerID, sehingga job yang dibatalkan
+// saat sedang dikirim tidak ditimpa
+func updateNotificationJob(workerID string, job models.NotificationJob, updates map[string]interface{}) {
+	updates["locked_by"], updates["locked_at"] = "", nil
+	err := initializers.DB.Table("common.notification_jobs").
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, models.NotificationJobProcessing, workerID).
+		Updates(updates).Error
+	if err != nil {
+		log.Printf("Gagal memperbarui job notifikasi %d: %v", job.ID, err)
+	}
+}
@@ -0,0 +1,501 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	"github.com/arkaramadhan/its-vo/common/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// notificationDigestCategory adalah kategori job ringkasan harian, tidak pernah diringkas ulang
+	notificationDigestCategory = "Digest"
+	defaultDigestTime          = "08:00"
+	maxReminderOffsets         = 5
+	maxReminderOffset          = 30 * 24 * time.Hour
+)
+
+// NotificationCategories adalah kategori notifikasi yang bisa diatur pengguna
+var NotificationCategories = []string{
+	"BookingRapat", "JadwalRapat", "TimelineDesktop", "JadwalCuti", "TimelineProject",
+	"ApprovalRequest", "Approval", "DocumentAssignment",
+}
+
+// notificationLowPriority adalah kategori pesan yang masuk ringkasan harian jika DailyDigest aktif
+var notificationLowPriority = map[string]bool{
+	"Approval":           true,
+	"DocumentAssignment": true,
+}
+
+// notificationPreferenceChannels adalah channel yang bisa diatur per kategori. Channel log tidak diatur.
+var notificationPreferenceChannels = []string{InAppChannel{}.Name(), "email", WebhookChannel{}.Name()}
+
+// notificationQuietChannels ditunda selama jam tenang. Inbox tetap diisi karena tidak mengganggu.
+var notificationQuietChannels = []string{"email", WebhookChannel{}.Name()}
+
+// parseNotificationOffsets membaca daftar offset seperti "24h,1h,15m"
+func parseNotificationOffsets(value string) ([]time.Duration, error) {
+	var offsets []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		offset, err := time.ParseDuration(part)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("offset %q tidak valid", part)
+		}
+		offsets = append(offsets, offset)
+	}
+	return offsets, nil
+}
+
+// formatNotificationOffsets menulis offset dalam bentuk ringkas seperti "24h,15m"
+func formatNotificationOffsets(offsets []time.Duration) string {
+	parts := make([]string, len(offsets))
+	for i, offset := range offsets {
+		if offset%time.Hour == 0 {
+			parts[i] = fmt.Sprintf("%dh", offset/time.Hour)
+		} else {
+			parts[i] = fmt.Sprintf("%dm", offset/time.Minute)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseClock membaca jam "HH:MM" menjadi menit sejak tengah malam
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("jam %q tidak valid, gunakan format HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// atClock mengembalikan waktu pertama setelah now (WIB) yang jatuh pada jam minutes
+func atClock(now time.Time, minutes int) time.Time {
+	local := now.In(jakartaLocation())
+	next := time.Date(local.Year(), local.Month(), local.Day(), minutes/60, minutes%60, 0, 0, local.Location())
+	if !next.After(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// notificationUsername mengubah penerima job menjadi username, penerima berupa email dicari di user.users
+func notificationUsername(recipient string) string {
+	recipient = strings.TrimSpace(recipient)
+	if strings.Contains(recipient, "@") {
+		if user, err := lookupRecipientUser(recipient); err == nil {
+			return user.Username
+		}
+	}
+	return recipient
+}
+
+// loadNotificationSetting membaca pengaturan pengguna, pengaturan default jika belum pernah disimpan
+func loadNotificationSetting(username string) (models.NotificationSetting, error) {
+	setting := models.NotificationSetting{Username: username, DigestTime: defaultDigestTime}
+	err := initializers.DB.Table("common.notification_settings").Where("LOWER(username) = LOWER(?)", username).Take(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return setting, nil
+	}
+	return setting, err
+}
+
+// reminderOffsets mengembalikan offset pengingat penerima, NOTIFICATION_OFFSETS jika tidak diatur
+func reminderOffsets(recipient string) []time.Duration {
+	if recipient == "" {
+		return notificationOffsets()
+	}
+	setting, err := loadNotificationSetting(notificationUsername(recipient))
+	if err != nil || setting.ReminderOffsets == "" {
+		return notificationOffsets()
+	}
+	offsets, err := parseNotificationOffsets(setting.ReminderOffsets)
+	if err != nil || len(offsets) == 0 {
+		return notificationOffsets()
+	}
+	return offsets
+}
+
+// notificationMode mengembalikan mode pengiriman kategori di channel untuk pengguna. Tanpa preferensi,
+// pesan prioritas rendah diringkas jika DailyDigest aktif, selain itu dikirim langsung.
+func notificationMode(setting models.NotificationSetting, category, channel string) (string, error) {
+	var preference models.NotificationPreference
+	err := initializers.DB.Table("common.notification_preferences").
+		Where("LOWER(username) = LOWER(?) AND category = ? AND channel = ?", setting.Username, category, channel).
+		Take(&preference).Error
+	switch {
+	case err == nil:
+		return preference.Mode, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return "", err
+	case setting.DailyDigest && notificationLowPriority[category]:
+		return models.NotificationModeDigest, nil
+	default:
+		return models.NotificationModeInstant, nil
+	}
+}
+
+// quietHoursEnd mengembalikan akhir jam tenang jika now berada di dalamnya. Jam tenang boleh melewati
+// tengah malam, misal 22:00-07:00.
+func quietHoursEnd(setting models.NotificationSetting, now time.Time) (time.Time, bool) {
+	if setting.QuietHoursStart == "" || setting.QuietHoursEnd == "" {
+		return time.Time{}, false
+	}
+	start, err := parseClock(setting.QuietHoursStart)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := parseClock(setting.QuietHoursEnd)
+	if err != nil || start == end {
+		return time.Time{}, false
+	}
+	local := now.In(jakartaLocation())
+	minute := local.Hour()*60 + local.Minute()
+	quiet := (start < end && minute >= start && minute < end) || (start > end && (minute >= start || minute < end))
+	if !quiet {
+		return time.Time{}, false
+	}
+	return atClock(now, end), true
+}
+
+// nextDigestTime mengembalikan jadwal ringkasan harian berikutnya untuk pengguna
+func nextDigestTime(setting models.NotificationSetting, now time.Time) time.Time {
+	minutes, err := parseClock(setting.DigestTime)
+	if err != nil {
+		minutes, _ = parseClock(defaultDigestTime)
+	}
+	return atClock(now, minutes)
+}
+
+// applyNotificationPreference menerapkan preferensi penerima sebelum job dikirim: dilewati jika dimatikan,
+// dipindah ke ringkasan harian, atau ditunda sampai jam tenang berakhir. true jika job sudah ditangani dan
+// tidak perlu dikirim sekarang.
+func applyNotificationPreference(workerID string, job models.NotificationJob) bool {
+	if job.Recipient == "" || !containsString(notificationPreferenceChannels, job.Channel) {
+		return false
+	}
+	setting, err := loadNotificationSetting(notificationUsername(job.Recipient))
+	if err != nil {
+		finishNotificationJob(workerID, job, err)
+		return true
+	}
+	now := time.Now()
+
+	if job.Category != notificationDigestCategory {
+		mode, err := notificationMode(setting, job.Category, job.Channel)
+		if err != nil {
+			finishNotificationJob(workerID, job, err)
+			return true
+		}
+		switch {
+		case mode == models.NotificationModeOff:
+			finishNotificationJob(workerID, job, fmt.Errorf("%w: dimatikan di preferensi %s", ErrNotificationSkipped, setting.Username))
+			return true
+		case mode == models.NotificationModeDigest && job.Kind == models.NotificationKindMessage:
+			updateNotificationJob(workerID, job, map[string]interface{}{
+				"status": models.NotificationJobDigest, "run_at": nextDigestTime(setting, now),
+			})
+			return true
+		}
+	}
+
+	if end, quiet := quietHoursEnd(setting, now); quiet && containsString(notificationQuietChannels, job.Channel) {
+		// Ditunda tanpa menambah percobaan. Pengingat yang tertunda melewati mulai event nanti dilewati.
+		updateNotificationJob(workerID, job, map[string]interface{}{
+			"status": models.NotificationJobPending, "run_at": end,
+		})
+		return true
+	}
+	return false
+}
+
+// NotificationDigestItem adalah satu notifikasi di ringkasan harian
+type NotificationDigestItem struct {
+	Category string    `json:"category"`
+	Subject  string    `json:"subject"`
+	Link     string    `json:"link,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// runNotificationDigests menggabungkan job ringkasan yang jatuh tempo menjadi satu pesan per penerima dan
+// channel. Pesan ringkasan dikirim worker seperti pesan biasa, termasuk percobaan ulangnya.
+func runNotificationDigests() error {
+	var created bool
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var jobs []models.NotificationJob
+		err := tx.Table("common.notification_jobs").
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ?", models.NotificationJobDigest, time.Now()).
+			Order("created_at, id").
+			Find(&jobs).Error
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+
+		type digestKey struct{ channel, recipient string }
+		groups := map[digestKey][]models.NotificationJob{}
+		var keys []digestKey
+		for _, job := range jobs {
+			key := digestKey{job.Channel, strings.ToLower(job.Recipient)}
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], job)
+		}
+
+		now := time.Now()
+		ids := make([]uint, 0, len(jobs))
+		digests := make([]models.NotificationJob, 0, len(keys))
+		for _, key := range keys {
+			items := make([]NotificationDigestItem, 0, len(groups[key]))
+			for _, job := range groups[key] {
+				ids = append(ids, job.ID)
+				item := NotificationDigestItem{Category: job.Category, Subject: job.Title, At: job.CreatedAt}
+				if message, err := RenderNotificationEmail(job, ""); err == nil {
+					item.Subject = message.Subject
+				}
+				data := map[string]string{}
+				if json.Unmarshal([]byte(job.Payload), &data) == nil {
+					item.Link = data["link"]
+				}
+				items = append(items, item)
+			}
+			encoded, err := json.Marshal(items)
+			if err != nil {
+				return err
+			}
+			payload, err := json.Marshal(map[string]string{"items": string(encoded), "count": strconv.Itoa(len(items))})
+			if err != nil {
+				return err
+			}
+			first := groups[key][0]
+			digests = append(digests, models.NotificationJob{
+				Kind:        models.NotificationKindMessage,
+				Category:    notificationDigestCategory,
+				Title:       "Ringkasan notifikasi harian",
+				EventStart:  now,
+				Channel:     first.Channel,
+				Recipient:   first.Recipient,
+				Payload:     string(payload),
+				RunAt:       now,
+				Status:      models.NotificationJobPending,
+				MaxAttempts: notificationEnvInt("NOTIFICATION_MAX_ATTEMPTS", 5),
+			})
+		}
+		if err := tx.Table("common.notification_jobs").Create(&digests).Error; err != nil {
+			return err
+		}
+		created = true
+		return tx.Table("common.notification_jobs").Where("id IN ?", ids).
+			Update("status", models.NotificationJobDigested).Error
+	})
+	if err == nil && created {
+		select {
+		case notificationWake <- struct{}{}:
+		default:
+		}
+	}
+	return err
+}
+
+// CategoryPreference adalah mode efektif satu kategori di setiap channel
+type CategoryPreference struct {
+	Category    string            `json:"category"`
+	LowPriority bool              `json:"low_priority"`
+	Channels    map[string]string `json:"channels"` // channel -> instant, digest, atau off
+}
+
+// notificationPreferenceResponse menyusun pengaturan dan mode efektif semua kategori untuk pengguna
+func notificationPreferenceResponse(username string) (gin.H, error) {
+	setting, err := loadNotificationSetting(username)
+	if err != nil {
+		return nil, err
+	}
+	categories := make([]CategoryPreference, 0, len(NotificationCategories))
+	for _, category := range NotificationCategories {
+		preference := CategoryPreference{Category: category, LowPriority: notificationLowPriority[category], Channels: map[string]string{}}
+		for _, channel := range notificationPreferenceChannels {
+			mode, err := notificationMode(setting, category, channel)
+			if err != nil {
+				return nil, err
+			}
+			preference.Channels[channel] = mode
+		}
+		categories = append(categories, preference)
+	}
+	return gin.H{
+		"setting":                  setting,
+		"default_reminder_offsets": formatNotificationOffsets(notificationOffsets()),
+		"categories":               categories,
+	}, nil
+}
+
+// GetNotificationPreferences mengembalikan pengaturan notifikasi pengguna yang login
+func GetNotificationPreferences(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Pengguna tidak dikenali"})
+		return
+	}
+	response, err := notificationPreferenceResponse(username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateNotificationPreferences menyimpan pengaturan notifikasi pengguna yang login. Field yang tidak dikirim
+// tidak diubah, mode kosong di preferences mengembalikan kategori ke default. Offset pengingat baru berlaku
+// untuk pengingat yang dijadwalkan setelahnya.
+func UpdateNotificationPreferences(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Pengguna tidak dikenali"})
+		return
+	}
+	var body struct {
+		ReminderOffsets *string `json:"reminder_offsets"`
+		QuietHoursStart *string `json:"quiet_hours_start"`
+		QuietHoursEnd   *string `json:"quiet_hours_end"`
+		DailyDigest     *bool   `json:"daily_digest"`
+		DigestTime      *string `json:"digest_time"`
+		WebhookURL      *string `json:"webhook_url"`
+		Preferences     []struct {
+			Category string `json:"category"`
+			Channel  string `json:"channel"`
+			Mode     string `json:"mode"`
+		} `json:"preferences"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	setting, err := loadNotificationSetting(username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	setting.Username = username
+	if body.ReminderOffsets != nil {
+		offsets, err := parseNotificationOffsets(*body.ReminderOffsets)
+		if err == nil && len(offsets) > maxReminderOffsets {
+			err = fmt.Errorf("maksimal %d offset pengingat", maxReminderOffsets)
+		}
+		for _, offset := range offsets {
+			if err == nil && offset > maxReminderOffset {
+				err = fmt.Errorf("offset %s melebihi 30 hari", offset)
+			}
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		setting.ReminderOffsets = formatNotificationOffsets(offsets)
+	}
+	if body.QuietHoursStart != nil {
+		setting.QuietHoursStart = strings.TrimSpace(*body.QuietHoursStart)
+	}
+	if body.QuietHoursEnd != nil {
+		setting.QuietHoursEnd = strings.TrimSpace(*body.QuietHoursEnd)
+	}
+	if body.DailyDigest != nil {
+		setting.DailyDigest = *body.DailyDigest
+	}
+	if body.DigestTime != nil {
+		setting.DigestTime = strings.TrimSpace(*body.DigestTime)
+	}
+	if body.WebhookURL != nil {
+		setting.WebhookURL = strings.TrimSpace(*body.WebhookURL)
+	}
+
+	if (setting.QuietHoursStart == "") != (setting.QuietHoursEnd == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Jam tenang harus diisi mulai dan selesai"})
+		return
+	}
+	for _, clock := range []string{setting.QuietHoursStart, setting.QuietHoursEnd, setting.DigestTime} {
+		if clock == "" {
+			continue
+		}
+		if _, err := parseClock(clock); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+	}
+	if setting.DigestTime == "" {
+		setting.DigestTime = defaultDigestTime
+	}
+	if setting.WebhookURL != "" {
+		if err := validateWebhookURL(setting.WebhookURL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+	}
+	for _, preference := range body.Preferences {
+		if preference.Category == "" || !containsString(notificationPreferenceChannels, preference.Channel) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Preferensi %s/%s tidak valid", preference.Category, preference.Channel)})
+			return
+		}
+		switch preference.Mode {
+		case "", models.NotificationModeInstant, models.NotificationModeDigest, models.NotificationModeOff:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Mode %q tidak valid", preference.Mode)})
+			return
+		}
+	}
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Table("common.notification_settings").Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "username"}},
+			DoUpdates: clause.AssignmentColumns([]string{"updated_at", "reminder_offsets", "quiet_hours_start", "quiet_hours_end", "daily_digest", "digest_time", "webhook_url"}),
+		}).Create(&setting).Error
+		if err != nil {
+			return err
+		}
+		for _, preference := range body.Preferences {
+			query := tx.Table("common.notification_preferences").
+				Where("username = ? AND category = ? AND channel = ?", username, preference.Category, preference.Channel)
+			if preference.Mode == "" {
+				if err := query.Delete(&models.NotificationPreference{}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			err := tx.Table("common.notification_preferences").Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "username"}, {Name: "category"}, {Name: "channel"}},
+				DoUpdates: clause.AssignmentColumns([]string{"updated_at", "mode"}),
+			}).Create(&models.NotificationPreference{
+				Username: username, Category: preference.Category, Channel: preference.Channel, Mode: preference.Mode,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	response, err := notificationPreferenceResponse(username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	log.Printf("Preferensi notifikasi %s diperbarui", username)
+	c.JSON(http.StatusOK, response)
+}
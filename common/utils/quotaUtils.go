@@ -0,0 +1,441 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	"github.com/arkaramadhan/its-vo/common/models"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// Status pemakaian storage terhadap kuota
+const (
+	QuotaUnlimited = "unlimited"
+	QuotaOK        = "ok"
+	QuotaWarning   = "warning"
+	QuotaExceeded  = "exceeded"
+)
+
+const defaultUsageTop = 20
+
+// ErrStorageQuotaExceeded dibungkus error upload yang ditolak karena kuota resource terlampaui
+var ErrStorageQuotaExceeded = errors.New("kuota penyimpanan terlampaui")
+
+type ResourceUsage struct {
+	Resource       string  `json:"resource"`
+	Files          int64   `json:"files"`
+	Bytes          int64   `json:"bytes"`
+	QuotaBytes     int64   `json:"quota_bytes"`
+	WarningPercent int     `json:"warning_percent"`
+	UsedPercent    float64 `json:"used_percent"`
+	Status         string  `json:"status"`
+}
+
+type RecordUsage struct {
+	Resource string `json:"resource"`
+	RecordID uint   `json:"record_id"`
+	Files    int64  `json:"files"`
+	Bytes    int64  `json:"bytes"`
+}
+
+type UploaderUsage struct {
+	UploadedBy string `json:"uploaded_by"`
+	Files      int64  `json:"files"`
+	Bytes      int64  `json:"bytes"`
+}
+
+type StorageUsageReport struct {
+	TotalFiles int64           `json:"total_files"`
+	TotalBytes int64           `json:"total_bytes"`
+	Resources  []ResourceUsage `json:"resources"`
+	Records    []RecordUsage   `json:"records"`
+	Uploaders  []UploaderUsage `json:"uploaders"`
+}
+
+type StorageQuotaRequest struct {
+	LimitBytes     int64 `json:"limit_bytes"`
+	WarningPercent int   `json:"warning_percent"`
+}
+
+func (u ResourceUsage) ToExcelRow() []interface{} {
+	quota := "-"
+	if u.QuotaBytes > 0 {
+		quota = FormatBytes(u.QuotaBytes)
+	}
+	return []interface{}{u.Resource, u.Files, FormatBytes(u.Bytes), quota, fmt.Sprintf("%.1f%%", u.UsedPercent), u.Status}
+}
+
+func (u ResourceUsage) GetDocType() string { return "" }
+
+func (u RecordUsage) ToExcelRow() []interface{} {
+	return []interface{}{u.Resource, u.RecordID, u.Files, FormatBytes(u.Bytes)}
+}
+
+func (u RecordUsage) GetDocType() string { return "" }
+
+func (u UploaderUsage) ToExcelRow() []interface{} {
+	return []interface{}{u.UploadedBy, u.Files, FormatBytes(u.Bytes)}
+}
+
+func (u UploaderUsage) GetDocType() string { return "" }
+
+// FormatBytes mengubah ukuran byte menjadi teks yang mudah dibaca, misal "1.5 MB"
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// FindStorageResourceByDir mencari resource berdasarkan direktori upload
+func FindStorageResourceByDir(baseDir string) (StorageResource, bool) {
+	for _, resource := range StorageResources {
+		if resource.BaseDir == strings.TrimSuffix(baseDir, "/") {
+			return resource, true
+		}
+	}
+	return StorageResource{}, false
+}
+
+func filesInResource(db *gorm.DB, resource StorageResource) *gorm.DB {
+	return db.Table("common.files").Where("file_path LIKE ?", resource.BaseDir+"/%")
+}
+
+func quotaStatus(usage *ResourceUsage) {
+	if usage.QuotaBytes <= 0 {
+		usage.Status = QuotaUnlimited
+		return
+	}
+	usage.UsedPercent = float64(usage.Bytes) * 100 / float64(usage.QuotaBytes)
+	switch {
+	case usage.UsedPercent >= 100:
+		usage.Status = QuotaExceeded
+	case usage.UsedPercent >= float64(usage.WarningPercent):
+		usage.Status = QuotaWarning
+	default:
+		usage.Status = QuotaOK
+	}
+}
+
+// GetResourceUsage menghitung pemakaian satu resource beserta status kuotanya
+func GetResourceUsage(resource StorageResource) (ResourceUsage, error) {
+	return resourceUsage(initializers.DB, resource)
+}
+
+func resourceUsage(db *gorm.DB, resource StorageResource) (ResourceUsage, error) {
+	usage := ResourceUsage{Resource: resource.Name}
+	if err := filesInResource(db, resource).
+		Select("COUNT(*) AS files, COALESCE(SUM(size), 0) AS bytes").
+		Scan(&usage).Error; err != nil {
+		return usage, err
+	}
+
+	var quota models.StorageQuota
+	err := db.Table("common.storage_quotas").Where("resource = ?", resource.Name).First(&quota).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return usage, err
+	}
+	usage.QuotaBytes = quota.LimitBytes
+	usage.WarningPercent = quota.WarningPercent
+	quotaStatus(&usage)
+	return usage, nil
+}
+
+// CheckStorageQuota menolak upload yang akan membuat resource melebihi kuotanya. Dipakai sebelum file
+// ditulis ke disk; pemeriksaan yang mengikat dilakukan CreateFileWithQuota saat metadata disimpan.
+// Upload juga ditolak jika pemakaian tidak bisa dihitung.
+func CheckStorageQuota(baseDir string, size int64) error {
+	resource, ok := FindStorageResourceByDir(baseDir)
+	if !ok {
+		return nil
+	}
+	return checkResourceQuota(initializers.DB, resource, size)
+}
+
+func checkResourceQuota(db *gorm.DB, resource StorageResource, size int64) error {
+	usage, err := resourceUsage(db, resource)
+	if err != nil {
+		log.Printf("Error menghitung kuota %s: %v", resource.Name, err)
+		return fmt.Errorf("gagal menghitung kuota penyimpanan %s: %w", resource.Name, err)
+	}
+	if usage.QuotaBytes > 0 && usage.Bytes+size > usage.QuotaBytes {
+		return fmt.Errorf("%w: %s (terpakai %s dari %s)",
+			ErrStorageQuotaExceeded, resource.Name, FormatBytes(usage.Bytes), FormatBytes(usage.QuotaBytes))
+	}
+	return nil
+}
+
+// CreateFileWithQuota menyimpan metadata file ke common.files setelah memeriksa kuota resource, dalam satu
+// transaksi di bawah advisory lock per resource sehingga upload bersamaan tidak bisa melewati kuota.
+func CreateFileWithQuota(baseDir string, file *models.File) error {
+	return initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if resource, ok := FindStorageResourceByDir(baseDir); ok {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "storage_quota:"+resource.Name).Error; err != nil {
+				return err
+			}
+			if err := checkResourceQuota(tx, resource, file.Size); err != nil {
+				return err
+			}
+		}
+		return tx.Table("common.files").Create(file).Error
+	})
+}
+
+// GetStorageUsage mengagregasi common.files per resource, per record, dan per pengunggah.
+// top membatasi jumlah record per resource; 0 berarti tanpa batas.
+func GetStorageUsage(resources []StorageResource, top int) (*StorageUsageReport, error) {
+	report := &StorageUsageReport{
+		Resources: []ResourceUsage{},
+		Records:   []RecordUsage{},
+		Uploaders: []UploaderUsage{},
+	}
+
+	for _, resource := range resources {
+		usage, err := GetResourceUsage(resource)
+		if err != nil {
+			return nil, fmt.Errorf("gagal menghitung pemakaian %s: %v", resource.Name, err)
+		}
+		report.Resources = append(report.Resources, usage)
+		report.TotalFiles += usage.Files
+		report.TotalBytes += usage.Bytes
+
+		// File.UserID berisi ID record pemilik lampiran
+		var records []RecordUsage
+		query := filesInResource(initializers.DB, resource).
+			Select("user_id AS record_id, COUNT(*) AS files, COALESCE(SUM(size), 0) AS bytes").
+			Group("user_id").
+			Order("bytes desc")
+		if top > 0 {
+			query = query.Limit(top)
+		}
+		if err := query.Scan(&records).Error; err != nil {
+			return nil, fmt.Errorf("gagal menghitung pemakaian record %s: %v", resource.Name, err)
+		}
+		for i := range records {
+			records[i].Resource = resource.Name
+		}
+		report.Records = append(report.Records, records...)
+	}
+
+	var conditions []string
+	var patterns []interface{}
+	for _, resource := range resources {
+		conditions = append(conditions, "file_path LIKE ?")
+		patterns = append(patterns, resource.BaseDir+"/%")
+	}
+	if len(patterns) > 0 {
+		if err := initializers.DB.Table("common.files").
+			Select("COALESCE(NULLIF(uploaded_by, ''), '-') AS uploaded_by, COUNT(*) AS files, COALESCE(SUM(size), 0) AS bytes").
+			Where(strings.Join(conditions, " OR "), patterns...).
+			Group("COALESCE(NULLIF(uploaded_by, ''), '-')").
+			Order("bytes desc").
+			Scan(&report.Uploaders).Error; err != nil {
+			return nil, fmt.Errorf("gagal menghitung pemakaian per pengunggah: %v", err)
+		}
+	}
+
+	return report, nil
+}
+
+func usageResources(c *gin.Context) ([]StorageResource, bool) {
+	name := c.Query("resource")
+	if name == "" {
+		return StorageResources, true
+	}
+	resource, ok := FindStorageResource(name)
+	if !ok {
+		RespondError(c, http.StatusBadRequest, "resource tidak dikenal: "+name)
+		return nil, false
+	}
+	return []StorageResource{resource}, true
+}
+
+// StorageUsageHandler menampilkan dashboard pemakaian storage.
+// Query: resource (opsional), top (jumlah record terbesar per resource, default 20).
+func StorageUsageHandler(c *gin.Context) {
+	resources, ok := usageResources(c)
+	if !ok {
+		return
+	}
+
+	top := defaultUsageTop
+	if value := c.Query("top"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			RespondError(c, http.StatusBadRequest, "top tidak valid")
+			return
+		}
+		top = n
+	}
+
+	report, err := GetStorageUsage(resources, top)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func ExportStorageUsageHandler(c *gin.Context) {
+	resources, ok := usageResources(c)
+	if !ok {
+		return
+	}
+
+	report, err := GetStorageUsage(resources, 0)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var resourceData, recordData, uploaderData []ExcelData
+	for _, usage := range report.Resources {
+		resourceData = append(resourceData, usage)
+	}
+	for _, usage := range report.Records {
+		recordData = append(recordData, usage)
+	}
+	for _, usage := range report.Uploaders {
+		uploaderData = append(uploaderData, usage)
+	}
+
+	cellStyles := &CustomStyles{
+		DefaultCellStyle: &excelize.Style{
+			Alignment: WrapAlignment,
+			Border:    BorderBlack,
+		},
+		DataAreaStyle: &excelize.Style{
+			Alignment: WrapAlignment,
+			Border:    BorderBlack,
+		},
+	}
+
+	configs := []ExcelConfig{
+		{
+			SheetName: "PER RESOURCE",
+			Columns: []ExcelColumn{
+				{Header: "Resource", Width: 20},
+				{Header: "Jumlah File", Width: 15},
+				{Header: "Ukuran", Width: 15},
+				{Header: "Kuota", Width: 15},
+				{Header: "Terpakai", Width: 12},
+				{Header: "Status", Width: 12},
+			},
+			Data:         resourceData,
+			CustomStyles: cellStyles,
+		},
+		{
+			SheetName: "PER RECORD",
+			Columns: []ExcelColumn{
+				{Header: "Resource", Width: 20},
+				{Header: "ID Record", Width: 12},
+				{Header: "Jumlah File", Width: 15},
+				{Header: "Ukuran", Width: 15},
+			},
+			Data:         recordData,
+			CustomStyles: cellStyles,
+		},
+		{
+			SheetName: "PER PENGUNGGAH",
+			Columns: []ExcelColumn{
+				{Header: "Pengunggah", Width: 25},
+				{Header: "Jumlah File", Width: 15},
+				{Header: "Ukuran", Width: 15},
+			},
+			Data:         uploaderData,
+			CustomStyles: cellStyles,
+		},
+	}
+
+	f := excelize.NewFile()
+	for _, config := range configs {
+		if err := ExportToSheet(f, config); err != nil {
+			RespondError(c, http.StatusInternalServerError, "Gagal membuat laporan storage: "+err.Error())
+			return
+		}
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=its_report_storage.xlsx")
+	c.Header("Content-Type", "application/octet-stream")
+	if err := f.Write(c.Writer); err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal menulis file excel: "+err.Error())
+	}
+}
+
+func StorageQuotaIndex(c *gin.Context) {
+	var quotas []models.StorageQuota
+	if err := initializers.DB.Table("common.storage_quotas").Order("resource").Find(&quotas).Error; err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal mengambil data kuota: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, quotas)
+}
+
+// UpsertStorageQuota membuat atau memperbarui kuota untuk resource pada parameter :resource
+func UpsertStorageQuota(c *gin.Context) {
+	resource, ok := FindStorageResource(c.Param("resource"))
+	if !ok {
+		RespondError(c, http.StatusBadRequest, "resource tidak dikenal: "+c.Param("resource"))
+		return
+	}
+
+	var requestBody StorageQuotaRequest
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		RespondError(c, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if requestBody.LimitBytes <= 0 {
+		RespondError(c, http.StatusBadRequest, "limit_bytes harus lebih dari 0")
+		return
+	}
+	if requestBody.WarningPercent == 0 {
+		requestBody.WarningPercent = 80
+	}
+	if requestBody.WarningPercent < 1 || requestBody.WarningPercent > 100 {
+		RespondError(c, http.StatusBadRequest, "warning_percent harus antara 1 dan 100")
+		return
+	}
+
+	var quota models.StorageQuota
+	err := initializers.DB.Table("common.storage_quotas").Where("resource = ?", resource.Name).First(&quota).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		RespondError(c, http.StatusInternalServerError, "Gagal mengambil data kuota: "+err.Error())
+		return
+	}
+
+	quota.Resource = resource.Name
+	quota.LimitBytes = requestBody.LimitBytes
+	quota.WarningPercent = requestBody.WarningPercent
+	quota.UpdateBy = c.GetString("username")
+	if err := initializers.DB.Table("common.storage_quotas").Save(&quota).Error; err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal menyimpan kuota: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, quota)
+}
+
+func DeleteStorageQuota(c *gin.Context) {
+	result := initializers.DB.Table("common.storage_quotas").Where("resource = ?", c.Param("resource")).Delete(&models.StorageQuota{})
+	if result.Error != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal menghapus kuota: "+result.Error.Error())
+		return
+	}
+	if result.RowsAffected == 0 {
+		RespondError(c, http.StatusNotFound, "kuota tidak ditemukan")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "kuota berhasil dihapus"})
+}
@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// realtimeChannel adalah channel LISTEN/NOTIFY PostgreSQL yang dipakai bersama semua service
+	realtimeChannel = "its_vo_events"
+	// realtimeMaxPayload di bawah batas payload NOTIFY (8000 byte)
+	realtimeMaxPayload   = 7500
+	realtimeHeartbeat    = 25 * time.Second
+	realtimeBuffer       = 32
+	realtimeRetryBackoff = 5 * time.Second
+)
+
+// Jenis RealtimeEvent
+const (
+	RealtimeNotification  = "notification"   // Notifikasi inbox baru atau berubah status
+	RealtimeBookingStatus = "booking_status" // Status booking rapat berubah
+	RealtimeCalendar      = "calendar"       // Event kalender dibuat, diubah, atau dihapus
+)
+
+// RealtimeEvent dikirim ke browser lewat SSE. Usernames kosong berarti untuk semua pengguna yang terhubung.
+type RealtimeEvent struct {
+	Type      string          `json:"type"`
+	Action    string          `json:"action"` // created, updated, deleted, read, ...
+	Category  string          `json:"category,omitempty"`
+	IDs       []uint          `json:"ids,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Usernames []string        `json:"-"`
+	At        time.Time       `json:"at"`
+}
+
+// realtimeEnvelope adalah payload NOTIFY, Usernames ikut dikirim agar instance lain bisa menyaring penerima
+type realtimeEnvelope struct {
+	RealtimeEvent
+	Usernames []string `json:"usernames,omitempty"`
+}
+
+// realtimeSubscriber adalah satu koneksi SSE
+type realtimeSubscriber struct {
+	username string
+	events   chan RealtimeEvent
+}
+
+var realtimeHub = struct {
+	sync.RWMutex
+	subscribers map[*realtimeSubscriber]struct{}
+}{subscribers: map[*realtimeSubscriber]struct{}{}}
+
+// PublishEvent menyebarkan event ke semua instance service lewat pg_notify. Instance yang menjalankan
+// StartRealtimeListener meneruskannya ke koneksi SSE pengguna yang dituju.
+func PublishEvent(event RealtimeEvent) {
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	payload, err := json.Marshal(realtimeEnvelope{RealtimeEvent: event, Usernames: event.Usernames})
+	if err == nil && len(payload) > realtimeMaxPayload {
+		// Data terlalu besar untuk NOTIFY, klien mengambil ulang lewat API
+		event.Data = nil
+		payload, err = json.Marshal(realtimeEnvelope{RealtimeEvent: event, Usernames: event.Usernames})
+	}
+	if err != nil {
+		log.Printf("Gagal menyusun event realtime %s: %v", event.Type, err)
+		return
+	}
+	if err := initializers.DB.Exec("SELECT pg_notify(?, ?)", realtimeChannel, string(payload)).Error; err != nil {
+		log.Printf("Gagal mengirim event realtime %s: %v", event.Type, err)
+	}
+}
+
+// PublishCalendarChange memberi tahu semua pengguna bahwa event kalender berubah
+func PublishCalendarChange(category, action string, ids ...uint) {
+	PublishEvent(RealtimeEvent{Type: RealtimeCalendar, Action: action, Category: category, IDs: ids})
+}
+
+// RealtimeData mengubah data menjadi json.RawMessage untuk RealtimeEvent.Data
+func RealtimeData(data interface{}) json.RawMessage {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// StartRealtimeListener menjalankan LISTEN di koneksi PostgreSQL terpisah dan meneruskan event ke koneksi SSE
+// di instance ini. Koneksi yang putus disambung ulang.
+func StartRealtimeListener() {
+	go func() {
+		for {
+			if err := listenRealtime(context.Background()); err != nil {
+				log.Printf("Listener realtime berhenti: %v, mencoba lagi dalam %s", err, realtimeRetryBackoff)
+			}
+			time.Sleep(realtimeRetryBackoff)
+		}
+	}()
+}
+
+func listenRealtime(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, os.Getenv("DB_URL"))
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+realtimeChannel); err != nil {
+		return err
+	}
+	log.Printf("Listener realtime terhubung ke channel %s", realtimeChannel)
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var envelope realtimeEnvelope
+		if err := json.Unmarshal([]byte(notification.Payload), &envelope); err != nil {
+			log.Printf("Event realtime tidak valid: %v", err)
+			continue
+		}
+		event := envelope.RealtimeEvent
+		event.Usernames = envelope.Usernames
+		broadcastRealtime(event)
+	}
+}
+
+// broadcastRealtime mengirim event ke subscriber yang dituju. Subscriber yang buffernya penuh dilewati
+// agar klien lambat tidak menahan yang lain.
+func broadcastRealtime(event RealtimeEvent) {
+	realtimeHub.RLock()
+	defer realtimeHub.RUnlock()
+	for subscriber := range realtimeHub.subscribers {
+		if len(event.Usernames) > 0 && !containsString(event.Usernames, subscriber.username) {
+			continue
+		}
+		select {
+		case subscriber.events <- event:
+		default:
+			log.Printf("Event realtime %s untuk %s dilewati, buffer penuh", event.Type, subscriber.username)
+		}
+	}
+}
+
+func subscribeRealtime(username string) *realtimeSubscriber {
+	subscriber := &realtimeSubscriber{username: username, events: make(chan RealtimeEvent, realtimeBuffer)}
+	realtimeHub.Lock()
+	realtimeHub.subscribers[subscriber] = struct{}{}
+	realtimeHub.Unlock()
+	return subscriber
+}
+
+func unsubscribeRealtime(subscriber *realtimeSubscriber) {
+	realtimeHub.Lock()
+	delete(realtimeHub.subscribers, subscriber)
+	realtimeHub.Unlock()
+}
+
+// StreamEvents membuka stream Server-Sent Events untuk pengguna yang login (cookie token). Nama event SSE
+// sama dengan RealtimeEvent.Type; event "ready" pertama berisi jumlah notifikasi belum dibaca.
+func StreamEvents(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Pengguna tidak dikenali"})
+		return
+	}
+
+	subscriber := subscribeRealtime(username)
+	defer unsubscribeRealtime(subscriber)
+
+	var unread int64
+	if err := unreadQuery(c).Count(&unread).Error; err != nil {
+		log.Printf("Gagal menghitung notifikasi belum dibaca %s: %v", username, err)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Matikan buffering proxy seperti nginx
+	c.SSEvent("ready", gin.H{"unread": unread})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(realtimeHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event := <-subscriber.events:
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			// Komentar SSE menjaga koneksi tetap hidup melewati proxy
+			fmt.Fprint(w, ": ping\n\n")
+			return true
+		}
+	})
+}
@@ -0,0 +1,551 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxRecurrenceOccurrences  = 2000 // Per seri per rentang ekspansi
+	recurrenceConflictHorizon = 1    // Tahun, batas cek bentrok untuk seri tanpa UNTIL/COUNT
+)
+
+// ErrInvalidOccurrence dikembalikan untuk scope/occurrence_start yang tidak valid, ditampilkan sebagai 400
+var ErrInvalidOccurrence = errors.New("kejadian tidak valid")
+
+// Recurrence di-embed pada model kegiatan yang bisa berulang. Seri disimpan sebagai satu record master
+// ber-RRule, kejadian yang dilewati dicatat di ExDates dan kejadian yang diubah disimpan sebagai record
+// override dengan ParentID dan OriginalStart.
+type Recurrence struct {
+	RRule           string `gorm:"column:rrule" json:"rrule,omitempty"` // RRULE RFC 5545 tanpa awalan "RRULE:"
+	ExDates         string `json:"exdates,omitempty"`                   // Start kejadian yang dilewati, dipisah koma
+	ParentID        *uint  `gorm:"index" json:"parent_id,omitempty"`    // Override: ID record master
+	OriginalStart   string `json:"original_start,omitempty"`            // Override: Start asli kejadian
+	OccurrenceStart string `gorm:"-" json:"occurrence_start,omitempty"` // Diisi saat ekspansi, kunci edit per kejadian
+}
+
+func (r Recurrence) GetRecurrence() Recurrence {
+	return r
+}
+
+func (r *Recurrence) RecurrenceRef() *Recurrence {
+	return r
+}
+
+// Normalize memvalidasi RRule dari client dan menulisnya ulang dalam bentuk baku.
+// Field yang dikelola server (ExDates dan field override) dikosongkan.
+func (r *Recurrence) Normalize() error {
+	r.ExDates, r.ParentID, r.OriginalStart, r.OccurrenceStart = "", nil, "", ""
+	if strings.TrimSpace(r.RRule) == "" {
+		r.RRule = ""
+		return nil
+	}
+	rule, err := ParseRRule(r.RRule, jakartaLocation())
+	if err != nil {
+		return fmt.Errorf("rrule tidak valid: %v", err)
+	}
+	r.RRule = rule.String()
+	return nil
+}
+
+func (r Recurrence) exDates() []string {
+	if r.ExDates == "" {
+		return nil
+	}
+	return strings.Split(r.ExDates, ",")
+}
+
+// RecurringEvent diimplementasikan model yang meng-embed Recurrence
+type RecurringEvent[T any] interface {
+	ExcelEvent
+	GetID() uint
+	GetRecurrence() Recurrence
+	WithOccurrence(occurrence Occurrence) T // Salinan event dengan Start/End kejadian
+}
+
+// RecurringRecord adalah pointer model berulang yang bisa diubah oleh UpdateRecurringEvent/DeleteRecurringEvent
+type RecurringRecord[T any] interface {
+	*T
+	RecurringEvent[T]
+	SetID(id uint)
+	SetTimes(start, end time.Time)
+	RecurrenceRef() *Recurrence
+}
+
+// Occurrence adalah satu kejadian dari sebuah event, Key adalah Start asli dalam format FormatEventTime
+type Occurrence struct {
+	Start time.Time
+	End   time.Time
+	Key   string
+}
+
+// FormatEventTime mengikuti format Start/End model kegiatan: "2006-01-02" untuk AllDay, selain itu RFC3339 WIB
+func FormatEventTime(t time.Time, allDay bool) string {
+	return NewEventTime(t, allDay).String()
+}
+
+func parseEventTime(value string) (time.Time, error) {
+	t, err := ParseEventTime(value)
+	return t.Time, err
+}
+
+// EventOccurrences mengembalikan kejadian event yang beririsan dengan [from, to).
+// Event tanpa RRule menghasilkan paling banyak satu kejadian.
+func EventOccurrences(event ExcelEvent, rec Recurrence, from, to time.Time) ([]Occurrence, error) {
+	allDay := event.GetAllDay()
+	start, end := eventWallTime(event.GetStart()), eventWallTime(event.GetEnd())
+	if end.Before(start) {
+		end = start
+	}
+	days, duration := dayDiff(start, end), end.Sub(start)
+	endOf := func(s time.Time) time.Time {
+		if allDay {
+			return s.AddDate(0, 0, days)
+		}
+		return s.Add(duration)
+	}
+	overlaps := func(s, e time.Time) bool {
+		return s.Before(to) && (e.After(from) || (e.Equal(s) && !s.Before(from)))
+	}
+
+	if rec.RRule == "" {
+		if overlaps(start, end) {
+			return []Occurrence{{Start: start, End: end, Key: FormatEventTime(start, allDay)}}, nil
+		}
+		return nil, nil
+	}
+
+	rule, err := ParseRRule(rec.RRule, jakartaLocation())
+	if err != nil {
+		return nil, err
+	}
+	excluded := map[string]bool{}
+	for _, key := range rec.exDates() {
+		excluded[key] = true
+	}
+
+	var occurrences []Occurrence
+	lower := from.Add(-duration)
+	for _, s := range rule.Between(start, lower, to, maxRecurrenceOccurrences) {
+		key := FormatEventTime(s, allDay)
+		if e := endOf(s); !excluded[key] && overlaps(s, e) {
+			occurrences = append(occurrences, Occurrence{Start: s, End: e, Key: key})
+		}
+	}
+	return occurrences, nil
+}
+
+// ExpandRecurring mengganti setiap master berulang dengan kejadiannya di [from, to). Event tunggal dan
+// override dikembalikan apa adanya, kejadian yang sudah di-override tidak digandakan.
+func ExpandRecurring[T RecurringEvent[T]](events []T, from, to time.Time) []T {
+	overridden := map[uint]map[string]bool{}
+	for _, event := range events {
+		if rec := event.GetRecurrence(); rec.ParentID != nil {
+			if overridden[*rec.ParentID] == nil {
+				overridden[*rec.ParentID] = map[string]bool{}
+			}
+			overridden[*rec.ParentID][rec.OriginalStart] = true
+		}
+	}
+
+	var result []T
+	for _, event := range events {
+		rec := event.GetRecurrence()
+		if rec.RRule == "" {
+			result = append(result, event)
+			continue
+		}
+		occurrences, err := EventOccurrences(event, rec, from, to)
+		if err != nil {
+			log.Printf("Error expanding event %d: %v", event.GetID(), err)
+			result = append(result, event)
+			continue
+		}
+		for _, occurrence := range occurrences {
+			if !overridden[event.GetID()][occurrence.Key] {
+				result = append(result, event.WithOccurrence(occurrence))
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].GetStart().Before(result[j].GetStart()) })
+	return result
+}
+
+// ConflictWindow adalah rentang cek bentrok sebuah event: sepanjang event tunggal, dari awal seri sampai
+// akhir kejadian terakhir untuk seri dengan UNTIL/COUNT, atau recurrenceConflictHorizon tahun ke depan
+// untuk seri tanpa batas
+func ConflictWindow(event ExcelEvent, rec Recurrence) (time.Time, time.Time) {
+	start, end := eventWallTime(event.GetStart()), eventWallTime(event.GetEnd())
+	if end.Before(start) {
+		end = start
+	}
+	if rec.RRule == "" {
+		return start, end
+	}
+	rule, err := ParseRRule(rec.RRule, jakartaLocation())
+	if err != nil {
+		return start, start.AddDate(recurrenceConflictHorizon, 0, 0)
+	}
+	last, bounded := rule.LastStart(start)
+	if !bounded {
+		return start, start.AddDate(recurrenceConflictHorizon, 0, 0)
+	}
+	if event.GetAllDay() {
+		return start, last.AddDate(0, 0, dayDiff(start, end))
+	}
+	return start, last.Add(end.Sub(start))
+}
+
+// FindConflicts mengembalikan kejadian dari others yang beririsan dengan salah satu kejadian event
+// di [from, to). others boleh berisi master berulang, override dan event tunggal.
+func FindConflicts[T RecurringEvent[T]](event T, others []T, from, to time.Time) ([]T, error) {
+	own, err := EventOccurrences(event, event.GetRecurrence(), from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(own) == 0 {
+		return nil, nil
+	}
+
+	// Event ikut diekspansi agar kejadian master yang sedang di-override event ini tidak dihitung
+	candidates := append(append([]T(nil), others...), event)
+	var conflicts []T
+	for _, other := range ExpandRecurring(candidates, from, to) {
+		if other.GetID() == event.GetID() {
+			continue
+		}
+		otherStart, otherEnd := eventWallTime(other.GetStart()), eventWallTime(other.GetEnd())
+		for _, occurrence := range own {
+			if otherStart.Before(occurrence.End) && otherEnd.After(occurrence.Start) {
+				conflicts = append(conflicts, other)
+				break
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// ParseOccurrenceRange membaca rentang daftar event. start/end menerima YYYY-MM-DD maupun ISO 8601 yang
+// dikirim FullCalendar (end eksklusif), selain itu mengikuti ParseCalendarPeriod. Nilai bool menandakan
+// rentang diminta eksplisit sehingga event tunggal juga difilter.
+func ParseOccurrenceRange(c *gin.Context) (CalendarPeriod, bool, error) {
+	start, end := c.Query("start"), c.Query("end")
+	if len(start) > len("2006-01-02") || len(end) > len("2006-01-02") {
+		parse := func(value string) (time.Time, error) {
+			if t, err := time.Parse(time.RFC3339, value); err == nil {
+				return eventWallTime(t), nil
+			}
+			return time.ParseInLocation("2006-01-02T15:04:05", value, jakartaLocation())
+		}
+		from, err := parse(start)
+		if err != nil {
+			return CalendarPeriod{}, true, fmt.Errorf("format start tidak valid")
+		}
+		to, err := parse(end)
+		if err != nil {
+			return CalendarPeriod{}, true, fmt.Errorf("format end tidak valid")
+		}
+		if !to.After(from) {
+			return CalendarPeriod{}, true, fmt.Errorf("end harus setelah start")
+		}
+		if to.After(from.AddDate(0, maxCalendarMonths, 0)) {
+			return CalendarPeriod{}, true, fmt.Errorf("periode maksimal %d bulan", maxCalendarMonths)
+		}
+		return CalendarPeriod{Start: from, End: to, Label: from.Format("2006-01-02") + "_" + to.Format("2006-01-02")}, true, nil
+	}
+
+	explicit := start != "" || end != "" || c.Query("start_month") != "" || c.Query("end_month") != "" || c.Query("year") != ""
+	period, err := ParseCalendarPeriod(c)
+	return period, explicit, err
+}
+
+// RecurrenceScope menentukan cakupan edit/hapus event berulang
+type RecurrenceScope string
+
+const (
+	ScopeThis      RecurrenceScope = "this"      // Hanya kejadian ini
+	ScopeFollowing RecurrenceScope = "following" // Kejadian ini dan sesudahnya
+	ScopeAll       RecurrenceScope = "all"       // Seluruh seri
+)
+
+// ParseRecurrenceScope membaca query scope, default all
+func ParseRecurrenceScope(c *gin.Context) (RecurrenceScope, error) {
+	switch scope := RecurrenceScope(c.DefaultQuery("scope", string(ScopeAll))); scope {
+	case ScopeThis, ScopeFollowing, ScopeAll:
+		return scope, nil
+	default:
+		return scope, fmt.Errorf("scope harus this, following, atau all")
+	}
+}
+
+func loadRecurring[T any, P RecurringRecord[T]](tx *gorm.DB, table string, id uint) (P, error) {
+	record := P(new(T))
+	err := tx.Table(table).Where("id = ?", id).First(record).Error
+	return record, err
+}
+
+// occurrenceAt memvalidasi bahwa key adalah kejadian dari seri master dan mengembalikan waktu serta key bakunya
+func occurrenceAt(master ExcelEvent, rec Recurrence, key string) (time.Time, string, error) {
+	if key == "" {
+		return time.Time{}, "", fmt.Errorf("%w: occurrence_start wajib diisi untuk scope this/following", ErrInvalidOccurrence)
+	}
+	at, err := parseEventTime(key)
+	if err != nil {
+		return at, key, fmt.Errorf("%w: format occurrence_start tidak valid", ErrInvalidOccurrence)
+	}
+	key = FormatEventTime(at, master.GetAllDay())
+	occurrences, err := EventOccurrences(master, rec, at, at.Add(time.Second))
+	if err != nil {
+		return at, key, err
+	}
+	for _, occurrence := range occurrences {
+		if occurrence.Key == key {
+			return at, key, nil
+		}
+	}
+	return at, key, fmt.Errorf("%w: %s bukan kejadian dari seri ini", ErrInvalidOccurrence, key)
+}
+
+// splitSeries mengakhiri seri master sebelum at. Mengembalikan RRULE sisa untuk seri lanjutan dan
+// ExDates yang jatuh sejak at.
+func splitSeries(master ExcelEvent, rec *Recurrence, at time.Time) (string, []string, error) {
+	rule, err := ParseRRule(rec.RRule, jakartaLocation())
+	if err != nil {
+		return "", nil, err
+	}
+	head, tail := rule, rule
+	if rule.Count > 0 {
+		start := eventWallTime(master.GetStart())
+		head.Count = len(rule.Between(start, start, at, 0))
+		tail.Count = rule.Count - head.Count
+	} else {
+		head.Until = at.Add(-time.Second)
+	}
+
+	var before, after []string
+	for _, key := range rec.exDates() {
+		if t, err := parseEventTime(key); err == nil && t.Before(at) {
+			before = append(before, key)
+		} else {
+			after = append(after, key)
+		}
+	}
+	rec.RRule, rec.ExDates = head.String(), strings.Join(before, ",")
+	return tail.String(), after, nil
+}
+
+// overridesFrom mengembalikan override seri master yang kejadian aslinya sejak at
+func overridesFrom[T any, P RecurringRecord[T]](tx *gorm.DB, table string, masterID uint, at time.Time) ([]P, error) {
+	var overrides []T
+	if err := tx.Table(table).Where("parent_id = ?", masterID).Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	var result []P
+	for i := range overrides {
+		record := P(&overrides[i])
+		if t, err := parseEventTime(record.RecurrenceRef().OriginalStart); err == nil && !t.Before(at) {
+			result = append(result, record)
+		}
+	}
+	return result, nil
+}
+
+// UpdateRecurringEvent menyimpan updated ke event id sesuai scope dan mengembalikan ID record yang dibuat
+// atau diubah. key adalah occurrence_start kejadian yang diedit (wajib untuk this/following). Untuk scope
+// all dari sebuah kejadian, pergeseran waktu kejadian diterapkan ke awal seri. Dipanggil di dalam transaksi.
+func UpdateRecurringEvent[T any, P RecurringRecord[T]](tx *gorm.DB, table string, id uint, updated P, scope RecurrenceScope, key string) ([]uint, error) {
+	current, err := loadRecurring[T, P](tx, table, id)
+	if err != nil {
+		return nil, err
+	}
+	upd, cur := updated.RecurrenceRef(), current.RecurrenceRef()
+
+	// Override: scope this mengubah override itu sendiri, scope lain diteruskan ke seri induknya
+	if cur.ParentID != nil {
+		if scope == ScopeThis {
+			updated.SetID(id)
+			upd.RRule, upd.ExDates, upd.ParentID, upd.OriginalStart = "", "", cur.ParentID, cur.OriginalStart
+			return []uint{id}, tx.Table(table).Save(updated).Error
+		}
+		key = cur.OriginalStart
+		if current, err = loadRecurring[T, P](tx, table, *cur.ParentID); err != nil {
+			return nil, err
+		}
+		cur = current.RecurrenceRef()
+	}
+	masterID := current.GetID()
+
+	if cur.RRule == "" {
+		updated.SetID(masterID)
+		return []uint{masterID}, tx.Table(table).Save(updated).Error
+	}
+
+	var at time.Time
+	if key != "" || scope != ScopeAll {
+		if at, key, err = occurrenceAt(current, *cur, key); err != nil {
+			return nil, err
+		}
+	}
+	masterStart := eventWallTime(current.GetStart())
+	if scope == ScopeFollowing && at.Equal(masterStart) {
+		scope = ScopeAll
+	}
+
+	switch scope {
+	case ScopeThis:
+		upd.RRule, upd.ExDates, upd.ParentID, upd.OriginalStart = "", "", &masterID, key
+		existing := P(new(T))
+		err := tx.Table(table).Where("parent_id = ? AND original_start = ?", masterID, key).First(existing).Error
+		switch {
+		case err == nil:
+			updated.SetID(existing.GetID())
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			updated.SetID(0)
+		default:
+			return nil, err
+		}
+		if err := tx.Table(table).Save(updated).Error; err != nil {
+			return nil, err
+		}
+		return []uint{updated.GetID()}, nil
+
+	case ScopeFollowing:
+		originalRule := cur.RRule
+		tailRule, tailExDates, err := splitSeries(current, cur, at)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Table(table).Save(current).Error; err != nil {
+			return nil, err
+		}
+
+		shifted := FormatEventTime(updated.GetStart(), updated.GetAllDay()) != key
+		if upd.RRule == "" || upd.RRule == originalRule {
+			upd.RRule = tailRule
+		}
+		upd.ParentID, upd.OriginalStart, upd.ExDates = nil, "", ""
+		if !shifted {
+			upd.ExDates = strings.Join(tailExDates, ",")
+		}
+		updated.SetID(0)
+		if err := tx.Table(table).Create(updated).Error; err != nil {
+			return nil, err
+		}
+
+		// Override sejak kejadian ini ikut pindah ke seri baru, atau dibuang jika jam seri bergeser
+		overrides, err := overridesFrom[T, P](tx, table, masterID, at)
+		if err != nil {
+			return nil, err
+		}
+		for _, override := range overrides {
+			if shifted {
+				err = tx.Table(table).Where("id = ?", override.GetID()).Delete(new(T)).Error
+			} else {
+				err = tx.Table(table).Where("id = ?", override.GetID()).Update("parent_id", updated.GetID()).Error
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return []uint{masterID, updated.GetID()}, nil
+
+	default:
+		if key != "" {
+			// Edit seluruh seri dari sebuah kejadian: geser awal seri sebesar pergeseran kejadian tersebut
+			newStart := masterStart.Add(eventWallTime(updated.GetStart()).Sub(at))
+			newEnd := newStart.Add(eventWallTime(updated.GetEnd()).Sub(eventWallTime(updated.GetStart())))
+			updated.SetTimes(newStart, newEnd)
+		}
+
+		// Jika pola atau jam seri berubah, pengecualian dan override lama tidak lagi cocok
+		changed := upd.RRule != cur.RRule || updated.GetAllDay() != current.GetAllDay() ||
+			!eventWallTime(updated.GetStart()).Equal(masterStart)
+		upd.ParentID, upd.OriginalStart, upd.ExDates = nil, "", cur.ExDates
+		if changed {
+			upd.ExDates = ""
+			if err := tx.Table(table).Where("parent_id = ?", masterID).Delete(new(T)).Error; err != nil {
+				return nil, err
+			}
+		}
+		updated.SetID(masterID)
+		return []uint{masterID}, tx.Table(table).Save(updated).Error
+	}
+}
+
+// DeleteRecurringEvent menghapus event id sesuai scope. Menghapus satu kejadian mencatatnya di ExDates master.
+// Dipanggil di dalam transaksi.
+func DeleteRecurringEvent[T any, P RecurringRecord[T]](tx *gorm.DB, table string, id uint, scope RecurrenceScope, key string) error {
+	current, err := loadRecurring[T, P](tx, table, id)
+	if err != nil {
+		return err
+	}
+	cur := current.RecurrenceRef()
+
+	if cur.ParentID != nil {
+		key = cur.OriginalStart
+		if scope == ScopeThis {
+			if err := tx.Table(table).Where("id = ?", id).Delete(new(T)).Error; err != nil {
+				return err
+			}
+		}
+		if current, err = loadRecurring[T, P](tx, table, *cur.ParentID); err != nil {
+			return err
+		}
+		cur = current.RecurrenceRef()
+	}
+	masterID := current.GetID()
+
+	if cur.RRule == "" || scope == ScopeAll {
+		return tx.Table(table).Where("id = ? OR parent_id = ?", masterID, masterID).Delete(new(T)).Error
+	}
+
+	at, key, err := occurrenceAt(current, Recurrence{RRule: cur.RRule}, key)
+	if err != nil {
+		return err
+	}
+
+	if scope == ScopeThis {
+		cur.ExDates = strings.Join(append(cur.exDates(), key), ",")
+		if err := tx.Table(table).Where("parent_id = ? AND original_start = ?", masterID, key).Delete(new(T)).Error; err != nil {
+			return err
+		}
+		return tx.Table(table).Save(current).Error
+	}
+
+	if at.Equal(eventWallTime(current.GetStart())) {
+		return tx.Table(table).Where("id = ? OR parent_id = ?", masterID, masterID).Delete(new(T)).Error
+	}
+	if _, _, err := splitSeries(current, cur, at); err != nil {
+		return err
+	}
+	overrides, err := overridesFrom[T, P](tx, table, masterID, at)
+	if err != nil {
+		return err
+	}
+	for _, override := range overrides {
+		if err := tx.Table(table).Where("id = ?", override.GetID()).Delete(new(T)).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Table(table).Save(current).Error
+}
+
+// RespondRecurrenceError memetakan error UpdateRecurringEvent/DeleteRecurringEvent ke status HTTP
+func RespondRecurrenceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		RespondError(c, http.StatusNotFound, "event tidak ditemukan")
+	case errors.Is(err, ErrInvalidOccurrence):
+		RespondError(c, http.StatusBadRequest, err.Error())
+	default:
+		RespondError(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,687 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recurringTestEvent adalah model berulang minimal dengan bentuk yang sama seperti model kegiatan
+type recurringTestEvent struct {
+	ID     uint `gorm:"primaryKey"`
+	Title  string
+	Start  EventTime
+	End    EventTime
+	AllDay bool
+	Recurrence
+}
+
+func (e recurringTestEvent) GetTitle() string    { return e.Title }
+func (e recurringTestEvent) GetStart() time.Time { return e.Start.Time }
+func (e recurringTestEvent) GetEnd() time.Time   { return e.End.Time }
+func (e recurringTestEvent) GetColor() string    { return "" }
+func (e recurringTestEvent) GetAllDay() bool     { return e.AllDay }
+func (e recurringTestEvent) GetResourceID() uint { return 0 }
+func (e recurringTestEvent) GetID() uint         { return e.ID }
+func (e *recurringTestEvent) SetID(id uint)      { e.ID = id }
+func (e *recurringTestEvent) SetTimes(start, end time.Time) {
+	e.Start, e.End = NewEventTime(start, e.AllDay), NewEventTime(end, e.AllDay)
+}
+
+func (e recurringTestEvent) WithOccurrence(occurrence Occurrence) recurringTestEvent {
+	e.Start, e.End = NewEventTime(occurrence.Start, e.AllDay), NewEventTime(occurrence.End, e.AllDay)
+	e.OccurrenceStart = occurrence.Key
+	return e
+}
+
+func timedEvent(id uint, title string, start time.Time, duration time.Duration, rrule string) recurringTestEvent {
+	return recurringTestEvent{
+		ID:         id,
+		Title:      title,
+		Start:      NewEventTime(start, false),
+		End:        NewEventTime(start.Add(duration), false),
+		Recurrence: Recurrence{RRule: rrule},
+	}
+}
+
+func occurrenceKeys(occurrences []Occurrence) string {
+	keys := make([]string, len(occurrences))
+	for i, occurrence := range occurrences {
+		keys[i] = occurrence.Key
+	}
+	return strings.Join(keys, ", ")
+}
+
+func TestEventOccurrences(t *testing.T) {
+	weekly := timedEvent(1, "Weekly", wib(2026, 1, 5, 9, 0), time.Hour, "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=6")
+	tests := []struct {
+		name     string
+		event    recurringTestEvent
+		from, to time.Time
+		want     string
+	}{
+		{
+			name:  "count",
+			event: weekly,
+			from:  wib(2026, 1, 1, 0, 0), to: wib(2026, 2, 1, 0, 0),
+			want: "2026-01-05T09:00:00+07:00, 2026-01-07T09:00:00+07:00, 2026-01-12T09:00:00+07:00, " +
+				"2026-01-14T09:00:00+07:00, 2026-01-19T09:00:00+07:00, 2026-01-21T09:00:00+07:00",
+		},
+		{
+			name: "exdates are skipped",
+			event: func() recurringTestEvent {
+				e := weekly
+				e.ExDates = "2026-01-07T09:00:00+07:00,2026-01-19T09:00:00+07:00"
+				return e
+			}(),
+			from: wib(2026, 1, 1, 0, 0), to: wib(2026, 2, 1, 0, 0),
+			want: "2026-01-05T09:00:00+07:00, 2026-01-12T09:00:00+07:00, 2026-01-14T09:00:00+07:00, 2026-01-21T09:00:00+07:00",
+		},
+		{
+			name:  "occurrence running into the window is included",
+			event: weekly,
+			from:  wib(2026, 1, 12, 9, 30), to: wib(2026, 1, 13, 0, 0),
+			want: "2026-01-12T09:00:00+07:00",
+		},
+		{
+			name:  "single event",
+			event: timedEvent(2, "Single", wib(2026, 1, 5, 9, 0), time.Hour, ""),
+			from:  wib(2026, 1, 1, 0, 0), to: wib(2026, 2, 1, 0, 0),
+			want: "2026-01-05T09:00:00+07:00",
+		},
+		{
+			name:  "single event outside the window",
+			event: timedEvent(2, "Single", wib(2026, 1, 5, 9, 0), time.Hour, ""),
+			from:  wib(2026, 1, 6, 0, 0), to: wib(2026, 2, 1, 0, 0),
+			want: "",
+		},
+		{
+			name: "all-day series keeps its length in days",
+			event: recurringTestEvent{
+				Start: NewEventTime(wib(2026, 1, 5, 0, 0), true), End: NewEventTime(wib(2026, 1, 7, 0, 0), true),
+				AllDay: true, Recurrence: Recurrence{RRule: "FREQ=WEEKLY;UNTIL=20260119"},
+			},
+			from: wib(2026, 1, 1, 0, 0), to: wib(2026, 2, 1, 0, 0),
+			want: "2026-01-05, 2026-01-12, 2026-01-19",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			occurrences, err := EventOccurrences(tt.event, tt.event.Recurrence, tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := occurrenceKeys(occurrences); got != tt.want {
+				t.Errorf("EventOccurrences() = %s\nwant %s", got, tt.want)
+			}
+			for _, occurrence := range occurrences {
+				if tt.event.AllDay && dayDiff(occurrence.Start, occurrence.End) != 2 {
+					t.Errorf("kejadian %s berakhir %s, want 2 hari", occurrence.Key, occurrence.End)
+				}
+			}
+		})
+	}
+}
+
+func TestExpandRecurringSkipsOverriddenOccurrences(t *testing.T) {
+	masterID := uint(1)
+	master := timedEvent(masterID, "Weekly", wib(2026, 1, 5, 9, 0), time.Hour, "FREQ=WEEKLY;COUNT=3")
+	override := timedEvent(2, "Moved", wib(2026, 1, 12, 13, 0), time.Hour, "")
+	override.ParentID, override.OriginalStart = &masterID, "2026-01-12T09:00:00+07:00"
+	single := timedEvent(3, "Single", wib(2026, 1, 6, 9, 0), time.Hour, "")
+
+	expanded := ExpandRecurring([]recurringTestEvent{master, override, single}, wib(2026, 1, 1, 0, 0), wib(2026, 2, 1, 0, 0))
+	var got []string
+	for _, event := range expanded {
+		got = append(got, fmt.Sprintf("%d %s", event.ID, event.Start))
+	}
+	want := []string{
+		"1 2026-01-05T09:00:00+07:00",
+		"3 2026-01-06T09:00:00+07:00",
+		"2 2026-01-12T13:00:00+07:00",
+		"1 2026-01-19T09:00:00+07:00",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ExpandRecurring() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if expanded[0].OccurrenceStart != "2026-01-05T09:00:00+07:00" {
+		t.Errorf("OccurrenceStart = %q", expanded[0].OccurrenceStart)
+	}
+}
+
+func TestConflictWindow(t *testing.T) {
+	start := wib(2026, 1, 5, 9, 0)
+	tests := []struct {
+		name  string
+		event recurringTestEvent
+		to    time.Time
+	}{
+		{"single event", timedEvent(1, "", start, time.Hour, ""), wib(2026, 1, 5, 10, 0)},
+		{"count", timedEvent(1, "", start, time.Hour, "FREQ=WEEKLY;COUNT=3"), wib(2026, 1, 19, 10, 0)},
+		{"until", timedEvent(1, "", start, time.Hour, "FREQ=DAILY;UNTIL=20260110T020000Z"), wib(2026, 1, 10, 10, 0)},
+		{"unbounded", timedEvent(1, "", start, time.Hour, "FREQ=MONTHLY"), wib(2027, 1, 5, 9, 0)},
+		{
+			"all-day count",
+			recurringTestEvent{
+				Start: NewEventTime(wib(2026, 1, 5, 0, 0), true), End: NewEventTime(wib(2026, 1, 7, 0, 0), true),
+				AllDay: true, Recurrence: Recurrence{RRule: "FREQ=WEEKLY;COUNT=2"},
+			},
+			wib(2026, 1, 14, 0, 0),
+		},
+	}
+	for _, tt := range tests {
+		from, to := ConflictWindow(tt.event, tt.event.Recurrence)
+		if !from.Equal(tt.event.Start.Time) || !to.Equal(tt.to) {
+			t.Errorf("%s: ConflictWindow() = %s, %s; want %s, %s", tt.name, from, to, tt.event.Start.Time, tt.to)
+		}
+	}
+}
+
+func TestFindConflicts(t *testing.T) {
+	masterID := uint(1)
+	weekly := timedEvent(masterID, "Weekly", wib(2026, 1, 5, 9, 0), time.Hour, "FREQ=WEEKLY;COUNT=4")
+	weekly.ExDates = "2026-01-19T09:00:00+07:00"
+	moved := timedEvent(2, "Moved", wib(2026, 1, 12, 14, 0), time.Hour, "")
+	moved.ParentID, moved.OriginalStart = &masterID, "2026-01-12T09:00:00+07:00"
+	others := []recurringTestEvent{weekly, moved}
+
+	tests := []struct {
+		name  string
+		event recurringTestEvent
+		want  string
+	}{
+		{"overlaps a series occurrence", timedEvent(10, "", wib(2026, 1, 26, 9, 30), time.Hour, ""), "1"},
+		{"touching end is not a conflict", timedEvent(10, "", wib(2026, 1, 26, 10, 0), time.Hour, ""), ""},
+		{"excluded occurrence is free", timedEvent(10, "", wib(2026, 1, 19, 9, 0), time.Hour, ""), ""},
+		{"overridden occurrence is free at its old time", timedEvent(10, "", wib(2026, 1, 12, 9, 0), time.Hour, ""), ""},
+		{"override conflicts at its new time", timedEvent(10, "", wib(2026, 1, 12, 14, 30), time.Hour, ""), "2"},
+		{"series against series", timedEvent(10, "", wib(2026, 1, 1, 9, 0), time.Hour, "FREQ=WEEKLY;BYDAY=MO;COUNT=2"), "1"},
+		{"series after the other ends", timedEvent(10, "", wib(2026, 2, 2, 9, 0), time.Hour, "FREQ=WEEKLY;COUNT=10"), ""},
+	}
+	for _, tt := range tests {
+		from, to := ConflictWindow(tt.event, tt.event.Recurrence)
+		conflicts, err := FindConflicts(tt.event, others, from, to)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var ids []string
+		for _, conflict := range conflicts {
+			ids = append(ids, fmt.Sprint(conflict.ID))
+		}
+		if got := strings.Join(ids, ","); got != tt.want {
+			t.Errorf("%s: FindConflicts() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSplitSeries(t *testing.T) {
+	tests := []struct {
+		name                     string
+		rrule, exDates           string
+		at                       time.Time
+		head, tail               string
+		headExDates, tailExDates string
+	}{
+		{
+			name:  "until is written back into the master",
+			rrule: "FREQ=WEEKLY;BYDAY=MO", exDates: "2026-01-12T09:00:00+07:00,2026-01-26T09:00:00+07:00",
+			at:   wib(2026, 1, 19, 9, 0),
+			head: "FREQ=WEEKLY;UNTIL=20260119T015959Z;BYDAY=MO", tail: "FREQ=WEEKLY;BYDAY=MO",
+			headExDates: "2026-01-12T09:00:00+07:00", tailExDates: "2026-01-26T09:00:00+07:00",
+		},
+		{
+			name:  "count is divided between both series",
+			rrule: "FREQ=WEEKLY;BYDAY=MO;COUNT=5",
+			at:    wib(2026, 1, 19, 9, 0),
+			head:  "FREQ=WEEKLY;COUNT=2;BYDAY=MO", tail: "FREQ=WEEKLY;COUNT=3;BYDAY=MO",
+		},
+		{
+			name:  "existing until stays on the tail",
+			rrule: "FREQ=DAILY;UNTIL=20260131T165959Z",
+			at:    wib(2026, 1, 10, 9, 0),
+			head:  "FREQ=DAILY;UNTIL=20260110T015959Z", tail: "FREQ=DAILY;UNTIL=20260131T165959Z",
+		},
+	}
+	for _, tt := range tests {
+		master := timedEvent(1, "", wib(2026, 1, 5, 9, 0), time.Hour, tt.rrule)
+		master.ExDates = tt.exDates
+		tail, tailExDates, err := splitSeries(master, &master.Recurrence, tt.at)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if master.RRule != tt.head || tail != tt.tail {
+			t.Errorf("%s: head %q tail %q; want %q, %q", tt.name, master.RRule, tail, tt.head, tt.tail)
+		}
+		if master.ExDates != tt.headExDates || strings.Join(tailExDates, ",") != tt.tailExDates {
+			t.Errorf("%s: exdates head %q tail %q; want %q, %q", tt.name, master.ExDates, tailExDates, tt.headExDates, tt.tailExDates)
+		}
+		// Seri lama berhenti tepat sebelum at
+		occurrences, err := EventOccurrences(master, master.Recurrence, tt.at, tt.at.AddDate(1, 0, 0))
+		if err != nil || len(occurrences) != 0 {
+			t.Errorf("%s: seri lama masih punya kejadian sejak at: %s, %v", tt.name, occurrenceKeys(occurrences), err)
+		}
+	}
+}
+
+func TestOccurrenceAt(t *testing.T) {
+	master := timedEvent(1, "", wib(2026, 1, 5, 9, 0), time.Hour, "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4")
+	if _, key, err := occurrenceAt(master, master.Recurrence, "2026-01-07 09:00:00"); err != nil || key != "2026-01-07T09:00:00+07:00" {
+		t.Errorf("occurrenceAt = %q, %v", key, err)
+	}
+	for _, key := range []string{"", "besok", "2026-01-08T09:00:00+07:00", "2026-01-19T09:00:00+07:00"} {
+		if _, _, err := occurrenceAt(master, master.Recurrence, key); err == nil {
+			t.Errorf("occurrenceAt(%q) tidak mengembalikan error", key)
+		}
+	}
+}
+
+// ********** Fake database ********** //
+
+// fakeStatement adalah satu query yang dijalankan gorm terhadap fakeDB
+type fakeStatement struct {
+	query string
+	args  []driver.NamedValue
+}
+
+var fakeColumnPattern = regexp.MustCompile(`"?(\w+)"?\s*=\s*\$(\d+)`)
+
+// value mengambil nilai kolom dari SET/WHERE (UPDATE) atau daftar kolom (INSERT)
+func (s fakeStatement) value(column string) (driver.Value, bool) {
+	if strings.HasPrefix(s.query, "INSERT") {
+		open, close := strings.Index(s.query, "("), strings.Index(s.query, ")")
+		for i, name := range strings.Split(s.query[open+1:close], ",") {
+			if strings.Trim(name, `" `) == column {
+				return s.args[i].Value, true
+			}
+		}
+		return nil, false
+	}
+	for _, match := range fakeColumnPattern.FindAllStringSubmatch(s.query, -1) {
+		if match[1] == column {
+			var n int
+			fmt.Sscan(match[2], &n)
+			return s.args[n-1].Value, true
+		}
+	}
+	return nil, false
+}
+
+// fakeDB menyimpan baris recurringTestEvent dan mencatat setiap statement. SELECT dijawab dari rows
+// berdasarkan kondisi id/parent_id/original_start, INSERT mengembalikan ID baru, statement lain hanya dicatat.
+type fakeDB struct {
+	mu         sync.Mutex
+	rows       []recurringTestEvent
+	nextID     uint
+	statements []fakeStatement
+}
+
+func (db *fakeDB) execs(prefix string) []fakeStatement {
+	var result []fakeStatement
+	for _, statement := range db.statements {
+		if strings.HasPrefix(statement.query, prefix) {
+			result = append(result, statement)
+		}
+	}
+	return result
+}
+
+var fakeColumns = []string{"id", "title", "start", "end", "all_day", "rrule", "ex_dates", "parent_id", "original_start"}
+
+func (db *fakeDB) query(query string, args []driver.NamedValue) (driver.Rows, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.statements = append(db.statements, fakeStatement{query, args})
+	if strings.HasPrefix(query, "INSERT") {
+		db.nextID++
+		return &fakeRows{columns: []string{"id"}, values: [][]driver.Value{{int64(db.nextID)}}}, nil
+	}
+	rows := &fakeRows{columns: fakeColumns}
+	where := map[string]driver.Value{}
+	for _, match := range fakeColumnPattern.FindAllStringSubmatch(query, -1) {
+		var n int
+		fmt.Sscan(match[2], &n)
+		where[match[1]] = args[n-1].Value
+	}
+	for _, row := range db.rows {
+		if id, ok := where["id"]; ok && fmt.Sprint(id) != fmt.Sprint(row.ID) {
+			continue
+		}
+		if parent, ok := where["parent_id"]; ok && (row.ParentID == nil || fmt.Sprint(parent) != fmt.Sprint(*row.ParentID)) {
+			continue
+		}
+		if original, ok := where["original_start"]; ok && original != row.OriginalStart {
+			continue
+		}
+		var parentID driver.Value
+		if row.ParentID != nil {
+			parentID = int64(*row.ParentID)
+		}
+		rows.values = append(rows.values, []driver.Value{
+			int64(row.ID), row.Title, row.Start.Time, row.End.Time, row.AllDay, row.RRule, row.ExDates, parentID, row.OriginalStart,
+		})
+	}
+	return rows, nil
+}
+
+func (db *fakeDB) exec(query string, args []driver.NamedValue) (driver.Result, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.statements = append(db.statements, fakeStatement{query, args})
+	return driver.RowsAffected(1), nil
+}
+
+type fakeDriver struct{ db *fakeDB }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return fakeConn(d), nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare tidak didukung")
+}
+func (c fakeConn) Close() error              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.db.query(query, args)
+}
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.db.exec(query, args)
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+var fakeDriverSeq struct {
+	sync.Mutex
+	n int
+}
+
+// newFakeGorm membuka gorm dengan dialect postgres di atas fakeDB berisi rows
+func newFakeGorm(t *testing.T, rows ...recurringTestEvent) (*gorm.DB, *fakeDB) {
+	t.Helper()
+	fake := &fakeDB{rows: rows, nextID: 100}
+	fakeDriverSeq.Lock()
+	fakeDriverSeq.n++
+	name := fmt.Sprintf("fake-recurrence-%d", fakeDriverSeq.n)
+	fakeDriverSeq.Unlock()
+	sql.Register(name, fakeDriver{fake})
+	sqlDB, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:                 logger.Default.LogMode(logger.Silent),
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, fake
+}
+
+func mustValue(t *testing.T, statement fakeStatement, column string) driver.Value {
+	t.Helper()
+	value, ok := statement.value(column)
+	if !ok {
+		t.Fatalf("kolom %s tidak ada di %s", column, statement.query)
+	}
+	return value
+}
+
+// ********** Update dan delete per scope ********** //
+
+const testTable = "test.events"
+
+func weeklyMaster() recurringTestEvent {
+	master := timedEvent(1, "Weekly", wib(2026, 1, 5, 9, 0), time.Hour, "FREQ=WEEKLY;BYDAY=MO")
+	master.ExDates = "2026-01-12T09:00:00+07:00,2026-01-26T09:00:00+07:00"
+	return master
+}
+
+func overrideOf(id uint, originalStart time.Time, start time.Time) recurringTestEvent {
+	masterID := uint(1)
+	override := timedEvent(id, "Override", start, time.Hour, "")
+	override.ParentID, override.OriginalStart = &masterID, FormatEventTime(originalStart, false)
+	return override
+}
+
+func TestUpdateRecurringEventThis(t *testing.T) {
+	db, fake := newFakeGorm(t, weeklyMaster())
+	updated := timedEvent(0, "Moved", wib(2026, 1, 19, 13, 0), time.Hour, "")
+
+	ids, err := UpdateRecurringEvent[recurringTestEvent](db, testTable, 1, &updated, ScopeThis, "2026-01-19T09:00:00+07:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inserts := fake.execs("INSERT")
+	if len(inserts) != 1 || len(ids) != 1 || ids[0] != 101 {
+		t.Fatalf("ids %v, inserts %d; want satu override baru", ids, len(inserts))
+	}
+	if got := mustValue(t, inserts[0], "parent_id"); fmt.Sprint(got) != "1" {
+		t.Errorf("parent_id = %v", got)
+	}
+	if got := mustValue(t, inserts[0], "original_start"); got != "2026-01-19T09:00:00+07:00" {
+		t.Errorf("original_start = %v", got)
+	}
+	if got := mustValue(t, inserts[0], "rrule"); got != "" {
+		t.Errorf("override tidak boleh punya rrule, dapat %v", got)
+	}
+	if len(fake.execs("UPDATE")) != 0 {
+		t.Error("master tidak boleh diubah untuk scope this")
+	}
+}
+
+func TestUpdateRecurringEventThisReplacesExistingOverride(t *testing.T) {
+	existing := overrideOf(5, wib(2026, 1, 19, 9, 0), wib(2026, 1, 19, 11, 0))
+	db, fake := newFakeGorm(t, weeklyMaster(), existing)
+	updated := timedEvent(0, "Moved again", wib(2026, 1, 19, 15, 0), time.Hour, "")
+
+	ids, err := UpdateRecurringEvent[recurringTestEvent](db, testTable, 1, &updated, ScopeThis, "2026-01-19T09:00:00+07:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != 5 || len(fake.execs("INSERT")) != 0 {
+		t.Errorf("ids %v; want override 5 diperbarui tanpa insert", ids)
+	}
+}
+
+func TestUpdateRecurringEventFollowing(t *testing.T) {
+	override := overrideOf(5, wib(2026, 2, 2, 9, 0), wib(2026, 2, 2, 11, 0))
+	db, fake := newFakeGorm(t, weeklyMaster(), override)
+	updated := timedEvent(0, "Renamed", wib(2026, 1, 19, 9, 0), time.Hour, "FREQ=WEEKLY;BYDAY=MO")
+
+	ids, err := UpdateRecurringEvent[recurringTestEvent](db, testTable, 1, &updated, ScopeFollowing, "2026-01-19T09:00:00+07:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 101 {
+		t.Fatalf("ids = %v, want [1 101]", ids)
+	}
+
+	// Master diakhiri dengan UNTIL sebelum kejadian yang diedit, exdate sesudahnya pindah ke seri baru
+	updates := fake.execs("UPDATE")
+	if len(updates) != 2 {
+		t.Fatalf("%d UPDATE, want master dan override", len(updates))
+	}
+	if got := mustValue(t, updates[0], "rrule"); got != "FREQ=WEEKLY;UNTIL=20260119T015959Z;BYDAY=MO" {
+		t.Errorf("rrule master = %v", got)
+	}
+	if got := mustValue(t, updates[0], "ex_dates"); got != "2026-01-12T09:00:00+07:00" {
+		t.Errorf("ex_dates master = %v", got)
+	}
+	insert := fake.execs("INSERT")[0]
+	if got := mustValue(t, insert, "rrule"); got != "FREQ=WEEKLY;BYDAY=MO" {
+		t.Errorf("rrule seri baru = %v", got)
+	}
+	if got := mustValue(t, insert, "ex_dates"); got != "2026-01-26T09:00:00+07:00" {
+		t.Errorf("ex_dates seri baru = %v", got)
+	}
+	// Override sejak kejadian ini ikut pindah ke seri baru
+	if got := mustValue(t, updates[1], "parent_id"); fmt.Sprint(got) != "101" {
+		t.Errorf("override dipindah ke %v, want 101", got)
+	}
+}
+
+func TestUpdateRecurringEventFollowingShiftDropsOverrides(t *testing.T) {
+	override := overrideOf(5, wib(2026, 2, 2, 9, 0), wib(2026, 2, 2, 11, 0))
+	db, fake := newFakeGorm(t, weeklyMaster(), override)
+	updated := timedEvent(0, "Later", wib(2026, 1, 19, 10, 0), time.Hour, "FREQ=WEEKLY;BYDAY=MO")
+
+	if _, err := UpdateRecurringEvent[recurringTestEvent](db, testTable, 1, &updated, ScopeFollowing, "2026-01-19T09:00:00+07:00"); err != nil {
+		t.Fatal(err)
+	}
+	if got := mustValue(t, fake.execs("INSERT")[0], "ex_dates"); got != "" {
+		t.Errorf("exdate lama tidak cocok dengan jam baru, dapat %v", got)
+	}
+	deletes := fake.execs("DELETE")
+	if len(deletes) != 1 || fmt.Sprint(mustValue(t, deletes[0], "id")) != "5" {
+		t.Errorf("override 5 harus dihapus, dapat %d DELETE", len(deletes))
+	}
+}
+
+func TestUpdateRecurringEventFollowingFromFirstOccurrenceIsAll(t *testing.T) {
+	db, fake := newFakeGorm(t, weeklyMaster())
+	updated := timedEvent(0, "Renamed", wib(2026, 1, 5, 9, 0), time.Hour, "FREQ=WEEKLY;BYDAY=MO")
+
+	ids, err := UpdateRecurringEvent[recurringTestEvent](db, testTable, 1, &updated, ScopeFollowing, "2026-01-05T09:00:00+07:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != 1 || len(fake.execs("INSERT")) != 0 {
+		t.Errorf("ids %v; want hanya master yang diubah", ids)
+	}
+	if got := mustValue(t, fake.execs("UPDATE")[0], "ex_dates"); got != weeklyMaster().ExDates {
+		t.Errorf("ex_dates = %v, want tetap", got)
+	}
+}
+
+func TestUpdateRecurringEventAllShiftsSeriesFromOccurrence(t *testing.T) {
+	override := overrideOf(5, wib(2026, 2, 2, 9, 0), wib(2026, 2, 2, 11, 0))
+	db, fake := newFakeGorm(t, weeklyMaster(), override)
+	// Kejadian 19 Jan digeser satu jam, seluruh seri ikut bergeser dari awalnya
+	updated := timedEvent(0, "Later", wib(2026, 1, 19, 10, 0), time.Hour, "FREQ=WEEKLY;BYDAY=MO")
+
+	if _, err := UpdateRecurringEvent[recurringTestEvent](db, testTable, 1, &updated, ScopeAll, "2026-01-19T09:00:00+07:00"); err != nil {
+		t.Fatal(err)
+	}
+	if !updated.Start.Equal(wib(2026, 1, 5, 10, 0)) || !updated.End.Equal(wib(2026, 1, 5, 11, 0)) {
+		t.Errorf("awal seri = %s - %s, want 5 Jan 10:00 - 11:00", updated.Start, updated.End)
+	}
+	if got := mustValue(t, fake.execs("UPDATE")[0], "ex_dates"); got != "" {
+		t.Errorf("ex_dates = %v, want dikosongkan karena jam seri berubah", got)
+	}
+	if deletes := fake.execs("DELETE"); len(deletes) != 1 || fmt.Sprint(mustValue(t, deletes[0], "parent_id")) != "1" {
+		t.Errorf("override seri harus dihapus")
+	}
+}
+
+func TestUpdateRecurringEventRejectsUnknownOccurrence(t *testing.T) {
+	db, _ := newFakeGorm(t, weeklyMaster())
+	updated := timedEvent(0, "Moved", wib(2026, 1, 20, 9, 0), time.Hour, "")
+	_, err := UpdateRecurringEvent[recurringTestEvent](db, testTable, 1, &updated, ScopeThis, "2026-01-20T09:00:00+07:00")
+	if err == nil || !strings.Contains(err.Error(), ErrInvalidOccurrence.Error()) {
+		t.Errorf("err = %v, want ErrInvalidOccurrence", err)
+	}
+}
+
+func TestDeleteRecurringEventThis(t *testing.T) {
+	db, fake := newFakeGorm(t, weeklyMaster())
+	if err := DeleteRecurringEvent[recurringTestEvent](db, testTable, 1, ScopeThis, "2026-01-19 09:00"); err != nil {
+		t.Fatal(err)
+	}
+	updates := fake.execs("UPDATE")
+	if len(updates) != 1 {
+		t.Fatalf("%d UPDATE, want 1", len(updates))
+	}
+	want := "2026-01-12T09:00:00+07:00,2026-01-26T09:00:00+07:00,2026-01-19T09:00:00+07:00"
+	if got := mustValue(t, updates[0], "ex_dates"); got != want {
+		t.Errorf("ex_dates = %v, want %s", got, want)
+	}
+	if got := mustValue(t, updates[0], "rrule"); got != "FREQ=WEEKLY;BYDAY=MO" {
+		t.Errorf("rrule = %v, want tidak berubah", got)
+	}
+}
+
+func TestDeleteRecurringEventFollowing(t *testing.T) {
+	before := overrideOf(4, wib(2026, 1, 5, 9, 0), wib(2026, 1, 5, 11, 0))
+	after := overrideOf(5, wib(2026, 2, 2, 9, 0), wib(2026, 2, 2, 11, 0))
+	db, fake := newFakeGorm(t, weeklyMaster(), before, after)
+	if err := DeleteRecurringEvent[recurringTestEvent](db, testTable, 1, ScopeFollowing, "2026-01-19T09:00:00+07:00"); err != nil {
+		t.Fatal(err)
+	}
+	deletes := fake.execs("DELETE")
+	if len(deletes) != 1 || fmt.Sprint(mustValue(t, deletes[0], "id")) != "5" {
+		t.Errorf("hanya override setelah kejadian yang boleh dihapus")
+	}
+	update := fake.execs("UPDATE")[0]
+	if got := mustValue(t, update, "rrule"); got != "FREQ=WEEKLY;UNTIL=20260119T015959Z;BYDAY=MO" {
+		t.Errorf("rrule master = %v", got)
+	}
+	if got := mustValue(t, update, "ex_dates"); got != "2026-01-12T09:00:00+07:00" {
+		t.Errorf("ex_dates master = %v", got)
+	}
+}
+
+func TestDeleteRecurringEventAll(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		scope RecurrenceScope
+		key   string
+	}{
+		{"scope all", ScopeAll, ""},
+		{"following from the first occurrence", ScopeFollowing, "2026-01-05T09:00:00+07:00"},
+	} {
+		db, fake := newFakeGorm(t, weeklyMaster())
+		if err := DeleteRecurringEvent[recurringTestEvent](db, testTable, 1, tt.scope, tt.key); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		deletes := fake.execs("DELETE")
+		if len(deletes) != 1 || !strings.Contains(deletes[0].query, "id = $1 OR parent_id = $2") {
+			t.Errorf("%s: want master dan override dihapus, dapat %v", tt.name, deletes)
+		}
+		if len(fake.execs("UPDATE")) != 0 {
+			t.Errorf("%s: tidak boleh ada UPDATE", tt.name)
+		}
+	}
+}
+
+func TestDeleteRecurringEventFromOverride(t *testing.T) {
+	override := overrideOf(5, wib(2026, 1, 19, 9, 0), wib(2026, 1, 19, 11, 0))
+	db, fake := newFakeGorm(t, weeklyMaster(), override)
+	if err := DeleteRecurringEvent[recurringTestEvent](db, testTable, 5, ScopeThis, ""); err != nil {
+		t.Fatal(err)
+	}
+	// Override dihapus dan kejadian aslinya dicatat di ExDates master agar tidak muncul lagi
+	if got := mustValue(t, fake.execs("UPDATE")[0], "ex_dates"); !strings.HasSuffix(fmt.Sprint(got), ",2026-01-19T09:00:00+07:00") {
+		t.Errorf("ex_dates = %v", got)
+	}
+}
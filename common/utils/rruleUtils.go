@@ -0,0 +1,420 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Batas iterasi periode agar RRULE yang tidak pernah cocok (misal BYMONTHDAY=31;BYMONTH=2) tidak berputar selamanya
+const rruleMaxPeriods = 50000
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// RRuleWeekday adalah satu nilai BYDAY, N adalah urutan dalam bulan/tahun (misal -1FR = Jumat terakhir), 0 berarti setiap
+type RRuleWeekday struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// RRule adalah subset RFC 5545 RRULE: FREQ DAILY/WEEKLY/MONTHLY/YEARLY dengan INTERVAL, COUNT, UNTIL,
+// BYDAY, BYMONTHDAY, BYMONTH, BYSETPOS dan WKST
+type RRule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      time.Time // Zero berarti tanpa batas
+	ByDay      []RRuleWeekday
+	ByMonthDay []int
+	ByMonth    []int
+	BySetPos   []int
+	WeekStart  time.Weekday
+}
+
+func parseRRuleInts(value string, min, max int) ([]int, error) {
+	var result []int
+	for _, part := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n == 0 || n < min || n > max {
+			return nil, fmt.Errorf("nilai %q tidak valid", part)
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+// parseRRuleUntil membaca UNTIL dalam bentuk UTC (…Z), waktu lokal, atau tanggal (inklusif sampai akhir hari)
+func parseRRuleUntil(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("20060102", value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("UNTIL %q tidak valid", value)
+	}
+	return t.AddDate(0, 0, 1).Add(-time.Second), nil
+}
+
+// ParseRRule membaca nilai RRULE (tanpa awalan "RRULE:"), loc dipakai untuk UNTIL tanpa zona
+func ParseRRule(value string, loc *time.Location) (RRule, error) {
+	rule := RRule{Interval: 1, WeekStart: time.Monday}
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return rule, fmt.Errorf("bagian RRULE %q tidak valid", part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(val)
+		case "INTERVAL":
+			if rule.Interval, err = strconv.Atoi(val); err != nil || rule.Interval < 1 {
+				return rule, fmt.Errorf("INTERVAL %q tidak valid", val)
+			}
+		case "COUNT":
+			if rule.Count, err = strconv.Atoi(val); err != nil || rule.Count < 1 {
+				return rule, fmt.Errorf("COUNT %q tidak valid", val)
+			}
+		case "UNTIL":
+			if rule.Until, err = parseRRuleUntil(val, loc); err != nil {
+				return rule, err
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(val), ",") {
+				day = strings.TrimSpace(day)
+				if len(day) < 2 {
+					return rule, fmt.Errorf("BYDAY %q tidak valid", day)
+				}
+				weekday, ok := rruleWeekdays[day[len(day)-2:]]
+				if !ok {
+					return rule, fmt.Errorf("BYDAY %q tidak valid", day)
+				}
+				n := 0
+				if prefix := day[:len(day)-2]; prefix != "" {
+					if n, err = strconv.Atoi(prefix); err != nil || n == 0 || n < -53 || n > 53 {
+						return rule, fmt.Errorf("BYDAY %q tidak valid", day)
+					}
+				}
+				rule.ByDay = append(rule.ByDay, RRuleWeekday{Weekday: weekday, N: n})
+			}
+		case "BYMONTHDAY":
+			if rule.ByMonthDay, err = parseRRuleInts(val, -31, 31); err != nil {
+				return rule, fmt.Errorf("BYMONTHDAY: %v", err)
+			}
+		case "BYMONTH":
+			if rule.ByMonth, err = parseRRuleInts(val, 1, 12); err != nil {
+				return rule, fmt.Errorf("BYMONTH: %v", err)
+			}
+		case "BYSETPOS":
+			if rule.BySetPos, err = parseRRuleInts(val, -366, 366); err != nil {
+				return rule, fmt.Errorf("BYSETPOS: %v", err)
+			}
+		case "WKST":
+			weekday, ok := rruleWeekdays[strings.ToUpper(val)]
+			if !ok {
+				return rule, fmt.Errorf("WKST %q tidak valid", val)
+			}
+			rule.WeekStart = weekday
+		default:
+			// BYHOUR, BYMINUTE, BYWEEKNO, dll. belum didukung
+			return rule, fmt.Errorf("bagian RRULE %s belum didukung", key)
+		}
+	}
+
+	switch rule.Freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	case "":
+		return rule, fmt.Errorf("RRULE tanpa FREQ")
+	default:
+		return rule, fmt.Errorf("FREQ %s belum didukung", rule.Freq)
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return rule, fmt.Errorf("COUNT dan UNTIL tidak boleh dipakai bersamaan")
+	}
+	return rule, nil
+}
+
+// String menulis ulang aturan dalam format RRULE
+func (r RRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	joinInts := func(values []int) string {
+		s := make([]string, len(values))
+		for i, v := range values {
+			s[i] = strconv.Itoa(v)
+		}
+		return strings.Join(s, ",")
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			code := strings.ToUpper(d.Weekday.String()[:2])
+			if d.N != 0 {
+				code = strconv.Itoa(d.N) + code
+			}
+			days[i] = code
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinInts(r.BySetPos))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+strings.ToUpper(r.WeekStart.String()[:2]))
+	}
+	return strings.Join(parts, ";")
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func daysIn(year int, month time.Month, loc *time.Location) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+}
+
+// dayDiff menghitung selisih hari kalender, aman terhadap pergantian DST
+func dayDiff(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// matchMonthDay memeriksa BYMONTHDAY termasuk nilai negatif (-1 = hari terakhir bulan)
+func (r RRule) matchMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := daysIn(day.Year(), day.Month(), day.Location())
+	for _, md := range r.ByMonthDay {
+		if md == day.Day() || (md < 0 && last+md+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWeekday memeriksa BYDAY tanpa urutan
+func (r RRule) matchWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, d := range r.ByDay {
+		if d.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOrdinalWeekday memeriksa BYDAY dengan urutan di dalam rentang [first, last]
+func (r RRule) matchOrdinalWeekday(day, first, last time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, d := range r.ByDay {
+		if d.Weekday != day.Weekday() {
+			continue
+		}
+		if d.N == 0 {
+			return true
+		}
+		if d.N > 0 && dayDiff(first, day)/7+1 == d.N {
+			return true
+		}
+		if d.N < 0 && dayDiff(day, last)/7+1 == -d.N {
+			return true
+		}
+	}
+	return false
+}
+
+// periodDays mengembalikan kandidat hari untuk periode ke-n sesuai FREQ, sudah difilter BYxxx
+func (r RRule) periodDays(first time.Time, n int) []time.Time {
+	loc := first.Location()
+	var days []time.Time
+	switch r.Freq {
+	case "DAILY":
+		day := first.AddDate(0, 0, n*r.Interval)
+		if (len(r.ByMonth) == 0 || containsInt(r.ByMonth, int(day.Month()))) && r.matchMonthDay(day) && r.matchWeekday(day) {
+			days = append(days, day)
+		}
+
+	case "WEEKLY":
+		weekStart := first.AddDate(0, 0, -((int(first.Weekday())-int(r.WeekStart)+7)%7)+7*n*r.Interval)
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if len(r.ByDay) == 0 && day.Weekday() != first.Weekday() {
+				continue
+			}
+			if r.matchWeekday(day) && (len(r.ByMonth) == 0 || containsInt(r.ByMonth, int(day.Month()))) {
+				days = append(days, day)
+			}
+		}
+
+	case "MONTHLY":
+		monthStart := time.Date(first.Year(), first.Month()+time.Month(n*r.Interval), 1, 0, 0, 0, 0, loc)
+		if len(r.ByMonth) > 0 && !containsInt(r.ByMonth, int(monthStart.Month())) {
+			return nil
+		}
+		days = r.monthDays(monthStart, first)
+
+	case "YEARLY":
+		year := first.Year() + n*r.Interval
+		months := r.ByMonth
+		if len(months) == 0 && len(r.ByDay) > 0 && len(r.ByMonthDay) == 0 {
+			// BYDAY tanpa BYMONTH: urutan dihitung dalam setahun
+			yearStart := time.Date(year, 1, 1, 0, 0, 0, 0, loc)
+			yearEnd := time.Date(year, 12, 31, 0, 0, 0, 0, loc)
+			for day := yearStart; !day.After(yearEnd); day = day.AddDate(0, 0, 1) {
+				if r.matchOrdinalWeekday(day, yearStart, yearEnd) {
+					days = append(days, day)
+				}
+			}
+			break
+		}
+		if len(months) == 0 {
+			if len(r.ByMonthDay) > 0 {
+				months = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+			} else {
+				months = []int{int(first.Month())}
+			}
+		}
+		sorted := append([]int(nil), months...)
+		sort.Ints(sorted)
+		for _, month := range sorted {
+			days = append(days, r.monthDays(time.Date(year, time.Month(month), 1, 0, 0, 0, 0, loc), first)...)
+		}
+	}
+	return r.applySetPos(days)
+}
+
+// periodStart adalah batas bawah hari pertama periode ke-n, dipakai untuk berhenti walau periode kosong
+func (r RRule) periodStart(first time.Time, n int) time.Time {
+	switch r.Freq {
+	case "DAILY":
+		return first.AddDate(0, 0, n*r.Interval)
+	case "WEEKLY":
+		return first.AddDate(0, 0, 7*n*r.Interval-6)
+	case "MONTHLY":
+		return time.Date(first.Year(), first.Month()+time.Month(n*r.Interval), 1, 0, 0, 0, 0, first.Location())
+	default:
+		return time.Date(first.Year()+n*r.Interval, 1, 1, 0, 0, 0, 0, first.Location())
+	}
+}
+
+// monthDays mengembalikan kandidat hari dalam satu bulan untuk MONTHLY/YEARLY
+func (r RRule) monthDays(monthStart, first time.Time) []time.Time {
+	monthEnd := monthStart.AddDate(0, 1, -1)
+	var days []time.Time
+	if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+		// Tanpa BYxxx: tanggal yang sama dengan DTSTART, bulan yang tidak punya tanggal itu dilewati
+		if first.Day() <= monthEnd.Day() {
+			days = append(days, time.Date(monthStart.Year(), monthStart.Month(), first.Day(), 0, 0, 0, 0, monthStart.Location()))
+		}
+		return days
+	}
+	for day := monthStart; !day.After(monthEnd); day = day.AddDate(0, 0, 1) {
+		if r.matchMonthDay(day) && r.matchOrdinalWeekday(day, monthStart, monthEnd) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+func (r RRule) applySetPos(days []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 || len(days) == 0 {
+		return days
+	}
+	var result []time.Time
+	for i, day := range days {
+		if containsInt(r.BySetPos, i+1) || containsInt(r.BySetPos, i-len(days)) {
+			result = append(result, day)
+		}
+	}
+	return result
+}
+
+// LastStart mengembalikan batas waktu mulai kejadian terakhir seri: UNTIL, atau start kejadian ke-COUNT.
+// Nilai bool false untuk seri tanpa batas.
+func (r RRule) LastStart(dtstart time.Time) (time.Time, bool) {
+	if !r.Until.IsZero() {
+		return r.Until.In(dtstart.Location()), true
+	}
+	if r.Count == 0 {
+		return time.Time{}, false
+	}
+	starts := r.Between(dtstart, dtstart, time.Date(9999, 12, 31, 0, 0, 0, 0, dtstart.Location()), 0)
+	return starts[len(starts)-1], true
+}
+
+// Between mengembalikan waktu mulai setiap kejadian dari dtstart yang jatuh di [from, to), paling banyak limit.
+// Jam dinding DTSTART dipertahankan di zona dtstart. DTSTART selalu dihitung sebagai kejadian pertama
+// sesuai RFC 5545, dan COUNT dihitung sejak DTSTART walaupun kejadiannya sebelum from.
+func (r RRule) Between(dtstart, from, to time.Time, limit int) []time.Time {
+	loc := dtstart.Location()
+	first := time.Date(dtstart.Year(), dtstart.Month(), dtstart.Day(), 0, 0, 0, 0, loc)
+	at := func(day time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, loc)
+	}
+
+	var result []time.Time
+	count := 0
+	emit := func(t time.Time) bool {
+		count++
+		if !t.Before(from) && t.Before(to) {
+			result = append(result, t)
+		}
+		return (r.Count > 0 && count >= r.Count) || (limit > 0 && len(result) >= limit)
+	}
+
+	if emit(dtstart) {
+		return result
+	}
+	for n := 0; n < rruleMaxPeriods && r.periodStart(first, n).Before(to); n++ {
+		for _, day := range r.periodDays(first, n) {
+			t := at(day)
+			if !t.After(dtstart) {
+				continue
+			}
+			if (!r.Until.IsZero() && t.After(r.Until)) || !t.Before(to) {
+				return result
+			}
+			if emit(t) {
+				return result
+			}
+		}
+	}
+	return result
+}
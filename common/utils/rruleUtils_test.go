@@ -0,0 +1,233 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// formatStarts menulis daftar waktu sebagai "2006-01-02 15:04" WIB agar mudah dibandingkan
+func formatStarts(times []time.Time) string {
+	parts := make([]string, len(times))
+	for i, t := range times {
+		parts[i] = t.In(jakartaLocation()).Format("2006-01-02 15:04")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func TestParseRRuleRejectsInvalidRules(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"FREQ=HOURLY",
+		"INTERVAL=2",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20260101",
+		"FREQ=DAILY;UNTIL=2026-01-01",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=0MO",
+		"FREQ=MONTHLY;BYDAY=54MO",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=WEEKLY;WKST=XX",
+		"FREQ=DAILY;BYHOUR=9",
+		"FREQ=DAILY;COUNT",
+	} {
+		if _, err := ParseRRule(value, jakartaLocation()); err == nil {
+			t.Errorf("ParseRRule(%q) tidak mengembalikan error", value)
+		}
+	}
+}
+
+func TestRRuleString(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"RRULE:freq=weekly;byday=mo,-1fr;interval=2", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,-1FR"},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,-1;COUNT=6", "FREQ=MONTHLY;COUNT=6;BYMONTHDAY=1,-1"},
+		{"FREQ=DAILY;UNTIL=20260131T170000", "FREQ=DAILY;UNTIL=20260131T100000Z"},
+		{"FREQ=DAILY;UNTIL=20260131T100000Z", "FREQ=DAILY;UNTIL=20260131T100000Z"},
+		{"FREQ=DAILY;UNTIL=20260131", "FREQ=DAILY;UNTIL=20260131T165959Z"},
+		{"FREQ=YEARLY;BYMONTH=11;BYDAY=4TH;WKST=SU", "FREQ=YEARLY;BYDAY=4TH;BYMONTH=11;WKST=SU"},
+		{"FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1"},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRRule(tt.in, jakartaLocation())
+		if err != nil {
+			t.Errorf("ParseRRule(%q): %v", tt.in, err)
+			continue
+		}
+		if got := rule.String(); got != tt.want {
+			t.Errorf("ParseRRule(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+		// Hasil String harus bisa dibaca ulang tanpa berubah
+		again, err := ParseRRule(rule.String(), jakartaLocation())
+		if err != nil || again.String() != tt.want {
+			t.Errorf("round trip %q = %q, %v", tt.want, again.String(), err)
+		}
+	}
+}
+
+func TestRRuleBetween(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		dtstart  time.Time
+		from, to time.Time // Zero berarti dtstart sampai dua tahun setelahnya
+		limit    int
+		want     string
+	}{
+		{
+			name:    "daily count",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: wib(2026, 1, 5, 9, 0),
+			want:    "2026-01-05 09:00, 2026-01-06 09:00, 2026-01-07 09:00",
+		},
+		{
+			name:    "weekly byday count",
+			rule:    "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4",
+			dtstart: wib(2026, 1, 5, 9, 0),
+			want:    "2026-01-05 09:00, 2026-01-07 09:00, 2026-01-12 09:00, 2026-01-14 09:00",
+		},
+		{
+			name:    "biweekly until in UTC",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU;UNTIL=20260210T235959Z",
+			dtstart: wib(2026, 1, 6, 13, 30),
+			want:    "2026-01-06 13:30, 2026-01-20 13:30, 2026-02-03 13:30",
+		},
+		{
+			name:    "until as date is inclusive",
+			rule:    "FREQ=DAILY;UNTIL=20260107",
+			dtstart: wib(2026, 1, 5, 9, 0),
+			want:    "2026-01-05 09:00, 2026-01-06 09:00, 2026-01-07 09:00",
+		},
+		{
+			name:    "until equal to an occurrence includes it",
+			rule:    "FREQ=DAILY;UNTIL=20260107T020000Z",
+			dtstart: wib(2026, 1, 5, 9, 0),
+			want:    "2026-01-05 09:00, 2026-01-06 09:00, 2026-01-07 09:00",
+		},
+		{
+			name:    "monthly last friday",
+			rule:    "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			dtstart: wib(2026, 1, 30, 10, 0),
+			want:    "2026-01-30 10:00, 2026-02-27 10:00, 2026-03-27 10:00",
+		},
+		{
+			name:    "monthly second monday",
+			rule:    "FREQ=MONTHLY;BYDAY=2MO;COUNT=3",
+			dtstart: wib(2026, 1, 12, 8, 0),
+			want:    "2026-01-12 08:00, 2026-02-09 08:00, 2026-03-09 08:00",
+		},
+		{
+			name:    "monthly on the 31st skips short months",
+			rule:    "FREQ=MONTHLY;COUNT=3",
+			dtstart: wib(2026, 1, 31, 8, 0),
+			want:    "2026-01-31 08:00, 2026-03-31 08:00, 2026-05-31 08:00",
+		},
+		{
+			name:    "monthly last day of month",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3",
+			dtstart: wib(2026, 1, 31, 8, 0),
+			want:    "2026-01-31 08:00, 2026-02-28 08:00, 2026-03-31 08:00",
+		},
+		{
+			name:    "monthly last weekday with bysetpos",
+			rule:    "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1;COUNT=3",
+			dtstart: wib(2026, 1, 30, 16, 0),
+			want:    "2026-01-30 16:00, 2026-02-27 16:00, 2026-03-31 16:00",
+		},
+		{
+			name:    "yearly fourth thursday of november",
+			rule:    "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH;COUNT=2",
+			dtstart: wib(2026, 11, 26, 9, 0),
+			want:    "2026-11-26 09:00, 2027-11-25 09:00",
+		},
+		{
+			name:    "yearly ordinal weekday within the year",
+			rule:    "FREQ=YEARLY;BYDAY=1MO;COUNT=2",
+			dtstart: wib(2026, 1, 5, 9, 0),
+			want:    "2026-01-05 09:00, 2027-01-04 09:00",
+		},
+		{
+			name:    "dtstart that does not match the rule is the first occurrence",
+			rule:    "FREQ=WEEKLY;BYDAY=WE;COUNT=3",
+			dtstart: wib(2026, 1, 5, 9, 0),
+			want:    "2026-01-05 09:00, 2026-01-07 09:00, 2026-01-14 09:00",
+		},
+		{
+			name:    "window in the middle of an unbounded series",
+			rule:    "FREQ=DAILY",
+			dtstart: wib(2026, 1, 5, 9, 0),
+			from:    wib(2026, 1, 10, 0, 0),
+			to:      wib(2026, 1, 12, 0, 0),
+			want:    "2026-01-10 09:00, 2026-01-11 09:00",
+		},
+		{
+			name:    "count is counted from dtstart before the window",
+			rule:    "FREQ=DAILY;COUNT=5",
+			dtstart: wib(2026, 1, 5, 9, 0),
+			from:    wib(2026, 1, 8, 0, 0),
+			to:      wib(2026, 1, 20, 0, 0),
+			want:    "2026-01-08 09:00, 2026-01-09 09:00",
+		},
+		{
+			name:    "to is exclusive",
+			rule:    "FREQ=DAILY",
+			dtstart: wib(2026, 1, 5, 9, 0),
+			from:    wib(2026, 1, 5, 9, 0),
+			to:      wib(2026, 1, 7, 9, 0),
+			want:    "2026-01-05 09:00, 2026-01-06 09:00",
+		},
+		{
+			name:    "limit",
+			rule:    "FREQ=DAILY",
+			dtstart: wib(2026, 1, 5, 9, 0),
+			limit:   2,
+			want:    "2026-01-05 09:00, 2026-01-06 09:00",
+		},
+		{
+			name:    "rule that never matches stops",
+			rule:    "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			dtstart: wib(2026, 1, 5, 9, 0),
+			want:    "2026-01-05 09:00",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRRule(tt.rule, jakartaLocation())
+			if err != nil {
+				t.Fatalf("ParseRRule(%q): %v", tt.rule, err)
+			}
+			from, to := tt.from, tt.to
+			if from.IsZero() {
+				from, to = tt.dtstart, tt.dtstart.AddDate(2, 0, 0)
+			}
+			if got := formatStarts(rule.Between(tt.dtstart, from, to, tt.limit)); got != tt.want {
+				t.Errorf("Between() = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRRuleLastStart(t *testing.T) {
+	dtstart := wib(2026, 1, 5, 9, 0)
+	tests := []struct {
+		rule    string
+		want    time.Time
+		bounded bool
+	}{
+		{"FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4", wib(2026, 1, 14, 9, 0), true},
+		{"FREQ=DAILY;COUNT=1", dtstart, true},
+		{"FREQ=DAILY;UNTIL=20260110T020000Z", wib(2026, 1, 10, 9, 0), true},
+		{"FREQ=MONTHLY", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRRule(tt.rule, jakartaLocation())
+		if err != nil {
+			t.Fatalf("ParseRRule(%q): %v", tt.rule, err)
+		}
+		got, bounded := rule.LastStart(dtstart)
+		if bounded != tt.bounded || !got.Equal(tt.want) {
+			t.Errorf("LastStart(%q) = %s, %v; want %s, %v", tt.rule, got, bounded, tt.want, tt.bounded)
+		}
+	}
+}
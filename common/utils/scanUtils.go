@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/models"
+)
+
+const clamdChunkSize = 64 * 1024
+
+// eicarSignature adalah string uji standar antivirus, dipakai oleh scanner lokal
+const eicarSignature = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+type ScanResult struct {
+	Infected  bool
+	Signature string
+}
+
+// VirusScanner adalah interface untuk pemindai file upload
+type VirusScanner interface {
+	Scan(r io.Reader) (ScanResult, error)
+}
+
+// ClamdScanner berbicara dengan daemon ClamAV memakai perintah INSTREAM
+type ClamdScanner struct {
+	Network string // "unix" atau "tcp"
+	Address string
+	Timeout time.Duration
+}
+
+func (s ClamdScanner) Scan(r io.Reader) (ScanResult, error) {
+	conn, err := net.DialTimeout(s.Network, s.Address, s.Timeout)
+	if err != nil {
+		return ScanResult{}, fmt.Errorf("gagal terhubung ke clamd: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(s.Timeout))
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return ScanResult{}, err
+	}
+
+	// Setiap chunk diawali panjang 4 byte big-endian, diakhiri chunk kosong
+	buf := make([]byte, clamdChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return ScanResult{}, err
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return ScanResult{}, err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return ScanResult{}, readErr
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return ScanResult{}, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return ScanResult{}, err
+	}
+	return parseClamdReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parseClamdReply mengolah balasan seperti "stream: OK" atau "stream: Eicar-Signature FOUND"
+func parseClamdReply(reply string) (ScanResult, error) {
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return ScanResult{}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return ScanResult{Infected: true, Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+	default:
+		return ScanResult{}, fmt.Errorf("balasan clamd tidak dikenal: %s", reply)
+	}
+}
+
+// LocalScanner adalah pengganti clamd untuk development, hanya mendeteksi file uji EICAR
+type LocalScanner struct{}
+
+func (LocalScanner) Scan(r io.Reader) (ScanResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	if bytes.Contains(data, []byte(eicarSignature)) {
+		return ScanResult{Infected: true, Signature: "Eicar-Test-Signature"}, nil
+	}
+	return ScanResult{}, nil
+}
+
+// UnavailableScanner dipakai jika tidak ada scanner yang diatur. Semua file ditandai error (fail closed)
+// sehingga tidak bisa diunduh sampai dipindai ulang dengan scanner yang benar.
+type UnavailableScanner struct{}
+
+func (UnavailableScanner) Scan(r io.Reader) (ScanResult, error) {
+	return ScanResult{}, fmt.Errorf("scanner virus belum diatur: isi CLAMD_ADDRESS")
+}
+
+// NewVirusScanner membaca CLAMD_ADDRESS, misal "unix:///var/run/clamav/clamd.ctl" atau "tcp://clamav:3310".
+// Jika kosong, LocalScanner hanya dipakai bila VIRUS_SCAN_LOCAL=true (development), selain itu
+// UnavailableScanner.
+func NewVirusScanner() VirusScanner {
+	address := os.Getenv("CLAMD_ADDRESS")
+	if address == "" {
+		if os.Getenv("VIRUS_SCAN_LOCAL") == "true" {
+			log.Printf("PERINGATAN: CLAMD_ADDRESS kosong, memakai LocalScanner yang hanya mendeteksi EICAR. Jangan dipakai di production.")
+			return LocalScanner{}
+		}
+		log.Printf("PERINGATAN: CLAMD_ADDRESS kosong, file upload ditandai error sampai scanner diatur")
+		return UnavailableScanner{}
+	}
+	network, addr, found := strings.Cut(address, "://")
+	if !found {
+		network, addr = "tcp", address
+	}
+	return ClamdScanner{Network: network, Address: addr, Timeout: 60 * time.Second}
+}
+
+// quarantineDir mengembalikan direktori karantina dari QUARANTINE_DIR
+func quarantineDir() string {
+	dir := os.Getenv("QUARANTINE_DIR")
+	if dir == "" {
+		dir = "/app/UploadedFile/.quarantine"
+	}
+	return dir
+}
+
+// ScanStoredFile memindai file di disk lalu memperbarui status scan pada metadata.
+// File yang terinfeksi dipindahkan ke direktori karantina.
+func ScanStoredFile(file *models.File) {
+	path := file.FilePath
+	if file.QuarantinePath != "" {
+		path = file.QuarantinePath
+	}
+
+	now := time.Now()
+	file.ScannedAt = &now
+
+	f, err := os.Open(path)
+	if err != nil {
+		file.ScanStatus = models.ScanError
+		file.ScanResult = err.Error()
+		return
+	}
+	result, err := NewVirusScanner().Scan(f)
+	f.Close()
+
+	switch {
+	case err != nil:
+		log.Printf("Error memindai %s: %v", path, err)
+		file.ScanStatus = models.ScanError
+		file.ScanResult = err.Error()
+	case result.Infected:
+		log.Printf("File %s terinfeksi: %s", path, result.Signature)
+		file.ScanStatus = models.ScanInfected
+		file.ScanResult = result.Signature
+		if file.QuarantinePath == "" {
+			quarantinePath, err := moveToQuarantine(path)
+			if err != nil {
+				log.Printf("Error memindahkan %s ke karantina: %v", path, err)
+				return
+			}
+			file.QuarantinePath = quarantinePath
+		}
+	default:
+		file.ScanStatus = models.ScanClean
+		file.ScanResult = ""
+	}
+}
+
+func moveToQuarantine(path string) (string, error) {
+	dir := quarantineDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	target := filepath.Join(dir, fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(path)))
+	if err := os.Rename(path, target); err != nil {
+		return "", err
+	}
+	// Cegah file karantina ikut tereksekusi atau terbaca
+	os.Chmod(target, 0o400)
+	return filepath.ToSlash(target), nil
+}
@@ -0,0 +1,341 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	"github.com/arkaramadhan/its-vo/common/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Kategori inkonsistensi yang dilaporkan oleh ReconcileStorage
+const (
+	IssueMissingFile      = "missing_file"      // Metadata ada, file tidak ada
+	IssueUntrackedFile    = "untracked_file"    // File ada, metadata tidak ada
+	IssueOrphanRecord     = "orphan_record"     // File/metadata milik record yang sudah dihapus
+	IssueMissingChecksum  = "missing_checksum"  // Metadata belum memiliki checksum
+	IssueChecksumMismatch = "checksum_mismatch" // Isi file tidak sesuai checksum tersimpan
+	IssueUnrecognizedPath = "unrecognized_path" // Path bukan <root>/<id>/<file>, hanya dilaporkan dan tidak pernah dihapus
+)
+
+// StorageResource memetakan direktori upload ke tabel pemilik record-nya
+type StorageResource struct {
+	Name       string
+	BaseDir    string
+	Table      string
+	SoftDelete bool // true jika tabel memakai gorm.Model (deleted_at)
+}
+
+var StorageResources = []StorageResource{
+	{Name: "memo", BaseDir: "/app/UploadedFile/memo", Table: "dokumen.memos"},
+	{Name: "beritaacara", BaseDir: "/app/UploadedFile/beritaacara", Table: "dokumen.berita_acaras"},
+	{Name: "surat", BaseDir: "/app/UploadedFile/surat", Table: "dokumen.surats"},
+	{Name: "sk", BaseDir: "/app/UploadedFile/sk", Table: "dokumen.sks"},
+	{Name: "perdin", BaseDir: "/app/UploadedFile/perdin", Table: "dokumen.perdins"},
+	{Name: "suratmasuk", BaseDir: "/app/UploadedFile/suratmasuk", Table: "informasi.surat_masuks"},
+	{Name: "suratkeluar", BaseDir: "/app/UploadedFile/suratkeluar", Table: "informasi.surat_keluars"},
+	{Name: "arsip", BaseDir: "/app/UploadedFile/arsip", Table: "informasi.arsips", SoftDelete: true},
+	{Name: "project", BaseDir: "/app/UploadedFile/project", Table: "project.projects"},
+	{Name: "meeting", BaseDir: "/app/UploadedFile/meeting", Table: "kegiatan.meetings"},
+	{Name: "meetingschedule", BaseDir: "/app/UploadedFile/meetingschedule", Table: "weekly_timeline.meeting_schedules"},
+}
+
+type StorageIssue struct {
+	Resource string `json:"resource"`
+	Category string `json:"category"`
+	RecordID uint   `json:"record_id"`
+	FileID   uint   `json:"file_id,omitempty"`
+	FilePath string `json:"file_path"`
+	Detail   string `json:"detail,omitempty"`
+	Fixed    bool   `json:"fixed"`
+}
+
+type StorageReport struct {
+	Apply      bool           `json:"apply"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Skipped    []string       `json:"skipped"`
+	Summary    map[string]int `json:"summary"`
+	Issues     []StorageIssue `json:"issues"`
+}
+
+func (r *StorageReport) add(issue StorageIssue) {
+	r.Summary[issue.Category]++
+	r.Issues = append(r.Issues, issue)
+}
+
+// FileChecksum menghitung SHA-256 dari isi file secara streaming
+func FileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// FindStorageResource mencari resource berdasarkan nama
+func FindStorageResource(name string) (StorageResource, bool) {
+	for _, resource := range StorageResources {
+		if resource.Name == name {
+			return resource, true
+		}
+	}
+	return StorageResource{}, false
+}
+
+// ReconcileStorage membandingkan common.files dengan isi direktori upload.
+// Jika apply false, hanya laporan yang dibuat tanpa mengubah apa pun.
+func ReconcileStorage(resources []StorageResource, apply bool) (*StorageReport, error) {
+	report := &StorageReport{
+		Apply:     apply,
+		StartedAt: time.Now(),
+		Summary:   make(map[string]int),
+	}
+
+	for _, resource := range resources {
+		// Jika root tidak ter-mount, jangan anggap semua file hilang
+		if _, err := os.Stat(resource.BaseDir); os.IsNotExist(err) {
+			log.Printf("Root %s tidak ditemukan, resource %s dilewati", resource.BaseDir, resource.Name)
+			report.Skipped = append(report.Skipped, resource.Name)
+			continue
+		}
+		if err := reconcileResource(resource, apply, report); err != nil {
+			return nil, fmt.Errorf("gagal memeriksa %s: %v", resource.Name, err)
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+func reconcileResource(resource StorageResource, apply bool, report *StorageReport) error {
+	// Kumpulkan ID record yang masih ada
+	query := initializers.DB.Table(resource.Table)
+	if resource.SoftDelete {
+		query = query.Where("deleted_at IS NULL")
+	}
+	var ids []uint
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	recordExists := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		recordExists[id] = true
+	}
+
+	var files []models.File
+	if err := initializers.DB.Table("common.files").Where("file_path LIKE ?", resource.BaseDir+"/%").Find(&files).Error; err != nil {
+		return err
+	}
+	tracked := make(map[string]bool, len(files))
+
+	// Periksa setiap metadata terhadap sistem file
+	for _, file := range files {
+		path := filepath.Clean(file.FilePath)
+		tracked[path] = true
+		recordID, ok := recordIDFromPath(resource.BaseDir, path)
+
+		issue := StorageIssue{Resource: resource.Name, RecordID: recordID, FileID: file.ID, FilePath: path}
+		if !ok {
+			issue.Category = IssueUnrecognizedPath
+			report.add(issue)
+			continue
+		}
+
+		// File terinfeksi berada di direktori karantina, bukan di root resource
+		if file.QuarantinePath != "" {
+			if recordExists[recordID] {
+				continue
+			}
+			issue.Category = IssueOrphanRecord
+			issue.Detail = "file dikarantina di " + file.QuarantinePath
+			if apply {
+				issue.Fixed = removeFileAndMetadata(quarantineDir(), file.QuarantinePath, file.ID)
+			}
+			report.add(issue)
+			continue
+		}
+
+		_, statErr := os.Stat(path)
+
+		switch {
+		case !recordExists[recordID]:
+			issue.Category = IssueOrphanRecord
+			if apply {
+				issue.Fixed = removeFileAndMetadata(resource.BaseDir, path, file.ID)
+			}
+		case os.IsNotExist(statErr):
+			issue.Category = IssueMissingFile
+			if apply {
+				issue.Fixed = deleteFileMetadata(file.ID)
+			}
+		case file.Checksum == "":
+			issue.Category = IssueMissingChecksum
+			if apply {
+				checksum, err := FileChecksum(path)
+				if err != nil {
+					issue.Detail = err.Error()
+				} else if err := initializers.DB.Table("common.files").Where("id = ?", file.ID).Update("checksum", checksum).Error; err != nil {
+					issue.Detail = err.Error()
+				} else {
+					issue.Fixed = true
+				}
+			}
+		default:
+			checksum, err := FileChecksum(path)
+			if err != nil {
+				issue.Category = IssueChecksumMismatch
+				issue.Detail = err.Error()
+			} else if checksum != file.Checksum {
+				issue.Category = IssueChecksumMismatch
+				issue.Detail = fmt.Sprintf("tersimpan %s, aktual %s", file.Checksum, checksum)
+			} else {
+				continue
+			}
+		}
+		report.add(issue)
+	}
+
+	// Periksa setiap file di disk terhadap metadata
+	return filepath.WalkDir(resource.BaseDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || tracked[filepath.Clean(path)] {
+			return nil
+		}
+
+		recordID, ok := recordIDFromPath(resource.BaseDir, path)
+		issue := StorageIssue{Resource: resource.Name, RecordID: recordID, FilePath: path}
+		switch {
+		case !ok:
+			issue.Category = IssueUnrecognizedPath
+		case recordExists[recordID]:
+			issue.Category = IssueUntrackedFile
+			if apply {
+				issue.Fixed = trackFile(path, recordID, &issue)
+			}
+		default:
+			issue.Category = IssueOrphanRecord
+			if apply {
+				issue.Fixed = removeFileAndMetadata(resource.BaseDir, path, 0)
+			}
+		}
+		report.add(issue)
+		return nil
+	})
+}
+
+// recordIDFromPath mengambil ID record dari path <baseDir>/<id>/<file>. Nilai bool false jika path tidak
+// berbentuk demikian (file di root, subfolder bertingkat, atau folder bukan angka)
+func recordIDFromPath(baseDir, path string) (uint, bool) {
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(filepath.Dir(rel), 10, 32)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func deleteFileMetadata(id uint) bool {
+	if err := initializers.DB.Table("common.files").Where("id = ?", id).Delete(&models.File{}).Error; err != nil {
+		log.Printf("Error menghapus metadata file %d: %v", id, err)
+		return false
+	}
+	return true
+}
+
+func removeFileAndMetadata(baseDir, path string, fileID uint) bool {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Error menghapus file %s: %v", path, err)
+		return false
+	}
+	// Hapus folder record jika sudah kosong, tapi jangan hapus root resource
+	dir := filepath.Dir(path)
+	if dir != filepath.Clean(baseDir) {
+		if entries, err := os.ReadDir(dir); err == nil && len(entries) == 0 {
+			os.Remove(dir)
+		}
+	}
+	if fileID != 0 {
+		return deleteFileMetadata(fileID)
+	}
+	return true
+}
+
+func trackFile(path string, recordID uint, issue *StorageIssue) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		issue.Detail = err.Error()
+		return false
+	}
+	checksum, err := FileChecksum(path)
+	if err != nil {
+		issue.Detail = err.Error()
+		return false
+	}
+
+	contentType := "application/octet-stream"
+	if f, err := os.Open(path); err == nil {
+		buf := make([]byte, 512)
+		n, _ := f.Read(buf)
+		contentType = http.DetectContentType(buf[:n])
+		f.Close()
+	}
+
+	newFile := models.File{
+		UserID:      recordID,
+		FilePath:    filepath.ToSlash(path),
+		FileName:    filepath.Base(path),
+		ContentType: contentType,
+		Size:        info.Size(),
+		Checksum:    checksum,
+	}
+	ScanStoredFile(&newFile)
+	if err := initializers.DB.Table("common.files").Create(&newFile).Error; err != nil {
+		issue.Detail = err.Error()
+		return false
+	}
+	issue.FileID = newFile.ID
+	return true
+}
+
+// ReconcileStorageHandler menjalankan pemeriksaan storage.
+// Query: resource (opsional), apply=true untuk memperbaiki; apply hanya diizinkan lewat POST.
+func ReconcileStorageHandler(c *gin.Context) {
+	resources := StorageResources
+	if name := c.Query("resource"); name != "" {
+		resource, ok := FindStorageResource(name)
+		if !ok {
+			RespondError(c, http.StatusBadRequest, "resource tidak dikenal: "+name)
+			return
+		}
+		resources = []StorageResource{resource}
+	}
+
+	apply := c.Request.Method == http.MethodPost && c.Query("apply") == "true"
+	report, err := ReconcileStorage(resources, apply)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -140,14 +140,26 @@ func UploadHandler(c *gin.Context, baseDir string) {
 		return
 	}
 
+	if err := CheckStorageQuota(baseDir, file.Size); err != nil {
+		respondQuotaError(c, err)
+		return
+	}
+
 	dir := filepath.Join(baseDir, id)
 
 	filePath := filepath.ToSlash(filepath.Join(dir, file.Filename))
+	_, statErr := os.Stat(filePath)
+	replaced := statErr == nil
 	if err := c.SaveUploadedFile(file, filePath); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal menyimpan file"})
 		return
 	}
 
+	checksum, err := FileChecksum(filePath)
+	if err != nil {
+		log.Printf("Error menghitung checksum %s: %v", filePath, err)
+	}
+
 	// Simpan metadata ke database
 	newFile := models.File{
 		UserID:      uint(userID),
@@ -155,16 +167,47 @@ func UploadHandler(c *gin.Context, baseDir string) {
 		FileName:    file.Filename,
 		ContentType: file.Header.Get("Content-Type"),
 		Size:        file.Size,
+		Checksum:    checksum,
+		UploadedBy:  c.GetString("username"),
 	}
-	result := initializers.DB.Table("common.files").Create(&newFile)
-	if result.Error != nil {
+
+	// Pindai file sebelum metadata disimpan, file terinfeksi langsung dikarantina
+	ScanStoredFile(&newFile)
+
+	if err := CreateFileWithQuota(baseDir, &newFile); err != nil {
+		// File baru yang tidak tercatat tidak boleh tertinggal di disk atau karantina
+		if !replaced {
+			os.Remove(filePath)
+		}
+		if newFile.QuarantinePath != "" {
+			os.Remove(newFile.QuarantinePath)
+		}
+		if errors.Is(err, ErrStorageQuotaExceeded) {
+			respondQuotaError(c, err)
+			return
+		}
+		log.Printf("Error menyimpan metadata file %s: %v", filePath, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal menyimpan metadata file"})
 		return
 	}
 
+	if newFile.ScanStatus == models.ScanInfected {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "File terinfeksi virus (" + newFile.ScanResult + ") dan telah dikarantina"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "File berhasil diunggah"})
 }
 
+// respondQuotaError membalas 413 untuk kuota terlampaui dan 500 jika kuota gagal dihitung
+func respondQuotaError(c *gin.Context, err error) {
+	if errors.Is(err, ErrStorageQuotaExceeded) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+}
+
 func GetFilesByID(c *gin.Context, baseDir string) {
 	id := c.Param("id")
 
@@ -266,6 +309,13 @@ func DownloadFileHandler(c *gin.Context, mainDir string) {
 		return
 	}
 
+	// Hanya file yang sudah dipindai dan bersih (atau file lama sebelum pemindaian) yang boleh diunduh
+	if !file.Downloadable() {
+		log.Printf("Download blocked for %s: scan status %s", fullPath, file.ScanStatus)
+		RespondError(c, http.StatusForbidden, "File belum dipindai atau terinfeksi virus")
+		return
+	}
+
 	// Periksa keberadaan file di sistem file
 	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
 		log.Printf("File not found in system: %s", fullPath)
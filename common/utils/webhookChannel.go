@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/models"
+)
+
+// webhookChannelTimeout membatasi lama menunggu respons webhook pengguna
+const webhookChannelTimeout = 10 * time.Second
+
+// webhookChannelClient hanya boleh terhubung ke alamat publik. Pemeriksaan di dialer berlaku juga untuk redirect
+// dan DNS yang berubah setelah URL disimpan. Proxy tidak dipakai karena akan melewati pemeriksaan ini.
+var webhookChannelClient = &http.Client{
+	Timeout: webhookChannelTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: webhookChannelTimeout,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !publicWebhookAddress(ip) {
+					return fmt.Errorf("alamat webhook %s tidak diizinkan", host)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: webhookChannelTimeout,
+	},
+}
+
+// publicWebhookAddress menolak alamat loopback, privat, link-local, multicast, dan unspecified
+// agar webhook pengguna tidak bisa dipakai untuk memanggil host internal
+func publicWebhookAddress(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// validateWebhookURL memeriksa URL webhook pengguna saat disimpan: harus http atau https dan semua alamat
+// hasil DNS harus publik
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("URL webhook harus http atau https")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookChannelTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("host webhook %s tidak ditemukan", u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !publicWebhookAddress(addr.IP) {
+			return fmt.Errorf("URL webhook tidak boleh mengarah ke alamat internal (%s)", addr.IP)
+		}
+	}
+	return nil
+}
+
+// WebhookChannel mengirim notifikasi sebagai JSON ke URL webhook pribadi penerima (NotificationSetting.WebhookURL),
+// misal incoming webhook chat. Penerima tanpa URL dilewati.
+type WebhookChannel struct{}
+
+func (WebhookChannel) Name() string { return "webhook" }
+
+// webhookNotification adalah body JSON yang dikirim WebhookChannel
+type webhookNotification struct {
+	Kind       string            `json:"kind"`
+	Category   string            `json:"category"`
+	Title      string            `json:"title"`
+	Text       string            `json:"text"` // Subject template, untuk webhook chat yang hanya membaca field text
+	Body       string            `json:"body"`
+	EventStart time.Time         `json:"event_start"`
+	SourceID   uint              `json:"source_id,omitempty"`
+	Recipient  string            `json:"recipient"`
+	Data       map[string]string `json:"data,omitempty"`
+}
+
+func (WebhookChannel) Send(job models.NotificationJob) error {
+	if job.Recipient == "" {
+		return fmt.Errorf("%w: tidak ada penerima", ErrNotificationSkipped)
+	}
+	username := notificationUsername(job.Recipient)
+	setting, err := loadNotificationSetting(username)
+	if err != nil {
+		return err
+	}
+	if setting.WebhookURL == "" {
+		return fmt.Errorf("%w: %s belum mengatur URL webhook", ErrNotificationSkipped, username)
+	}
+	message, err := RenderNotificationEmail(job, username)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotificationSkipped, err)
+	}
+	data := map[string]string{}
+	if job.Payload != "" {
+		if err := json.Unmarshal([]byte(job.Payload), &data); err != nil {
+			return fmt.Errorf("%w: %v", ErrNotificationSkipped, err)
+		}
+	}
+
+	body, err := json.Marshal(webhookNotification{
+		Kind:       job.Kind,
+		Category:   job.Category,
+		Title:      job.Title,
+		Text:       message.Subject,
+		Body:       message.Text,
+		EventStart: job.EventStart,
+		SourceID:   job.SourceID,
+		Recipient:  username,
+		Data:       data,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := webhookChannelClient.Post(setting.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s membalas %s", username, resp.Status)
+	}
+	return nil
+}
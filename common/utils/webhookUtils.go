@@ -0,0 +1,318 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	"github.com/arkaramadhan/its-vo/common/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	webhookBatch            = 50
+	webhookTimeout          = 15 * time.Second
+	webhookLockTimeout      = 5 * time.Minute // Delivery processing lebih lama dari ini dianggap worker-nya mati
+	webhookBaseBackoff      = 30 * time.Second
+	webhookMaxBackoff       = 6 * time.Hour
+	webhookMaxResponseBytes = 2048
+)
+
+// Header yang dikirim bersama setiap webhook
+const (
+	WebhookEventHeader     = "X-ITSVO-Event"
+	WebhookDeliveryHeader  = "X-ITSVO-Delivery"
+	WebhookTimestampHeader = "X-ITSVO-Timestamp"
+	WebhookSignatureHeader = "X-ITSVO-Signature" // sha256=<hex HMAC-SHA256(secret, timestamp + "." + body)>
+)
+
+var (
+	webhookClient = &http.Client{Timeout: webhookTimeout}
+
+	// webhookWake membangunkan worker di proses ini tanpa menunggu interval polling
+	webhookWake = make(chan struct{}, 1)
+)
+
+// WebhookPayload adalah body JSON yang dikirim ke penerima webhook
+type WebhookPayload struct {
+	ID         string          `json:"id"` // EventID, sama untuk setiap percobaan dan pengiriman ulang
+	Type       string          `json:"type"`
+	Service    string          `json:"service"`
+	Resource   string          `json:"resource"`
+	ResourceID string          `json:"resource_id,omitempty"`
+	Action     string          `json:"action"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// SignWebhookPayload menghitung signature HMAC-SHA256 body untuk header X-ITSVO-Signature. Penerima
+// menghitung ulang dengan secret yang sama dan menolak timestamp yang terlalu lama untuk mencegah replay.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookEventMatches memeriksa apakah eventType termasuk daftar EventTypes subscription. Pola yang didukung:
+// "*", "<resource>.*", dan nama event lengkap.
+func webhookEventMatches(eventTypes, eventType string) bool {
+	resource, _, _ := strings.Cut(eventType, ".")
+	for _, pattern := range strings.Split(eventTypes, ",") {
+		switch pattern = strings.TrimSpace(pattern); pattern {
+		case "*", eventType, resource + ".*":
+			return true
+		}
+	}
+	return false
+}
+
+func wakeWebhookWorker() {
+	select {
+	case webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// StartWebhookWorker menjalankan penyebar domain event dan pengirim webhook di background. Aman dijalankan
+// di semua service sekaligus karena event dan delivery diklaim dengan FOR UPDATE SKIP LOCKED. Interval
+// polling dari WEBHOOK_POLL_SECONDS (default 10).
+func StartWebhookWorker() {
+	interval := time.Duration(notificationEnvInt("WEBHOOK_POLL_SECONDS", 10)) * time.Second
+	hostname, _ := os.Hostname()
+	workerID := fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	log.Printf("Worker webhook %s berjalan setiap %s", workerID, interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			for {
+				dispatched, err := DispatchDomainEvents(webhookBatch)
+				if err != nil {
+					log.Printf("Worker webhook: %v", err)
+				}
+				if err != nil || dispatched < webhookBatch {
+					break
+				}
+			}
+			for {
+				processed, err := RunDueWebhookDeliveries(workerID, webhookBatch)
+				if err != nil {
+					log.Printf("Worker webhook: %v", err)
+				}
+				if err != nil || processed < webhookBatch {
+					break
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-webhookWake:
+			}
+		}
+	}()
+}
+
+// DispatchDomainEvents membuat WebhookDelivery untuk setiap domain event yang belum disebar dan setiap
+// subscription aktif yang cocok, mengembalikan jumlah event yang diproses
+func DispatchDomainEvents(limit int) (int, error) {
+	var events []models.DomainEvent
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Table("common.domain_events").
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("dispatched_at IS NULL").
+			Order("id").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		var subscriptions []models.WebhookSubscription
+		if err := tx.Table("common.webhook_subscriptions").Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		maxAttempts := notificationEnvInt("WEBHOOK_MAX_ATTEMPTS", 8)
+		var deliveries []models.WebhookDelivery
+		ids := make([]uint, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+			var payload []byte
+			for _, subscription := range subscriptions {
+				if !webhookEventMatches(subscription.EventTypes, event.Type) {
+					continue
+				}
+				if payload == nil {
+					if payload, err = json.Marshal(newWebhookPayload(event)); err != nil {
+						return err
+					}
+				}
+				deliveries = append(deliveries, models.WebhookDelivery{
+					SubscriptionID: subscription.ID,
+					EventID:        event.EventID,
+					EventType:      event.Type,
+					Payload:        string(payload),
+					Status:         models.WebhookDeliveryPending,
+					MaxAttempts:    maxAttempts,
+					NextAttemptAt:  now,
+				})
+			}
+		}
+		if len(deliveries) > 0 {
+			if err := tx.Table("common.webhook_deliveries").Create(&deliveries).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Table("common.domain_events").Where("id IN ?", ids).Update("dispatched_at", now).Error
+	})
+	return len(events), err
+}
+
+func newWebhookPayload(event models.DomainEvent) WebhookPayload {
+	payload := WebhookPayload{
+		ID:         event.EventID,
+		Type:       event.Type,
+		Service:    event.Service,
+		Resource:   event.Resource,
+		ResourceID: event.ResourceID,
+		Action:     event.Action,
+		OccurredAt: event.CreatedAt,
+	}
+	if event.Data != "" {
+		payload.Data = json.RawMessage(event.Data)
+	}
+	return payload
+}
+
+// RunDueWebhookDeliveries mengklaim dan mengirim delivery yang jatuh tempo, mengembalikan jumlah yang diproses
+func RunDueWebhookDeliveries(workerID string, limit int) (int, error) {
+	var deliveries []models.WebhookDelivery
+	now := time.Now()
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Table("common.webhook_deliveries").
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND locked_at < ?)",
+				models.WebhookDeliveryPending, now, models.WebhookDeliveryProcessing, now.Add(-webhookLockTimeout)).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+		ids := make([]uint, len(deliveries))
+		for i := range deliveries {
+			ids[i] = deliveries[i].ID
+		}
+		return tx.Table("common.webhook_deliveries").Where("id IN ?", ids).
+			Updates(map[string]interface{}{"status": models.WebhookDeliveryProcessing, "locked_by": workerID, "locked_at": now}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, delivery := range deliveries {
+		processWebhookDelivery(workerID, delivery)
+	}
+	return len(deliveries), nil
+}
+
+// webhookResult adalah hasil satu percobaan pengiriman
+type webhookResult struct {
+	status   int
+	body     string
+	duration time.Duration
+	err      error
+}
+
+// processWebhookDelivery mengirim delivery lalu mencatat hasilnya. Kegagalan dicoba ulang dengan jeda
+// bertambah dua kali lipat (30 detik, 1 menit, 2 menit, ... maks 6 jam) sampai MaxAttempts.
+func processWebhookDelivery(workerID string, delivery models.WebhookDelivery) {
+	var subscription models.WebhookSubscription
+	err := initializers.DB.Table("common.webhook_subscriptions").First(&subscription, delivery.SubscriptionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !subscription.Active) {
+		finishWebhookDelivery(workerID, delivery, webhookResult{err: errors.New("subscription dihapus atau nonaktif")}, true)
+		return
+	}
+	if err != nil {
+		finishWebhookDelivery(workerID, delivery, webhookResult{err: err}, false)
+		return
+	}
+	finishWebhookDelivery(workerID, delivery, sendWebhook(subscription, delivery), false)
+}
+
+func sendWebhook(subscription models.WebhookSubscription, delivery models.WebhookDelivery) webhookResult {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return webhookResult{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ITS-VO-Webhook/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(subscription.Secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := webhookClient.Do(req)
+	result := webhookResult{duration: time.Since(start)}
+	if err != nil {
+		result.err = err
+		return result
+	}
+	defer resp.Body.Close()
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxResponseBytes))
+	result.status, result.body = resp.StatusCode, string(response)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		result.err = fmt.Errorf("penerima membalas %s", resp.Status)
+	}
+	return result
+}
+
+// finishWebhookDelivery mencatat hasil percobaan. giveUp menandai delivery gagal tanpa dicoba ulang.
+func finishWebhookDelivery(workerID string, delivery models.WebhookDelivery, result webhookResult, giveUp bool) {
+	attempts := delivery.Attempts + 1
+	now := time.Now()
+	updates := map[string]interface{}{
+		"attempts":        attempts,
+		"response_status": result.status,
+		"response_body":   result.body,
+		"duration_ms":     result.duration.Milliseconds(),
+		"locked_by":       "",
+		"locked_at":       nil,
+	}
+	switch {
+	case result.err == nil:
+		updates["status"], updates["delivered_at"], updates["last_error"] = models.WebhookDeliveryDelivered, now, ""
+	case giveUp || attempts >= delivery.MaxAttempts:
+		updates["status"], updates["last_error"] = models.WebhookDeliveryFailed, result.err.Error()
+		log.Printf("Webhook delivery %d (%s) gagal setelah %d percobaan: %v", delivery.ID, delivery.EventType, attempts, result.err)
+	default:
+		backoff := webhookBaseBackoff << (attempts - 1)
+		if backoff > webhookMaxBackoff || backoff <= 0 {
+			backoff = webhookMaxBackoff
+		}
+		updates["status"], updates["next_attempt_at"], updates["last_error"] = models.WebhookDeliveryPending, now.Add(backoff), result.err.Error()
+	}
+	err := initializers.DB.Table("common.webhook_deliveries").
+		Where("id = ? AND status = ? AND locked_by = ?", delivery.ID, models.WebhookDeliveryProcessing, workerID).
+		Updates(updates).Error
+	if err != nil {
+		log.Printf("Gagal memperbarui webhook delivery %d: %v", delivery.ID, err)
+	}
+}
@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	"github.com/arkaramadhan/its-vo/common/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ZipData adalah interface yang harus diimplementasikan oleh model yang lampirannya bisa diunduh sebagai ZIP
+type ZipData interface {
+	GetID() uint
+	GetDocNumber() string // Dipakai sebagai nama folder di dalam ZIP
+}
+
+// ZipFilterConfig menentukan kolom yang dipakai untuk memfilter record saat unduh ZIP massal
+type ZipFilterConfig struct {
+	DateField   string // Kolom tanggal untuk filter start/end, mis. "tanggal"
+	NumberField string // Kolom nomor dokumen untuk filter kategori SAG/ISO
+}
+
+var manifestHeader = []string{"folder", "record_id", "no_dokumen", "file_name", "content_type", "size", "uploaded_at"}
+
+// ApplyZipFilter menerapkan filter dari query parameter (start, end, category) ke query record
+func ApplyZipFilter(c *gin.Context, query *gorm.DB, config ZipFilterConfig) (*gorm.DB, error) {
+	if start := c.Query("start"); start != "" && config.DateField != "" {
+		startDate, err := time.Parse("2006-01-02", start)
+		if err != nil {
+			return nil, fmt.Errorf("format start tidak valid: %v", err)
+		}
+		query = query.Where(fmt.Sprintf("%s >= ?", config.DateField), startDate)
+	}
+
+	if end := c.Query("end"); end != "" && config.DateField != "" {
+		endDate, err := time.Parse("2006-01-02", end)
+		if err != nil {
+			return nil, fmt.Errorf("format end tidak valid: %v", err)
+		}
+		// end bersifat inklusif, jadi bandingkan dengan awal hari berikutnya
+		query = query.Where(fmt.Sprintf("%s < ?", config.DateField), endDate.AddDate(0, 0, 1))
+	}
+
+	if category := strings.ToUpper(c.Query("category")); category != "" && config.NumberField != "" {
+		if category != "SAG" && category != "ISO" {
+			return nil, fmt.Errorf("kategori tidak valid")
+		}
+		query = query.Where(fmt.Sprintf("%s LIKE ?", config.NumberField), "%ITS-"+category+"%")
+	}
+
+	return query, nil
+}
+
+// GetFilesByRecord mengambil metadata lampiran milik satu record dari common.files
+func GetFilesByRecord(baseDir string, id uint) ([]models.File, error) {
+	var files []models.File
+	filePathPattern := fmt.Sprintf("%s/%d/%%", baseDir, id)
+	err := initializers.DB.Table("common.files").Where("file_path LIKE ?", filePathPattern).Order("id").Find(&files).Error
+	return files, err
+}
+
+// ZipFolderName membangun nama folder yang aman dari nomor dokumen
+func ZipFolderName(record ZipData) string {
+	name := strings.TrimSpace(record.GetDocNumber())
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '-'
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == ".." {
+		return strconv.FormatUint(uint64(record.GetID()), 10)
+	}
+	return name
+}
+
+// zipEntryName membangun nama entry ZIP dari nama file lampiran: hanya nama dasar tanpa direktori agar entry
+// tidak bisa keluar dari folder record, diberi akhiran angka jika namanya sudah dipakai di folder yang sama
+func zipEntryName(folder, fileName string, used map[string]bool) string {
+	base := filepath.Base(strings.ReplaceAll(fileName, "\\", "/"))
+	if base == "." || base == ".." || base == "/" {
+		base = "file"
+	}
+	name := folder + "/" + base
+	ext := filepath.Ext(base)
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s/%s_%d%s", folder, strings.TrimSuffix(base, ext), i, ext)
+	}
+	used[name] = true
+	return name
+}
+
+// WriteZip menulis seluruh lampiran record ke w sebagai ZIP beserta manifest.csv.
+// File dibaca satu per satu dari disk sehingga ZIP tidak pernah dibangun di memori.
+func WriteZip(w io.Writer, baseDir string, records []ZipData) error {
+	zw := zip.NewWriter(w)
+	manifest := [][]string{manifestHeader}
+	usedFolders := make(map[string]bool)
+	usedEntries := make(map[string]bool)
+
+	for _, record := range records {
+		files, err := GetFilesByRecord(baseDir, record.GetID())
+		if err != nil {
+			return fmt.Errorf("gagal mengambil data file: %v", err)
+		}
+		if len(files) == 0 {
+			continue
+		}
+
+		folder := ZipFolderName(record)
+		if usedFolders[folder] {
+			folder = fmt.Sprintf("%s_%d", folder, record.GetID())
+		}
+		usedFolders[folder] = true
+
+		for _, file := range files {
+			if !file.Downloadable() {
+				log.Printf("Melewati %s di ZIP: scan status %s", file.FilePath, file.ScanStatus)
+				continue
+			}
+			entry := zipEntryName(folder, file.FileName, usedEntries)
+			if err := addFileToZip(zw, entry, file); err != nil {
+				log.Printf("Error menambahkan %s ke ZIP: %v", file.FilePath, err)
+				continue
+			}
+			manifest = append(manifest, []string{
+				folder,
+				strconv.FormatUint(uint64(record.GetID()), 10),
+				record.GetDocNumber(),
+				strings.TrimPrefix(entry, folder+"/"),
+				file.ContentType,
+				strconv.FormatInt(file.Size, 10),
+				file.CreatedAt.Format(time.RFC3339),
+			})
+		}
+	}
+
+	manifestWriter, err := zw.Create("manifest.csv")
+	if err != nil {
+		return err
+	}
+	csvWriter := csv.NewWriter(manifestWriter)
+	if err := csvWriter.WriteAll(manifest); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func addFileToZip(zw *zip.Writer, name string, file models.File) error {
+	src, err := os.Open(file.FilePath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	header := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: file.CreatedAt,
+	}
+	dst, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// StreamZip mengirim ZIP lampiran langsung ke response tanpa buffer
+func StreamZip(c *gin.Context, baseDir string, records []ZipData, fileName string) {
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName + ".zip"}))
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+
+	if err := WriteZip(c.Writer, baseDir, records); err != nil {
+		// Header sudah terkirim, jadi error hanya bisa dicatat
+		log.Printf("Error streaming ZIP %s: %v", fileName, err)
+	}
+}
+
+// DownloadZipByID mengirim ZIP berisi seluruh lampiran dari satu record
+func DownloadZipByID(c *gin.Context, db *gorm.DB, schema, baseDir string, record ZipData, modelName string) {
+	id := c.Param("id")
+	if err := db.Table(schema).First(record, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": modelName + " tidak ditemukan"})
+		return
+	}
+
+	StreamZip(c, baseDir, []ZipData{record}, fmt.Sprintf("%s_%s", modelName, ZipFolderName(record)))
+}
+
+// DownloadZipByFilter mengirim ZIP berisi lampiran dari semua record yang cocok dengan filter query
+func DownloadZipByFilter[T any, PT interface {
+	*T
+	ZipData
+}](c *gin.Context, db *gorm.DB, schema, baseDir string, config ZipFilterConfig, modelName string) {
+	query, err := ApplyZipFilter(c, db.Table(schema), config)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var records []T
+	if err := query.Order("id").Find(&records).Error; err != nil {
+		RespondError(c, http.StatusInternalServerError, "Gagal mengambil data "+modelName+": "+err.Error())
+		return
+	}
+
+	zipData := make([]ZipData, 0, len(records))
+	for i := range records {
+		zipData = append(zipData, PT(&records[i]))
+	}
+
+	StreamZip(c, baseDir, zipData, fmt.Sprintf("%s_%s", modelName, time.Now().Format("20060102")))
+}
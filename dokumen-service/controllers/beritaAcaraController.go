@@ -39,6 +39,23 @@ func DownloadFileHandlerBeritaAcara(c *gin.Context) {
 	helper.DownloadFileHandler(c, "/app/UploadedFile/beritaacara")
 }
 
+func DownloadZipHandlerBeritaAcara(c *gin.Context) {
+	var record models.BeritaAcara
+	helper.DownloadZipByID(c, initializers.DB, "dokumen.berita_acaras", "/app/UploadedFile/beritaacara", &record, "beritaacara")
+}
+
+func DownloadZipFilterHandlerBeritaAcara(c *gin.Context) {
+	helper.DownloadZipByFilter[models.BeritaAcara](c, initializers.DB, "dokumen.berita_acaras", "/app/UploadedFile/beritaacara", helper.ZipFilterConfig{
+		DateField:   "tanggal",
+		NumberField: "no_surat",
+	}, "beritaacara")
+}
+
+func CreateShareLinkBeritaAcara(c *gin.Context) {
+	var record models.BeritaAcara
+	helper.CreateShareLink(c, initializers.DB, "dokumen.berita_acaras", "/app/UploadedFile/beritaacara", &record, "beritaacara")
+}
+
 func BeritaAcaraIndex(c *gin.Context) {
 	var beritaAcaras []models.BeritaAcara
 	helper.FetchAllRecords(initializers.DB, c, &beritaAcaras, "dokumen.berita_acaras", "Gagal mengambil data berita acara")
@@ -100,6 +117,11 @@ func BeritaAcaraCreate(c *gin.Context) {
 		return
 	}
 
+	helper.NotifyDocumentAssignment(helper.DocumentAssignment{
+		Type: "berita acara", ID: bc.ID, Number: bc.NoSurat, Perihal: bc.Perihal, Tanggal: bc.Tanggal, Pic: bc.Pic,
+		AssignedBy: requestBody.CreateBy,
+	})
+
 	c.JSON(http.StatusCreated, gin.H{"message": "berita acara berhasil dibuat"})
 }
 
@@ -135,6 +157,7 @@ func BeritaAcaraUpdate(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"message": "berita acara tidak ditemukan"})
 		return
 	}
+	previousPic := helper.GetValue(bc.Pic)
 
 	// Mengambil nomor surat terbaru
 	nomor, err := GetLatestBeritaAcaraNumber(*requestBody.NoSurat)
@@ -162,6 +185,11 @@ func BeritaAcaraUpdate(c *gin.Context) {
 		return
 	}
 
+	helper.NotifyDocumentAssignment(helper.DocumentAssignment{
+		Type: "berita acara", ID: bc.ID, Number: bc.NoSurat, Perihal: bc.Perihal, Tanggal: bc.Tanggal, Pic: bc.Pic,
+		PreviousPic: previousPic, AssignedBy: c.GetString("username"),
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": "berita acara berhasil diupdate"})
 }
 
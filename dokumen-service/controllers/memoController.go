@@ -41,6 +41,23 @@ func DownloadFileHandlerMemo(c *gin.Context) {
 	helper.DownloadFileHandler(c, "/app/UploadedFile/memo")
 }
 
+func DownloadZipHandlerMemo(c *gin.Context) {
+	var record models.Memo
+	helper.DownloadZipByID(c, initializers.DB, "dokumen.memos", "/app/UploadedFile/memo", &record, "memo")
+}
+
+func DownloadZipFilterHandlerMemo(c *gin.Context) {
+	helper.DownloadZipByFilter[models.Memo](c, initializers.DB, "dokumen.memos", "/app/UploadedFile/memo", helper.ZipFilterConfig{
+		DateField:   "tanggal",
+		NumberField: "no_memo",
+	}, "memo")
+}
+
+func CreateShareLinkMemo(c *gin.Context) {
+	var record models.Memo
+	helper.CreateShareLink(c, initializers.DB, "dokumen.memos", "/app/UploadedFile/memo", &record, "memo")
+}
+
 func GetLatestMemoNumber(category string) (string, error) {
 	var lastMemo models.Memo
 	if category != "ITS-SAG" && category != "ITS-ISO" {
@@ -111,6 +128,11 @@ func MemoCreate(c *gin.Context) {
 		return
 	}
 
+	helper.NotifyDocumentAssignment(helper.DocumentAssignment{
+		Type: "memo", ID: memosag.ID, Number: memosag.NoMemo, Perihal: memosag.Perihal, Tanggal: memosag.Tanggal, Pic: memosag.Pic,
+		AssignedBy: requestBody.CreateBy,
+	})
+
 	c.JSON(http.StatusCreated, gin.H{"message": "memo berhasil dibuat"})
 }
 
@@ -146,6 +168,7 @@ func MemoUpdate(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Memo not found"})
 		return
 	}
+	previousPic := helper.GetValue(memo.Pic)
 
 	// Mengambil nomor surat terbaru
 	nomor, err := GetLatestMemoNumber(*requestBody.NoMemo)
@@ -172,6 +195,11 @@ func MemoUpdate(c *gin.Context) {
 		return
 	}
 
+	helper.NotifyDocumentAssignment(helper.DocumentAssignment{
+		Type: "memo", ID: memo.ID, Number: memo.NoMemo, Perihal: memo.Perihal, Tanggal: memo.Tanggal, Pic: memo.Pic,
+		PreviousPic: previousPic, AssignedBy: c.GetString("username"),
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Memo updated successfully"})
 }
 
@@ -39,6 +39,23 @@ func DownloadFileHandlerPerdin(c *gin.Context) {
 	helper.DownloadFileHandler(c, "/app/UploadedFile/perdin")
 }
 
+func DownloadZipHandlerPerdin(c *gin.Context) {
+	var record models.Perdin
+	helper.DownloadZipByID(c, initializers.DB, "dokumen.perdins", "/app/UploadedFile/perdin", &record, "perdin")
+}
+
+func DownloadZipFilterHandlerPerdin(c *gin.Context) {
+	helper.DownloadZipByFilter[models.Perdin](c, initializers.DB, "dokumen.perdins", "/app/UploadedFile/perdin", helper.ZipFilterConfig{
+		DateField:   "tanggal",
+		NumberField: "no_perdin",
+	}, "perdin")
+}
+
+func CreateShareLinkPerdin(c *gin.Context) {
+	var record models.Perdin
+	helper.CreateShareLink(c, initializers.DB, "dokumen.perdins", "/app/UploadedFile/perdin", &record, "perdin")
+}
+
 func GetLatestPerdinNumber(category string) (string, error) {
 	var lastPerdin models.Perdin
 	if !strings.HasPrefix(category, "PD-ITS") {
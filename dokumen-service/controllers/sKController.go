@@ -39,6 +39,23 @@ func DownloadFileHandlerSk(c *gin.Context) {
 	helper.DownloadFileHandler(c, "/app/UploadedFile/sk")
 }
 
+func DownloadZipHandlerSk(c *gin.Context) {
+	var record models.Sk
+	helper.DownloadZipByID(c, initializers.DB, "dokumen.sks", "/app/UploadedFile/sk", &record, "sk")
+}
+
+func DownloadZipFilterHandlerSk(c *gin.Context) {
+	helper.DownloadZipByFilter[models.Sk](c, initializers.DB, "dokumen.sks", "/app/UploadedFile/sk", helper.ZipFilterConfig{
+		DateField:   "tanggal",
+		NumberField: "no_surat",
+	}, "sk")
+}
+
+func CreateShareLinkSk(c *gin.Context) {
+	var record models.Sk
+	helper.CreateShareLink(c, initializers.DB, "dokumen.sks", "/app/UploadedFile/sk", &record, "sk")
+}
+
 func GetLatestSkNumber(category string) (string, error) {
 	var lastSk models.Sk
 	if category != "ITS-SAG" && category != "ITS-ISO" {
@@ -97,6 +114,11 @@ func SkCreate(c *gin.Context) {
 		return
 	}
 
+	helper.NotifyDocumentAssignment(helper.DocumentAssignment{
+		Type: "SK", ID: sK.ID, Number: sK.NoSurat, Perihal: sK.Perihal, Tanggal: sK.Tanggal, Pic: sK.Pic,
+		AssignedBy: requestBody.CreateBy,
+	})
+
 	c.JSON(http.StatusCreated, gin.H{"message": "surat berhasil dibuat"})
 }
 
@@ -132,6 +154,7 @@ func SkUpdate(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"message": "sk tidak ditemukan"})
 		return
 	}
+	previousPic := helper.GetValue(sk.Pic)
 
 	// Mengambil nomor surat terbaru
 	nomor, err := GetLatestSkNumber(*requestBody.NoSurat)
@@ -159,6 +182,11 @@ func SkUpdate(c *gin.Context) {
 		return
 	}
 
+	helper.NotifyDocumentAssignment(helper.DocumentAssignment{
+		Type: "SK", ID: sk.ID, Number: sk.NoSurat, Perihal: sk.Perihal, Tanggal: sk.Tanggal, Pic: sk.Pic,
+		PreviousPic: previousPic, AssignedBy: c.GetString("username"),
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": "surat berhasil diupdate"})
 }
 
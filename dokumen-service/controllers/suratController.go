@@ -39,6 +39,23 @@ func DownloadFileHandlerSurat(c *gin.Context) {
 	helper.DownloadFileHandler(c, "/app/UploadedFile/surat")
 }
 
+func DownloadZipHandlerSurat(c *gin.Context) {
+	var record models.Surat
+	helper.DownloadZipByID(c, initializers.DB, "dokumen.surats", "/app/UploadedFile/surat", &record, "surat")
+}
+
+func DownloadZipFilterHandlerSurat(c *gin.Context) {
+	helper.DownloadZipByFilter[models.Surat](c, initializers.DB, "dokumen.surats", "/app/UploadedFile/surat", helper.ZipFilterConfig{
+		DateField:   "tanggal",
+		NumberField: "no_surat",
+	}, "surat")
+}
+
+func CreateShareLinkSurat(c *gin.Context) {
+	var record models.Surat
+	helper.CreateShareLink(c, initializers.DB, "dokumen.surats", "/app/UploadedFile/surat", &record, "surat")
+}
+
 func GetLatestSuratNumber(category string) (string, error) {
 	var lastSurat models.Surat
 	if category != "ITS-SAG" && category != "ITS-ISO" {
@@ -102,6 +119,11 @@ func SuratCreate(c *gin.Context) {
 	}
 	log.Printf("Surat created successfully: %v", surat)
 
+	helper.NotifyDocumentAssignment(helper.DocumentAssignment{
+		Type: "surat", ID: surat.ID, Number: surat.NoSurat, Perihal: surat.Perihal, Tanggal: surat.Tanggal, Pic: surat.Pic,
+		AssignedBy: requestBody.CreateBy,
+	})
+
 	c.JSON(http.StatusCreated, gin.H{"message": "surat berhasil dibuat"})
 }
 
@@ -137,6 +159,7 @@ func SuratUpdate(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"message": "surat tidak ditemukan"})
 		return
 	}
+	previousPic := helper.GetValue(surat.Pic)
 
 	// Mengambil nomor surat terbaru
 	nomor, err := GetLatestSuratNumber(*requestBody.NoSurat)
@@ -164,6 +187,11 @@ func SuratUpdate(c *gin.Context) {
 		return
 	}
 
+	helper.NotifyDocumentAssignment(helper.DocumentAssignment{
+		Type: "surat", ID: surat.ID, Number: surat.NoSurat, Perihal: surat.Perihal, Tanggal: surat.Tanggal, Pic: surat.Pic,
+		PreviousPic: previousPic, AssignedBy: c.GetString("username"),
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Surat updated successfully"})
 }
 
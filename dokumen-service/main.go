@@ -7,6 +7,7 @@ import (
 	"github.com/arkaramadhan/its-vo/common/initializers"
 	exportAll "github.com/arkaramadhan/its-vo/common/exportAll"
 	"github.com/arkaramadhan/its-vo/common/middleware"
+	"github.com/arkaramadhan/its-vo/common/utils"
 	"github.com/arkaramadhan/its-vo/dokumen-service/controllers"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
@@ -34,6 +35,15 @@ func main() {
 
 	r.Use(middleware.CORS())
 
+	utils.RegisterDomainEvents(initializers.DB, "dokumen")
+	utils.StartWebhookWorker()
+
+	utils.StartNotificationWorker()
+
+	// ********** Public Share Link ********** //
+	r.GET("/share/:token", utils.DownloadShareLink)
+	r.POST("/share/:token", utils.DownloadShareLink)
+
 	// ********** Middleware ********** //
 	r.Use(middleware.TokenAuthMiddleware())
 	store := cookie.NewStore([]byte("secret"))
@@ -52,6 +62,9 @@ func main() {
 	r.GET("/downloadMemo/:id/:filename", controllers.DownloadFileHandlerMemo)
 	r.DELETE("/deleteMemo/:id/:filename", controllers.DeleteFileHandlerMemo)
 	r.GET("/filesMemo/:id", controllers.GetFilesByIDMemo)
+	r.GET("/zipMemo", controllers.DownloadZipFilterHandlerMemo)
+	r.GET("/zipMemo/:id", controllers.DownloadZipHandlerMemo)
+	r.POST("/shareMemo/:id", middleware.RequireRole("admin"), controllers.CreateShareLinkMemo)
 
 	// ********** Routes for Berita Acara ********** //
 	r.GET("/beritaAcara", controllers.BeritaAcaraIndex)
@@ -66,6 +79,9 @@ func main() {
 	r.GET("/downloadBeritaAcara/:id/:filename", controllers.DownloadFileHandlerBeritaAcara)
 	r.DELETE("/deleteBeritaAcara/:id/:filename", controllers.DeleteFileHandlerBeritaAcara)
 	r.GET("/filesBeritaAcara/:id", controllers.GetFilesByIDBeritaAcara)
+	r.GET("/zipBeritaAcara", controllers.DownloadZipFilterHandlerBeritaAcara)
+	r.GET("/zipBeritaAcara/:id", controllers.DownloadZipHandlerBeritaAcara)
+	r.POST("/shareBeritaAcara/:id", middleware.RequireRole("admin"), controllers.CreateShareLinkBeritaAcara)
 
 	// ********** Routes for Surat ********** //
 	r.GET("/surat", controllers.SuratIndex)
@@ -80,6 +96,9 @@ func main() {
 	r.GET("/downloadSurat/:id/:filename", controllers.DownloadFileHandlerSurat)
 	r.DELETE("/deleteSurat/:id/:filename", controllers.DeleteFileHandlerSurat)
 	r.GET("/filesSurat/:id", controllers.GetFilesByIDSurat)
+	r.GET("/zipSurat", controllers.DownloadZipFilterHandlerSurat)
+	r.GET("/zipSurat/:id", controllers.DownloadZipHandlerSurat)
+	r.POST("/shareSurat/:id", middleware.RequireRole("admin"), controllers.CreateShareLinkSurat)
 
 	// ********** Routes for SK ********** //
 	r.GET("/sk", controllers.SkIndex)
@@ -94,6 +113,9 @@ func main() {
 	r.GET("/downloadSk/:id/:filename", controllers.DownloadFileHandlerSk)
 	r.DELETE("/deleteSk/:id/:filename", controllers.DeleteFileHandlerSk)
 	r.GET("/filesSk/:id", controllers.GetFilesByIDSk)
+	r.GET("/zipSk", controllers.DownloadZipFilterHandlerSk)
+	r.GET("/zipSk/:id", controllers.DownloadZipHandlerSk)
+	r.POST("/shareSk/:id", middleware.RequireRole("admin"), controllers.CreateShareLinkSk)
 
 	// ********** Routes for Perdin ********** //
 	r.POST("/Perdin", controllers.PerdinCreate)
@@ -108,6 +130,53 @@ func main() {
 	r.GET("/downloadPerdin/:id/:filename", controllers.DownloadFileHandlerPerdin)
 	r.DELETE("/deletePerdin/:id/:filename", controllers.DeleteFileHandlerPerdin)
 	r.GET("/filesPerdin/:id", controllers.GetFilesByIDPerdin)
+	r.GET("/zipPerdin", controllers.DownloadZipFilterHandlerPerdin)
+	r.GET("/zipPerdin/:id", controllers.DownloadZipHandlerPerdin)
+	r.POST("/sharePerdin/:id", middleware.RequireRole("admin"), controllers.CreateShareLinkPerdin)
+
+	// ********** Route Share Link ********** //
+	r.GET("/shareLinks", middleware.RequireRole("admin"), utils.ShareLinkIndex)
+	r.DELETE("/shareLinks/:id", middleware.RequireRole("admin"), utils.RevokeShareLink)
+	r.GET("/shareLinks/:id/access", middleware.RequireRole("admin"), utils.GetShareLinkAccessLog)
+
+	// ********** Route Notification ********** //
+	r.GET("/notification-jobs", middleware.RequireRole("admin"), utils.NotificationJobIndex)
+	r.POST("/notification-jobs/:id/retry", middleware.RequireRole("admin"), utils.RetryNotificationJob)
+	r.POST("/notification-jobs/test-email", middleware.RequireRole("admin"), utils.SendTestEmail)
+
+	// ********** Route Storage ********** //
+	r.GET("/storage/reconcile", middleware.RequireRole("admin"), utils.ReconcileStorageHandler)
+	r.POST("/storage/reconcile", middleware.RequireRole("admin"), utils.ReconcileStorageHandler)
+	r.GET("/storage/usage", middleware.RequireRole("admin"), utils.StorageUsageHandler)
+	r.GET("/storage/usage/export", middleware.RequireRole("admin"), utils.ExportStorageUsageHandler)
+	r.GET("/storage/quotas", middleware.RequireRole("admin"), utils.StorageQuotaIndex)
+	r.PUT("/storage/quotas/:resource", middleware.RequireRole("admin"), utils.UpsertStorageQuota)
+	r.DELETE("/storage/quotas/:resource", middleware.RequireRole("admin"), utils.DeleteStorageQuota)
+
+	// ********** Route Quarantine ********** //
+	r.GET("/quarantine", middleware.RequireRole("admin"), utils.QuarantineIndex)
+	r.POST("/quarantine/scan", middleware.RequireRole("admin"), utils.ScanPendingFiles)
+	r.POST("/quarantine/:id/rescan", middleware.RequireRole("admin"), utils.RescanFile)
+	r.POST("/quarantine/:id/release", middleware.RequireRole("admin"), utils.ReleaseQuarantinedFile)
+	r.DELETE("/quarantine/:id", middleware.RequireRole("admin"), utils.DeleteQuarantinedFile)
+
+	// ********** Route Hari Libur ********** //
+	r.GET("/holidays", utils.GetHolidays)
+	r.POST("/holidays", middleware.RequireRole("admin"), utils.CreateHoliday)
+	r.PUT("/holidays/:id", middleware.RequireRole("admin"), utils.UpdateHoliday)
+	r.DELETE("/holidays/:id", middleware.RequireRole("admin"), utils.DeleteHoliday)
+	r.POST("/holidays/import", middleware.RequireRole("admin"), utils.ImportHolidays)
+	r.GET("/working-days", utils.WorkingDaysHandler)
+	r.GET("/working-days/add", utils.AddWorkingDaysHandler)
+
+	// ********** Route Webhook ********** //
+	r.GET("/webhooks", middleware.RequireRole("admin"), utils.WebhookIndex)
+	r.POST("/webhooks", middleware.RequireRole("admin"), utils.CreateWebhook)
+	r.PUT("/webhooks/:id", middleware.RequireRole("admin"), utils.UpdateWebhook)
+	r.DELETE("/webhooks/:id", middleware.RequireRole("admin"), utils.DeleteWebhook)
+	r.POST("/webhooks/:id/ping", middleware.RequireRole("admin"), utils.PingWebhook)
+	r.GET("/webhooks/:id/deliveries", middleware.RequireRole("admin"), utils.WebhookDeliveryIndex)
+	r.POST("/webhook-deliveries/:id/redeliver", middleware.RequireRole("admin"), utils.RedeliverWebhook)
 
 	r.GET("/exportAll", exportAll.ExportAll)
 
@@ -67,6 +67,14 @@ func (ba *Memo) GetDocType() string {
 	return "ISO"
 }
 
+func (ba *Memo) GetID() uint {
+	return ba.ID
+}
+
+func (ba *Memo) GetDocNumber() string {
+	return helper.GetValue(ba.NoMemo)
+}
+
 func (m *Memo) SetProperty(key string, value interface{}) error {
 	switch key {
 	case "Tanggal":
@@ -155,6 +163,14 @@ func (ba *BeritaAcara) GetDocType() string {
 	return "ISO"
 }
 
+func (ba *BeritaAcara) GetID() uint {
+	return ba.ID
+}
+
+func (ba *BeritaAcara) GetDocNumber() string {
+	return helper.GetValue(ba.NoSurat)
+}
+
 func (ba *BeritaAcara) SetProperty(key string, value interface{}) error {
 	switch key {
 	case "Tanggal":
@@ -243,6 +259,14 @@ func (s *Surat) GetDocType() string {
 	return "ISO"
 }
 
+func (s *Surat) GetID() uint {
+	return s.ID
+}
+
+func (s *Surat) GetDocNumber() string {
+	return helper.GetValue(s.NoSurat)
+}
+
 func (s *Surat) SetProperty(key string, value interface{}) error {
 	switch key {
 	case "Tanggal":
@@ -331,6 +355,14 @@ func (ba *Sk) GetDocType() string {
 	return "ISO"
 }
 
+func (ba *Sk) GetID() uint {
+	return ba.ID
+}
+
+func (ba *Sk) GetDocNumber() string {
+	return helper.GetValue(ba.NoSurat)
+}
+
 func (s *Sk) SetProperty(key string, value interface{}) error {
 	switch key {
 	case "Tanggal":
@@ -418,6 +450,14 @@ func (p *Perdin) GetDocType() string {
 	return "ISO"
 }
 
+func (p *Perdin) GetID() uint {
+	return p.ID
+}
+
+func (p *Perdin) GetDocNumber() string {
+	return helper.GetValue(p.NoPerdin)
+}
+
 func (p *Perdin) SetProperty(key string, value interface{}) error {
 	switch key {
 	case "Tanggal":
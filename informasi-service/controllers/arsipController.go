@@ -40,6 +40,23 @@ func DownloadFileHandlerArsip(c *gin.Context) {
 	helper.DownloadFileHandler(c, "/app/UploadedFile/arsip")
 }
 
+func DownloadZipHandlerArsip(c *gin.Context) {
+	var record models.Arsip
+	helper.DownloadZipByID(c, initializers.DB, "informasi.arsips", "/app/UploadedFile/arsip", &record, "arsip")
+}
+
+func DownloadZipFilterHandlerArsip(c *gin.Context) {
+	helper.DownloadZipByFilter[models.Arsip](c, initializers.DB, "informasi.arsips", "/app/UploadedFile/arsip", helper.ZipFilterConfig{
+		DateField:   "tanggal_dokumen",
+		NumberField: "no_arsip",
+	}, "arsip")
+}
+
+func CreateShareLinkArsip(c *gin.Context) {
+	var record models.Arsip
+	helper.CreateShareLink(c, initializers.DB, "informasi.arsips", "/app/UploadedFile/arsip", &record, "arsip")
+}
+
 func ArsipIndex(c *gin.Context) {
 	var arsips []models.Arsip
 	helper.FetchAllRecords(initializers.DB, c, &arsips, "informasi.arsips", "Gagal mengambil data arsip")
@@ -38,6 +38,23 @@ func DownloadFileHandlerSuratKeluar(c *gin.Context) {
 	helper.DownloadFileHandler(c, "/app/UploadedFile/suratkeluar")
 }
 
+func DownloadZipHandlerSuratKeluar(c *gin.Context) {
+	var record models.SuratKeluar
+	helper.DownloadZipByID(c, initializers.DB, "informasi.surat_keluars", "/app/UploadedFile/suratkeluar", &record, "suratkeluar")
+}
+
+func DownloadZipFilterHandlerSuratKeluar(c *gin.Context) {
+	helper.DownloadZipByFilter[models.SuratKeluar](c, initializers.DB, "informasi.surat_keluars", "/app/UploadedFile/suratkeluar", helper.ZipFilterConfig{
+		DateField:   "tanggal",
+		NumberField: "no_surat",
+	}, "suratkeluar")
+}
+
+func CreateShareLinkSuratKeluar(c *gin.Context) {
+	var record models.SuratKeluar
+	helper.CreateShareLink(c, initializers.DB, "informasi.surat_keluars", "/app/UploadedFile/suratkeluar", &record, "suratkeluar")
+}
+
 func SuratKeluarCreate(c *gin.Context) {
 	// Get data off req body
 	var requestBody SuratKeluarRequest
@@ -38,6 +38,23 @@ func DownloadFileHandlerSuratMasuk(c *gin.Context) {
 	helper.DownloadFileHandler(c, "/app/UploadedFile/suratmasuk")
 }
 
+func DownloadZipHandlerSuratMasuk(c *gin.Context) {
+	var record models.SuratMasuk
+	helper.DownloadZipByID(c, initializers.DB, "informasi.surat_masuks", "/app/UploadedFile/suratmasuk", &record, "suratmasuk")
+}
+
+func DownloadZipFilterHandlerSuratMasuk(c *gin.Context) {
+	helper.DownloadZipByFilter[models.SuratMasuk](c, initializers.DB, "informasi.surat_masuks", "/app/UploadedFile/suratmasuk", helper.ZipFilterConfig{
+		DateField:   "tanggal",
+		NumberField: "no_surat",
+	}, "suratmasuk")
+}
+
+func CreateShareLinkSuratMasuk(c *gin.Context) {
+	var record models.SuratMasuk
+	helper.CreateShareLink(c, initializers.DB, "informasi.surat_masuks", "/app/UploadedFile/suratmasuk", &record, "suratmasuk")
+}
+
 func SuratMasukCreate(c *gin.Context) {
 	// Get data off req body
 	var requestBody SuratMasukRequest
@@ -6,6 +6,7 @@ import (
 
 	"github.com/arkaramadhan/its-vo/common/initializers"
 	"github.com/arkaramadhan/its-vo/common/middleware"
+	"github.com/arkaramadhan/its-vo/common/utils"
 	exportAll "github.com/arkaramadhan/its-vo/common/exportAll"
 	"github.com/arkaramadhan/its-vo/informasi-service/controllers"
 	"github.com/gin-contrib/sessions"
@@ -35,6 +36,13 @@ func main() {
 
 	r.Use(middleware.CORS())
 
+	utils.RegisterDomainEvents(initializers.DB, "informasi")
+	utils.StartWebhookWorker()
+
+	// ********** Public Share Link ********** //
+	r.GET("/share/:token", utils.DownloadShareLink)
+	r.POST("/share/:token", utils.DownloadShareLink)
+
 	// ********** Middleware ********** //
 	r.Use(middleware.TokenAuthMiddleware())
 	store := cookie.NewStore([]byte("secret"))
@@ -53,6 +61,9 @@ func main() {
 	r.GET("/downloadSuratMasuk/:id/:filename", controllers.DownloadFileHandlerSuratMasuk)
 	r.DELETE("/deleteSuratMasuk/:id/:filename", controllers.DeleteFileHandlerSuratMasuk)
 	r.GET("/filesSuratMasuk/:id", controllers.GetFilesByIDSuratMasuk)
+	r.GET("/zipSuratMasuk", controllers.DownloadZipFilterHandlerSuratMasuk)
+	r.GET("/zipSuratMasuk/:id", controllers.DownloadZipHandlerSuratMasuk)
+	r.POST("/shareSuratMasuk/:id", middleware.RequireRole("admin"), controllers.CreateShareLinkSuratMasuk)
 
 	// *********** Route Surat Masuk *********** //
 	r.POST("/SuratKeluar", controllers.SuratKeluarCreate)
@@ -67,6 +78,9 @@ func main() {
 	r.GET("/downloadSuratKeluar/:id/:filename", controllers.DownloadFileHandlerSuratKeluar)
 	r.DELETE("/deleteSuratKeluar/:id/:filename", controllers.DeleteFileHandlerSuratKeluar)
 	r.GET("/filesSuratKeluar/:id", controllers.GetFilesByIDSuratKeluar)
+	r.GET("/zipSuratKeluar", controllers.DownloadZipFilterHandlerSuratKeluar)
+	r.GET("/zipSuratKeluar/:id", controllers.DownloadZipHandlerSuratKeluar)
+	r.POST("/shareSuratKeluar/:id", middleware.RequireRole("admin"), controllers.CreateShareLinkSuratKeluar)
 
 	// *********** Route Arsip *********** //
 	r.GET("/Arsip", controllers.ArsipIndex)
@@ -79,9 +93,51 @@ func main() {
 
 	r.POST("/uploadFileArsip", controllers.UploadHandlerArsip)
 	r.GET("/filesArsip/:id", controllers.GetFilesByIDArsip)
+	r.GET("/zipArsip", controllers.DownloadZipFilterHandlerArsip)
+	r.GET("/zipArsip/:id", controllers.DownloadZipHandlerArsip)
+	r.POST("/shareArsip/:id", middleware.RequireRole("admin"), controllers.CreateShareLinkArsip)
 	r.GET("/downloadArsip/:id/:filename", controllers.DownloadFileHandlerArsip)
 	r.DELETE("/deleteArsip/:id/:filename", controllers.DeleteFileHandlerArsip)
 
+	// ********** Route Share Link ********** //
+	r.GET("/shareLinks", middleware.RequireRole("admin"), utils.ShareLinkIndex)
+	r.DELETE("/shareLinks/:id", middleware.RequireRole("admin"), utils.RevokeShareLink)
+	r.GET("/shareLinks/:id/access", middleware.RequireRole("admin"), utils.GetShareLinkAccessLog)
+
+	// ********** Route Storage ********** //
+	r.GET("/storage/reconcile", middleware.RequireRole("admin"), utils.ReconcileStorageHandler)
+	r.POST("/storage/reconcile", middleware.RequireRole("admin"), utils.ReconcileStorageHandler)
+	r.GET("/storage/usage", middleware.RequireRole("admin"), utils.StorageUsageHandler)
+	r.GET("/storage/usage/export", middleware.RequireRole("admin"), utils.ExportStorageUsageHandler)
+	r.GET("/storage/quotas", middleware.RequireRole("admin"), utils.StorageQuotaIndex)
+	r.PUT("/storage/quotas/:resource", middleware.RequireRole("admin"), utils.UpsertStorageQuota)
+	r.DELETE("/storage/quotas/:resource", middleware.RequireRole("admin"), utils.DeleteStorageQuota)
+
+	// ********** Route Quarantine ********** //
+	r.GET("/quarantine", middleware.RequireRole("admin"), utils.QuarantineIndex)
+	r.POST("/quarantine/scan", middleware.RequireRole("admin"), utils.ScanPendingFiles)
+	r.POST("/quarantine/:id/rescan", middleware.RequireRole("admin"), utils.RescanFile)
+	r.POST("/quarantine/:id/release", middleware.RequireRole("admin"), utils.ReleaseQuarantinedFile)
+	r.DELETE("/quarantine/:id", middleware.RequireRole("admin"), utils.DeleteQuarantinedFile)
+
+	// ********** Route Hari Libur ********** //
+	r.GET("/holidays", utils.GetHolidays)
+	r.POST("/holidays", middleware.RequireRole("admin"), utils.CreateHoliday)
+	r.PUT("/holidays/:id", middleware.RequireRole("admin"), utils.UpdateHoliday)
+	r.DELETE("/holidays/:id", middleware.RequireRole("admin"), utils.DeleteHoliday)
+	r.POST("/holidays/import", middleware.RequireRole("admin"), utils.ImportHolidays)
+	r.GET("/working-days", utils.WorkingDaysHandler)
+	r.GET("/working-days/add", utils.AddWorkingDaysHandler)
+
+	// ********** Route Webhook ********** //
+	r.GET("/webhooks", middleware.RequireRole("admin"), utils.WebhookIndex)
+	r.POST("/webhooks", middleware.RequireRole("admin"), utils.CreateWebhook)
+	r.PUT("/webhooks/:id", middleware.RequireRole("admin"), utils.UpdateWebhook)
+	r.DELETE("/webhooks/:id", middleware.RequireRole("admin"), utils.DeleteWebhook)
+	r.POST("/webhooks/:id/ping", middleware.RequireRole("admin"), utils.PingWebhook)
+	r.GET("/webhooks/:id/deliveries", middleware.RequireRole("admin"), utils.WebhookDeliveryIndex)
+	r.POST("/webhook-deliveries/:id/redeliver", middleware.RequireRole("admin"), utils.RedeliverWebhook)
+
 	r.GET("/exportAll", exportAll.ExportAll)
 
 	r.Run(":8082")
@@ -68,6 +68,14 @@ func (ba *SuratMasuk) GetDocType() string {
 	return "ISO"
 }
 
+func (ba *SuratMasuk) GetID() uint {
+	return ba.ID
+}
+
+func (ba *SuratMasuk) GetDocNumber() string {
+	return helper.GetValue(ba.NoSurat)
+}
+
 // model for suratKeluar
 type SuratKeluar struct {
 	ID        uint       `gorm:"primaryKey"`
@@ -128,6 +136,14 @@ func (ba *SuratKeluar) GetDocType() string {
 	return "ISO"
 }
 
+func (ba *SuratKeluar) GetID() uint {
+	return ba.ID
+}
+
+func (ba *SuratKeluar) GetDocNumber() string {
+	return helper.GetValue(ba.NoSurat)
+}
+
 type Arsip struct {
 	gorm.Model
 	NoArsip           *string    `json:"no_arsip"`
@@ -200,3 +216,11 @@ func (ba *Arsip) GetDocType() string {
 	}
 	return "ISO"
 }
+
+func (ba *Arsip) GetID() uint {
+	return ba.ID
+}
+
+func (ba *Arsip) GetDocNumber() string {
+	return helper.GetValue(ba.NoArsip)
+}
@@ -1,15 +1,17 @@
 package controllers
 
 import (
+	"errors"
 	"log"
 	"net/http"
-	"time"
+	"strconv"
 
 	"github.com/arkaramadhan/its-vo/common/initializers"
 	helper "github.com/arkaramadhan/its-vo/common/utils"
 	"github.com/arkaramadhan/its-vo/kegiatan-service/models"
 	"github.com/gin-gonic/gin"
 	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
 )
 
 // Create a new event
@@ -19,6 +21,17 @@ func GetEventsRapat(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+
+	// Event berulang dipecah menjadi kejadian di dalam rentang start/end
+	period, explicit, err := helper.ParseOccurrenceRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	events = helper.ExpandRecurring(events, period.Start, period.End)
+	if explicit {
+		events = helper.FilterEventsByPeriod(events, period)
+	}
 	c.JSON(http.StatusOK, events)
 }
 
@@ -29,35 +42,74 @@ func CreateEventRapat(c *gin.Context) {
 		return
 	}
 
-	// Set notification menggunakan fungsi dari notificationController
-	loc, err := time.LoadLocation("Asia/Jakarta")
-	if err != nil {
-		log.Printf("Error loading location: %v", err)
+	if event.Start.IsZero() || event.End.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "start dan end harus diisi"})
+		return
+	}
+	if err := event.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
+	event.ID, event.CreateBy = 0, c.GetString("username")
 
-	var startTime time.Time
-	if event.AllDay {
-		// Jika AllDay = true, set waktu ke tengah malam
-		startTime, err = time.ParseInLocation("2006-01-02T15:04:05", event.Start+"T00:00:00", loc)
-	} else {
-		// Jika tidak, parse dengan format RFC3339
-		startTime, err = time.ParseInLocation(time.RFC3339, event.Start, loc)
+	if err := initializers.DB.Create(&event).Error; err != nil {
+		log.Printf("Error creating event: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
 	}
+	helper.SetNotification(event.Title, event.GetStart(), "JadwalRapat", event.ID)
+	helper.PublishCalendarChange("JadwalRapat", "created", event.ID)
+	c.JSON(http.StatusOK, event)
+}
 
+// UpdateEventRapat mengubah event. Untuk event berulang, query scope menentukan cakupan (this, following, all)
+// dan occurrence_start di body menunjuk kejadian yang diedit.
+func UpdateEventRapat(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		log.Printf("Error parsing start time: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+	scope, err := helper.ParseRecurrenceScope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
-	helper.SetNotification(event.Title, startTime, "JadwalRapat") // Panggil fungsi SetNotification
+	var event models.JadwalRapat
+	if err := c.ShouldBindJSON(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	occurrenceStart := event.OccurrenceStart
+	if err := event.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
 
-	if err := initializers.DB.Create(&event).Error; err != nil {
-		log.Printf("Error creating event: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	var events []models.JadwalRapat
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.JadwalRapat
+		if err := tx.Table("kegiatan.jadwal_rapats").First(&current, id).Error; err != nil {
+			return err
+		}
+		if err := checkOwner(c, current.CreateBy); err != nil {
+			return err
+		}
+		event.CreateBy = current.CreateBy
+		ids, err := helper.UpdateRecurringEvent[models.JadwalRapat](tx, "kegiatan.jadwal_rapats", uint(id), &event, scope, occurrenceStart)
+		if err != nil {
+			return err
+		}
+		return tx.Table("kegiatan.jadwal_rapats").Where("id IN ?", ids).Find(&events).Error
+	})
+	if err != nil {
+		respondJadwalRapatError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, event)
+	helper.SyncNotifications("JadwalRapat", eventIDs(events)...)
+	helper.PublishCalendarChange("JadwalRapat", "updated", eventIDs(events)...)
+	c.JSON(http.StatusOK, gin.H{"message": "Jadwal rapat berhasil diperbarui", "events": events})
 }
 
 func DeleteEventRapat(c *gin.Context) {
@@ -66,13 +118,45 @@ func DeleteEventRapat(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "ID harus disertakan"})
 		return
 	}
-	if err := initializers.DB.Where("id = ?", id).Delete(&models.JadwalRapat{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
 		return
 	}
+
+	// Event berulang: scope this, following, atau all dengan occurrence_start kejadian yang dihapus
+	scope, err := helper.ParseRecurrenceScope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.JadwalRapat
+		if err := tx.Table("kegiatan.jadwal_rapats").First(&current, eventID).Error; err != nil {
+			return err
+		}
+		if err := checkOwner(c, current.CreateBy); err != nil {
+			return err
+		}
+		return helper.DeleteRecurringEvent[models.JadwalRapat, *models.JadwalRapat](tx, "kegiatan.jadwal_rapats", uint(eventID), scope, c.Query("occurrence_start"))
+	})
+	if err != nil {
+		respondJadwalRapatError(c, err)
+		return
+	}
+	helper.SyncNotifications("JadwalRapat", uint(eventID))
+	helper.PublishCalendarChange("JadwalRapat", "deleted", uint(eventID))
 	c.Status(http.StatusNoContent)
 }
 
+func respondJadwalRapatError(c *gin.Context, err error) {
+	if errors.Is(err, errNotOwner) {
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+		return
+	}
+	helper.RespondRecurrenceError(c, err)
+}
+
 func ExportJadwalRapatHandler(c *gin.Context) {
 	var f *excelize.File
 	ExportJadwalRapatToExcel(c, f, "JADWAL RAPAT", true)
@@ -85,11 +169,26 @@ func ExportJadwalRapatToExcel(c *gin.Context, f *excelize.File, sheetName string
 		return err
 	}
 
+	// Periode export dari query year, start_month/end_month, atau start/end
+	period, err := helper.ParseCalendarPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return err
+	}
+
+	// Event berulang dipecah menjadi kejadian di dalam periode
 	var excelEvents []helper.ExcelEvent
-	for _, event := range events {
+	for _, event := range helper.ExpandRecurring(events, period.Start, period.End) {
 		excelEvents = append(excelEvents, event) // Pastikan `event` adalah tipe yang mengimplementasikan `ExcelEvent`
 	}
 
+	// Layout export: month (default), week, atau agenda
+	layout, err := helper.ParseCalendarLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return err
+	}
+
 	config := helper.CalenderConfig{
 		SheetName:   "JADWAL RAPAT",
 		FileName:    "jadwal_rapat.xlsx",
@@ -97,6 +196,9 @@ func ExportJadwalRapatToExcel(c *gin.Context, f *excelize.File, sheetName string
 		UseResource: false,
 		RowOffset:   0,
 		ColOffset:   0,
+		Period:      period,
+		ShowLegend:  c.Query("legend") == "true",
+		Layout:      layout,
 	}
 
 	if f != nil {
@@ -110,3 +212,57 @@ func ExportJadwalRapatToExcel(c *gin.Context, f *excelize.File, sheetName string
 	}
 	return nil
 }
+
+func ExportJadwalRapatICS(c *gin.Context) {
+	var events []models.JadwalRapat
+	if err := initializers.DB.Table("kegiatan.jadwal_rapats").Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	var icsEvents []helper.ICSEvent
+	for _, event := range events {
+		icsEvents = append(icsEvents, event)
+	}
+	helper.ServeICS(c, "jadwal_rapat", "Jadwal Rapat", icsEvents)
+}
+
+// jadwalRapatImport mengimport event .ics ke jadwal rapat, bentrok hanya ditandai di preview
+var jadwalRapatImport = helper.ICSImportTarget{
+	Name: "jadwalRapat",
+	Exists: func(uid string) (bool, error) {
+		var count int64
+		err := initializers.DB.Table("kegiatan.jadwal_rapats").Where("import_uid = ?", uid).Count(&count).Error
+		return count > 0, err
+	},
+	Conflicts: func(event helper.ICSImportEvent) (bool, error) {
+		var count int64
+		err := initializers.DB.Table("kegiatan.jadwal_rapats").
+			Where("start < ? AND \"end\" > ?", event.End, event.Start).Count(&count).Error
+		return count > 0, err
+	},
+	Save: func(c *gin.Context, event helper.ICSImportEvent) (string, error) {
+		rapat := models.JadwalRapat{
+			Title:     event.Title,
+			Start:     helper.NewEventTime(event.Start, event.AllDay),
+			End:       helper.NewEventTime(event.End, event.AllDay),
+			AllDay:    event.AllDay,
+			ImportUID: event.UID,
+			CreateBy:  c.GetString("username"),
+		}
+		if err := initializers.DB.Create(&rapat).Error; err != nil {
+			return "", err
+		}
+		helper.SetNotification(rapat.Title, event.Start, "JadwalRapat", rapat.ID)
+		helper.PublishCalendarChange("JadwalRapat", "created", rapat.ID)
+		return "", nil
+	},
+}
+
+func PreviewICSJadwalRapat(c *gin.Context) {
+	helper.PreviewICSImport(c, jadwalRapatImport)
+}
+
+func ConfirmICSJadwalRapat(c *gin.Context) {
+	helper.ConfirmICSImport(c, jadwalRapatImport)
+}
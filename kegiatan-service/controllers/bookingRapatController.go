@@ -1,8 +1,12 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/arkaramadhan/its-vo/common/initializers"
@@ -10,16 +14,33 @@ import (
 	"github.com/arkaramadhan/its-vo/kegiatan-service/models"
 	"github.com/gin-gonic/gin"
 	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
 )
 
 // Create a new event
 func GetEventsBookingRapat(c *gin.Context) {
 	var events []models.BookingRapat
-	// Tambahkan filter untuk tidak menampilkan event dengan status "pending"
-	if err := initializers.DB.Where("status != ?", "pending").Find(&events).Error; err != nil {
+	// Hanya booking yang sudah disetujui yang tampil di kalender
+	if err := bookingRapatQuery(c).Where("status = ?", models.BookingApproved).Find(&events).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+	events, err := withRoomNames(initializers.DB, events)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	// Event berulang dipecah menjadi kejadian di dalam rentang start/end
+	period, explicit, err := helper.ParseOccurrenceRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	events = helper.ExpandRecurring(events, period.Start, period.End)
+	if explicit {
+		events = helper.FilterEventsByPeriod(events, period)
+	}
 	c.JSON(http.StatusOK, events)
 }
 
@@ -31,43 +52,161 @@ func CreateEventBookingRapat(c *gin.Context) {
 		return
 	}
 
-	// Simpan event ke database terlebih dahulu
-	if err := initializers.DB.Create(&event).Error; err != nil {
-		log.Printf("Error creating event: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	if event.Start.IsZero() || event.End.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "start dan end harus diisi"})
+		return
+	}
+	event.ID, event.CreateBy = 0, c.GetString("username")
+	if !event.End.After(event.Start.Time) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "end harus setelah start"})
+		return
+	}
+	if err := event.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
 	// Log untuk memeriksa data yang diterima
 	log.Printf("Event Start: %s, Event End: %s", event.Start, event.End)
 
-	// Set notification menggunakan fungsi dari notificationController
-	loc, err := time.LoadLocation("Asia/Jakarta")
+	// Cek bentrok dan simpan dalam satu transaksi agar tidak balapan dengan booking lain
+	var conflicts []bookingSummary
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockBookingRapat(tx); err != nil {
+			return err
+		}
+		var err error
+		conflicts, err = saveBookingRapat(tx, &event, newBookingRapatHistory(c, "create", ""))
+		return err
+	})
 	if err != nil {
-		log.Printf("Error loading location: %v", err)
+		log.Printf("Error creating event: %v", err)
+		respondBookingRapatError(c, err)
 		return
 	}
 
-	var startTime time.Time
-	if event.AllDay {
-		startTime, err = time.ParseInLocation("2006-01-02T15:04:05", event.Start+"T00:00:00", loc)
-	} else {
-		startTime, err = time.ParseInLocation(time.RFC3339, event.Start, loc)
-	}
+	// Panggil fungsi SetNotification setelah event berhasil disimpan
+	helper.SetNotification(event.Title, event.GetStart(), "BookingRapat", event.ID)
+	helper.PublishCalendarChange("BookingRapat", "created", event.ID)
 
+	c.JSON(http.StatusOK, newBookingRapatResponse(event, conflicts))
+}
+
+// GetMyBookingRapat menampilkan semua booking milik pengguna yang login, termasuk yang pending dan
+// sudah berakhir statusnya. Query status memfilter per status.
+func GetMyBookingRapat(c *gin.Context) {
+	query := bookingRapatQuery(c).Where("create_by = ?", c.GetString("username"))
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var events []models.BookingRapat
+	if err := query.Order("start DESC").Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	events, err := withRoomNames(initializers.DB, events)
 	if err != nil {
-		log.Printf("Error parsing start time: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+	if events == nil {
+		events = []models.BookingRapat{}
+	}
+	c.JSON(http.StatusOK, events)
+}
 
-	// Panggil fungsi SetNotification setelah event berhasil disimpan
-	helper.SetNotification(event.Title, startTime, "BookingRapat")
+// bookingRapatQuery memfilter booking dengan query room_id jika diisi
+func bookingRapatQuery(c *gin.Context) *gorm.DB {
+	query := initializers.DB.Table("kegiatan.booking_rapats")
+	if roomID := c.Query("room_id"); roomID != "" {
+		query = query.Where("room_id = ?", roomID)
+	}
+	return query
+}
 
-	// Cek bentrok, kecualikan event yang sedang dibuat
-	var conflictingEvents []models.BookingRapat
-	if err := initializers.DB.Where("id != ? AND start < ? AND \"end\" > ?", event.ID, event.End, event.Start).Find(&conflictingEvents).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-		return
+// errBookingRapatOverlap dikembalikan jika exclusion constraint menolak booking, ditampilkan sebagai 409
+var errBookingRapatOverlap = errors.New("booking bentrok dengan booking lain yang sudah disetujui")
+
+// bookingSummary meringkas booking yang bentrok atau mengisi slot ruangan.
+// Untuk booking berulang Start/End berisi kejadiannya.
+type bookingSummary struct {
+	ID                uint             `json:"id"`
+	Title             string           `json:"title"`
+	Start             helper.EventTime `json:"start"`
+	End               helper.EventTime `json:"end"`
+	Status            string           `json:"status"`
+	RoomID            *uint            `json:"room_id"`
+	RoomName          string           `json:"room_name,omitempty"`
+	ConflictRequestID uint             `json:"conflict_request_id,omitempty"`
+}
+
+func newBookingSummary(event models.BookingRapat) bookingSummary {
+	return bookingSummary{
+		ID:       event.ID,
+		Title:    event.Title,
+		Start:    event.Start,
+		End:      event.End,
+		Status:   event.Status,
+		RoomID:   event.RoomID,
+		RoomName: event.RoomName,
+	}
+}
+
+// bookingRapatResponse mempertahankan field booking di level atas agar frontend lama tetap bisa membacanya
+type bookingRapatResponse struct {
+	models.BookingRapat
+	Message   string           `json:"message,omitempty"`
+	Conflicts []bookingSummary `json:"conflicts"`
+}
+
+func newBookingRapatResponse(event models.BookingRapat, conflicts []bookingSummary) bookingRapatResponse {
+	message := "Booking rapat berhasil disimpan"
+	if len(conflicts) > 0 {
+		message = fmt.Sprintf("Booking rapat disimpan dengan status pending karena bentrok dengan %d booking lain", len(conflicts))
+	}
+	if conflicts == nil {
+		conflicts = []bookingSummary{}
+	}
+	return bookingRapatResponse{BookingRapat: event, Message: message, Conflicts: conflicts}
+}
+
+// lockBookingRapat menyerialkan penulisan booking sampai transaksi selesai, sehingga cek bentrok dan
+// simpan tidak bisa disela booking lain. Exclusion constraint di database menjadi pengaman terakhir.
+func lockBookingRapat(tx *gorm.DB) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "kegiatan.booking_rapats").Error
+}
+
+// conflictsBookingRapat mengembalikan booking lain di ruangan yang sama yang bentrok dengan salah satu kejadian
+// event. Booking tanpa ruangan hanya dicek terhadap booking lain tanpa ruangan. Booking berulang dicek per
+// kejadian, event yang sedang dicek dan override-nya dikecualikan.
+func conflictsBookingRapat(tx *gorm.DB, event models.BookingRapat) ([]models.BookingRapat, error) {
+	from, to := helper.ConflictWindow(event, event.Recurrence)
+
+	// Saring kasar di database, pengecekan per kejadian dilakukan oleh FindConflicts
+	var others []models.BookingRapat
+	if err := tx.Table("kegiatan.booking_rapats").
+		Where("id != ?", event.ID).
+		Where("parent_id IS NULL OR parent_id != ?", event.ID).
+		Where("room_id IS NOT DISTINCT FROM ?", event.RoomID).
+		Where("status IN ?", models.BookingActiveStatuses).
+		Where("rrule <> '' OR (start < ? AND \"end\" > ?)", to, from).
+		Find(&others).Error; err != nil {
+		return nil, err
+	}
+	return helper.FindConflicts(event, others, from, to)
+}
+
+// saveBookingRapat menentukan status booking dari hasil cek bentrok, menyimpannya (insert jika ID kosong,
+// selain itu hanya status), mencatat riwayat dengan entry dan mencatat ConflictRequest untuk setiap
+// booking yang bentrok. Booking tanpa bentrok langsung disetujui oleh system.
+// Dipanggil di dalam transaksi setelah lockBookingRapat.
+func saveBookingRapat(tx *gorm.DB, event *models.BookingRapat, entry models.BookingRapatHistory) ([]bookingSummary, error) {
+	if err := validateBookingRoom(tx, event); err != nil {
+		return nil, err
+	}
+	conflictingEvents, err := conflictsBookingRapat(tx, *event)
+	if err != nil {
+		return nil, err
 	}
 
 	// Log untuk memeriksa hasil query
@@ -76,21 +215,168 @@ func CreateEventBookingRapat(c *gin.Context) {
 		log.Printf("Bentrok dengan %s: Start: %s, End: %s", conflict.Title, conflict.Start, conflict.End)
 	}
 
-	// Atur status berdasarkan bentrok
 	if len(conflictingEvents) > 0 {
-		event.Status = "pending"
+		event.Status, event.DecidedBy, event.DecidedAt = models.BookingPending, "", nil
+		event.StatusComment = fmt.Sprintf("Menunggu persetujuan, bentrok dengan %d booking lain", len(conflictingEvents))
+	} else {
+		now := time.Now()
+		event.Status, event.DecidedBy, event.DecidedAt = models.BookingApproved, bookingSystemActor, &now
+		event.StatusComment = "Disetujui otomatis, tidak ada bentrok"
+	}
+	if event.ID == 0 {
+		err = tx.Table("kegiatan.booking_rapats").Create(event).Error
 	} else {
-		event.Status = "acc"
+		err = tx.Table("kegiatan.booking_rapats").Where("id = ?", event.ID).Updates(map[string]interface{}{
+			"status":         event.Status,
+			"decided_by":     event.DecidedBy,
+			"decided_at":     event.DecidedAt,
+			"status_comment": event.StatusComment,
+		}).Error
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), models.BookingRapatOverlapConstraint) {
+			return nil, errBookingRapatOverlap
+		}
+		return nil, err
 	}
 
-	// Update status event di database
-	if err := initializers.DB.Save(&event).Error; err != nil {
-		log.Printf("Error updating event status: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	entry.BookingID, entry.ToStatus, entry.Comment = event.ID, event.Status, event.StatusComment
+	if err := recordBookingRapatHistory(tx, entry); err != nil {
+		return nil, err
+	}
+
+	// Permintaan bentrok lama milik booking ini diganti dengan hasil cek terbaru
+	if err := tx.Table("kegiatan.conflict_requests").Where("new_event_id = ? AND status = ?", event.ID, models.BookingPending).
+		Delete(&models.ConflictRequest{}).Error; err != nil {
+		return nil, err
+	}
+	var conflicts []bookingSummary
+	for _, conflict := range conflictingEvents {
+		request := models.ConflictRequest{
+			NewEventID: event.ID,
+			OldEventID: conflict.ID,
+			Status:     models.BookingPending,
+			OldTitle:   conflict.Title,
+			NewTitle:   event.Title,
+			StartTime:  conflict.Start.String(),
+			EndTime:    conflict.End.String(),
+			Date:       conflict.GetStart(),
+		}
+		if err := tx.Table("kegiatan.conflict_requests").Create(&request).Error; err != nil {
+			return nil, err
+		}
+		summary := newBookingSummary(conflict)
+		summary.RoomName, summary.ConflictRequestID = event.RoomName, request.ID
+		conflicts = append(conflicts, summary)
+	}
+	return conflicts, nil
+}
+
+// newBookingRapatHistory menyiapkan entry riwayat untuk saveBookingRapat dengan actor dari token
+func newBookingRapatHistory(c *gin.Context, action, fromStatus string) models.BookingRapatHistory {
+	actor := bookingActorFromContext(c)
+	return models.BookingRapatHistory{Action: action, FromStatus: fromStatus, Actor: actor.Name, ActorID: actor.ID}
+}
+
+// cleanupConflictRequests menghapus permintaan bentrok yang merujuk booking yang sudah dihapus
+func cleanupConflictRequests(tx *gorm.DB) error {
+	return tx.Table("kegiatan.conflict_requests").
+		Where("new_event_id NOT IN (SELECT id FROM kegiatan.booking_rapats) OR old_event_id NOT IN (SELECT id FROM kegiatan.booking_rapats)").
+		Delete(&models.ConflictRequest{}).Error
+}
+
+func respondBookingRapatError(c *gin.Context, err error) {
+	if errors.Is(err, errBookingRapatOverlap) {
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
 		return
 	}
+	if errors.Is(err, errNotOwner) {
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+		return
+	}
+	if errors.Is(err, errBookingTransition) {
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	}
+	if errors.Is(err, errRoomNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	helper.RespondRecurrenceError(c, err)
+}
 
-	c.JSON(http.StatusOK, event)
+// UpdateEventBookingRapat mengubah booking. Untuk booking berulang, query scope menentukan cakupan
+// (this, following, all) dan occurrence_start di body menunjuk kejadian yang diedit.
+// Status semua record yang berubah dihitung ulang dengan logika bentrok yang sama seperti saat dibuat.
+func UpdateEventBookingRapat(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+	scope, err := helper.ParseRecurrenceScope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	var event models.BookingRapat
+	if err := c.ShouldBindJSON(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	occurrenceStart := event.OccurrenceStart
+	if err := event.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	// Status dari client diabaikan, dihitung ulang dari hasil cek bentrok setelah perubahan disimpan
+	event.Status = models.BookingPending
+
+	var events []bookingRapatResponse
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockBookingRapat(tx); err != nil {
+			return err
+		}
+		var current models.BookingRapat
+		if err := tx.Table("kegiatan.booking_rapats").First(&current, id).Error; err != nil {
+			return err
+		}
+		if err := checkOwner(c, current.CreateBy); err != nil {
+			return err
+		}
+		if !containsStatus(models.BookingActiveStatuses, current.Status) {
+			return fmt.Errorf("%w: booking berstatus %s tidak bisa diubah", errBookingTransition, current.Status)
+		}
+		event.CreateBy = current.CreateBy
+		ids, err := helper.UpdateRecurringEvent[models.BookingRapat](tx, "kegiatan.booking_rapats", uint(id), &event, scope, occurrenceStart)
+		if err != nil {
+			return err
+		}
+		var updated []models.BookingRapat
+		if err := tx.Table("kegiatan.booking_rapats").Where("id IN ?", ids).Find(&updated).Error; err != nil {
+			return err
+		}
+		for i := range updated {
+			conflicts, err := saveBookingRapat(tx, &updated[i], newBookingRapatHistory(c, "update", current.Status))
+			if err != nil {
+				return err
+			}
+			events = append(events, newBookingRapatResponse(updated[i], conflicts))
+		}
+		return cleanupConflictRequests(tx)
+	})
+	if err != nil {
+		respondBookingRapatError(c, err)
+		return
+	}
+
+	for _, event := range events {
+		helper.SyncNotifications("BookingRapat", event.ID)
+	}
+	helper.PublishCalendarChange("BookingRapat", "updated", eventIDs(events)...)
+	c.JSON(http.StatusOK, gin.H{"message": "Booking rapat berhasil diperbarui", "events": events})
 }
 
 func DeleteEventBookingRapat(c *gin.Context) {
@@ -99,10 +385,37 @@ func DeleteEventBookingRapat(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "ID harus disertakan"})
 		return
 	}
-	if err := initializers.DB.Where("id = ?", id).Delete(&models.BookingRapat{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+
+	// Booking berulang: scope this, following, atau all dengan occurrence_start kejadian yang dihapus
+	scope, err := helper.ParseRecurrenceScope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.BookingRapat
+		if err := tx.Table("kegiatan.booking_rapats").First(&current, eventID).Error; err != nil {
+			return err
+		}
+		if err := checkOwner(c, current.CreateBy); err != nil {
+			return err
+		}
+		if err := helper.DeleteRecurringEvent[models.BookingRapat, *models.BookingRapat](tx, "kegiatan.booking_rapats", uint(eventID), scope, c.Query("occurrence_start")); err != nil {
+			return err
+		}
+		return cleanupConflictRequests(tx)
+	})
+	if err != nil {
+		respondBookingRapatError(c, err)
 		return
 	}
+	helper.SyncNotifications("BookingRapat", uint(eventID))
+	helper.PublishCalendarChange("BookingRapat", "deleted", uint(eventID))
 	c.Status(http.StatusNoContent)
 }
 
@@ -113,23 +426,47 @@ func ExportBookingRapatHandler(c *gin.Context) {
 
 func ExportBookingRapatToExcel(c *gin.Context, f *excelize.File, sheetName string, isStandAlone bool) error {
 	var events []models.BookingRapat
-	if err := initializers.DB.Table("kegiatan.booking_rapats").Where("status = ?", "acc").Find(&events).Error; err != nil {
+	if err := bookingRapatQuery(c).Where("status = ?", models.BookingApproved).Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return err
+	}
+	rooms, err := roomNames(initializers.DB)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return err
 	}
 
+	// Periode export dari query year, start_month/end_month, atau start/end
+	period, err := helper.ParseCalendarPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return err
+	}
+
+	// Event berulang dipecah menjadi kejadian di dalam periode
 	var excelEvents []helper.ExcelEvent
-	for _, event := range events {
+	for _, event := range helper.ExpandRecurring(events, period.Start, period.End) {
 		excelEvents = append(excelEvents, event) // Pastikan `event` adalah tipe yang mengimplementasikan `ExcelEvent`
 	}
 
+	// Layout export: month (default), week, atau agenda
+	layout, err := helper.ParseCalendarLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return err
+	}
+
 	config := helper.CalenderConfig{
 		SheetName:   "BOOKING RAPAT",
 		FileName:    "bookingRapat.xlsx",
 		Events:      excelEvents,
-		UseResource: false,
+		UseResource: c.Query("group") == "room", // Nama ruangan di setiap event dan legend per ruangan
+		ResourceMap: rooms,
 		RowOffset:   0,
 		ColOffset:   0,
+		Period:      period,
+		ShowLegend:  c.Query("legend") == "true",
+		Layout:      layout,
 	}
 
 	if f != nil {
@@ -143,3 +480,93 @@ func ExportBookingRapatToExcel(c *gin.Context, f *excelize.File, sheetName strin
 	}
 	return nil
 }
+
+// ExportBookingRapatICS mengirim event sebagai iCalendar, dipakai untuk unduhan dan feed langganan
+func ExportBookingRapatICS(c *gin.Context) {
+	var events []models.BookingRapat
+	if err := bookingRapatQuery(c).Where("status = ?", models.BookingApproved).Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	events, err := withRoomNames(initializers.DB, events)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	var icsEvents []helper.ICSEvent
+	for _, event := range events {
+		icsEvents = append(icsEvents, event)
+	}
+	helper.ServeICS(c, "booking_rapat", "Booking Rapat", icsEvents)
+}
+
+// bookingRapatImport mengimport event .ics sebagai booking, status mengikuti logika bentrok CreateEventBookingRapat
+var bookingRapatImport = helper.ICSImportTarget{
+	Name: "bookingRapat",
+	Exists: func(uid string) (bool, error) {
+		var count int64
+		err := initializers.DB.Table("kegiatan.booking_rapats").Where("import_uid = ?", uid).Count(&count).Error
+		return count > 0, err
+	},
+	Conflicts: func(event helper.ICSImportEvent) (bool, error) {
+		booking, err := importedBookingRapat(initializers.DB, event)
+		if err != nil {
+			return false, err
+		}
+		conflicts, err := conflictsBookingRapat(initializers.DB, booking)
+		return len(conflicts) > 0, err
+	},
+	Save: func(c *gin.Context, event helper.ICSImportEvent) (string, error) {
+		var booking models.BookingRapat
+		err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+			if err := lockBookingRapat(tx); err != nil {
+				return err
+			}
+			var err error
+			if booking, err = importedBookingRapat(tx, event); err != nil {
+				return err
+			}
+			booking.CreateBy = c.GetString("username")
+			_, err = saveBookingRapat(tx, &booking, newBookingRapatHistory(c, "import", ""))
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+		helper.SetNotification(booking.Title, event.Start, "BookingRapat", booking.ID)
+		helper.PublishCalendarChange("BookingRapat", "created", booking.ID)
+		return booking.Status, nil
+	},
+}
+
+// importedBookingRapat menyusun booking dari event .ics. LOCATION dicocokkan dengan nama ruangan tanpa
+// membedakan huruf besar/kecil, lokasi kosong atau yang bukan nama ruangan menjadi booking tanpa ruangan.
+func importedBookingRapat(tx *gorm.DB, event helper.ICSImportEvent) (models.BookingRapat, error) {
+	booking := models.BookingRapat{
+		Title:     event.Title,
+		Start:     helper.NewEventTime(event.Start, event.AllDay),
+		End:       helper.NewEventTime(event.End, event.AllDay),
+		AllDay:    event.AllDay,
+		ImportUID: event.UID,
+	}
+	if location := strings.TrimSpace(event.Location); location != "" {
+		var room models.Room
+		err := tx.Table("kegiatan.rooms").Where("LOWER(name) = LOWER(?)", location).First(&room).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return booking, err
+		}
+		if err == nil {
+			booking.RoomID = &room.ID
+		}
+	}
+	return booking, validateBookingRoom(tx, &booking)
+}
+
+func PreviewICSBookingRapat(c *gin.Context) {
+	helper.PreviewICSImport(c, bookingRapatImport)
+}
+
+func ConfirmICSBookingRapat(c *gin.Context) {
+	helper.ConfirmICSImport(c, bookingRapatImport)
+}
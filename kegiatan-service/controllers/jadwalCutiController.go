@@ -3,7 +3,7 @@ package controllers
 import (
 	"log"
 	"net/http"
-	"time"
+	"strconv"
 
 	"github.com/arkaramadhan/its-vo/common/initializers"
 	helper "github.com/arkaramadhan/its-vo/common/utils"
@@ -30,33 +30,18 @@ func CreateEventCuti(c *gin.Context) {
 		return
 	}
 
-	// Set notification menggunakan fungsi dari notificationController
-	loc, err := time.LoadLocation("Asia/Jakarta")
-	if err != nil {
-		log.Printf("Error loading location: %v", err)
-		return
-	}
-
-	var startTime time.Time
-	if event.AllDay {
-		// Jika AllDay = true, set waktu ke tengah malam
-		startTime, err = time.ParseInLocation("2006-01-02T15:04:05", event.Start+"T00:00:00", loc)
-	} else {
-		// Jika tidak, parse dengan format RFC3339
-		startTime, err = time.ParseInLocation(time.RFC3339, event.Start, loc)
-	}
-
-	if err != nil {
-		log.Printf("Error parsing start time: %v", err)
+	if event.Start.IsZero() || event.End.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "start dan end harus diisi"})
 		return
 	}
 
-	helper.SetNotification(event.Title, startTime, "JadwalCuti") // Panggil fungsi SetNotification
 	if err := initializers.DB.Create(&event).Error; err != nil {
 		log.Printf("Error creating event: %v", err) // Add this line
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+	helper.SetNotification(event.Title, event.GetStart(), "JadwalCuti", event.ID)
+	helper.PublishCalendarChange("JadwalCuti", "created", event.ID)
 	c.JSON(http.StatusOK, event)
 }
 
@@ -66,10 +51,24 @@ func DeleteEventCuti(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "ID harus disertakan"})
 		return
 	}
+	// Entri dari pengajuan cuti dihapus dengan membatalkan pengajuannya agar saldo ikut kembali
+	var linked int64
+	if err := initializers.DB.Table("kegiatan.jadwal_cutis").Where("id = ? AND leave_request_id IS NOT NULL", id).Count(&linked).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	if linked > 0 {
+		c.JSON(http.StatusConflict, gin.H{"message": "Jadwal ini berasal dari pengajuan cuti, batalkan lewat pengajuan cuti"})
+		return
+	}
 	if err := initializers.DB.Where("id = ?", id).Delete(&models.JadwalCuti{}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+	if eventID, err := strconv.ParseUint(id, 10, 32); err == nil {
+		helper.SyncNotifications("JadwalCuti", uint(eventID))
+		helper.PublishCalendarChange("JadwalCuti", "deleted", uint(eventID))
+	}
 	c.Status(http.StatusNoContent)
 }
 
@@ -90,6 +89,13 @@ func ExportJadwalCutiToExcel(c *gin.Context, f *excelize.File, sheetName string,
 		excelEvents = append(excelEvents, event) // Pastikan `event` adalah tipe yang mengimplementasikan `ExcelEvent`
 	}
 
+	// Periode export dari query year, start_month/end_month, atau start/end
+	period, err := helper.ParseCalendarPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return err
+	}
+
 	config := helper.CalenderConfig{
 		SheetName:   "JADWAL CUTI",
 		FileName:    "jadwal_cuti.xlsx",
@@ -97,6 +103,8 @@ func ExportJadwalCutiToExcel(c *gin.Context, f *excelize.File, sheetName string,
 		UseResource: false,
 		RowOffset:   0,
 		ColOffset:   0,
+		Period:      period,
+		ShowLegend:  c.Query("legend") == "true",
 	}
 
 	if f != nil {
@@ -110,3 +118,17 @@ func ExportJadwalCutiToExcel(c *gin.Context, f *excelize.File, sheetName string,
 	}
 	return nil
 }
+
+func ExportJadwalCutiICS(c *gin.Context) {
+	var events []models.JadwalCuti
+	if err := initializers.DB.Table("kegiatan.jadwal_cutis").Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	var icsEvents []helper.ICSEvent
+	for _, event := range events {
+		icsEvents = append(icsEvents, event)
+	}
+	helper.ServeICS(c, "jadwal_cuti", "Jadwal Cuti", icsEvents)
+}
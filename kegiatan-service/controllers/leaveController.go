@@ -0,0 +1,746 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	helper "github.com/arkaramadhan/its-vo/common/utils"
+	"github.com/arkaramadhan/its-vo/kegiatan-service/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Default saldo cuti, bisa diubah lewat LEAVE_ANNUAL_DAYS dan LEAVE_MAX_CARRY_OVER
+const (
+	defaultLeaveAnnualDays   = 12
+	defaultLeaveMaxCarryOver = 6
+)
+
+var (
+	// errLeaveTransition dikembalikan untuk aksi yang tidak berlaku pada status pengajuan saat ini, ditampilkan sebagai 409
+	errLeaveTransition = errors.New("status pengajuan cuti tidak bisa diubah")
+	// errLeaveForbidden dikembalikan jika pengguna tidak berhak memutuskan atau membatalkan pengajuan, ditampilkan sebagai 403
+	errLeaveForbidden = errors.New("anda tidak berhak mengubah pengajuan cuti ini")
+	// errLeaveOverlap dikembalikan jika tanggal cuti beririsan dengan pengajuan lain milik pengguna yang sama, ditampilkan sebagai 409
+	errLeaveOverlap = errors.New("tanggal cuti beririsan dengan pengajuan lain")
+	// errLeaveInvalid dikembalikan untuk masukan pengajuan yang tidak valid, ditampilkan sebagai 400
+	errLeaveInvalid = errors.New("pengajuan cuti tidak valid")
+)
+
+type leaveTransition struct {
+	From          []string
+	To            string
+	NeedComment   bool
+	SuccessPrefix string
+}
+
+var leaveTransitions = map[string]leaveTransition{
+	"approve": {From: []string{models.LeavePending}, To: models.LeaveApproved, SuccessPrefix: "Pengajuan cuti disetujui"},
+	"reject":  {From: []string{models.LeavePending}, To: models.LeaveRejected, NeedComment: true, SuccessPrefix: "Pengajuan cuti ditolak"},
+	"cancel":  {From: models.LeaveActiveStatuses, To: models.LeaveCancelled, SuccessPrefix: "Pengajuan cuti dibatalkan"},
+}
+
+type LeaveRequestInput struct {
+	Type      string `json:"type"`
+	StartDate string `json:"start_date"` // YYYY-MM-DD
+	EndDate   string `json:"end_date"`   // YYYY-MM-DD, inklusif
+	Reason    string `json:"reason"`
+}
+
+type LeaveBalanceInput struct {
+	Entitlement int `json:"entitlement"`
+	CarriedOver int `json:"carried_over"`
+}
+
+type LeaveSupervisorInput struct {
+	Supervisor string `json:"supervisor"`
+}
+
+// leaveBalanceSummary adalah saldo cuti tahunan beserta pemakaiannya
+type leaveBalanceSummary struct {
+	Username    string `json:"username"`
+	Year        int    `json:"year"`
+	Entitlement int    `json:"entitlement"`
+	CarriedOver int    `json:"carried_over"`
+	Used        int    `json:"used"`      // Hari kerja cuti tahunan yang sudah disetujui
+	Pending     int    `json:"pending"`   // Hari kerja cuti tahunan yang menunggu persetujuan
+	Remaining   int    `json:"remaining"` // Entitlement + CarriedOver - Used - Pending
+}
+
+// ********** Jenis Cuti ********** //
+
+func GetLeaveTypes(c *gin.Context) {
+	var types []models.LeaveType
+	if err := initializers.DB.Table("kegiatan.leave_types").Order("code").Find(&types).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, types)
+}
+
+// UpdateLeaveType mengubah nama, batas hari, warna, atau pemotongan saldo sebuah jenis cuti
+func UpdateLeaveType(c *gin.Context) {
+	var leaveType models.LeaveType
+	if err := initializers.DB.Table("kegiatan.leave_types").Where("code = ?", c.Param("code")).First(&leaveType).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Jenis cuti tidak ditemukan"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+	code := leaveType.Code
+	if err := c.ShouldBindJSON(&leaveType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	leaveType.Code = code
+	leaveType.Name = strings.TrimSpace(leaveType.Name)
+	if leaveType.Name == "" || leaveType.MaxDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "name harus diisi dan max_days tidak boleh negatif"})
+		return
+	}
+	if err := initializers.DB.Table("kegiatan.leave_types").Save(&leaveType).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, leaveType)
+}
+
+// ********** Pengajuan Cuti ********** //
+
+// GetLeaveRequests mengembalikan antrean persetujuan: semua pengajuan untuk admin, selain itu pengajuan
+// yang atasannya adalah pengguna. Query status dan year memfilter hasil.
+func GetLeaveRequests(c *gin.Context) {
+	query, ok := leaveRequestQuery(c)
+	if !ok {
+		return
+	}
+	if c.GetString("role") != "admin" {
+		query = query.Where("supervisor = ?", c.GetString("username"))
+	}
+	var requests []models.LeaveRequest
+	if err := query.Order("start_date DESC").Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// GetMyLeaveRequests mengembalikan pengajuan cuti milik pengguna yang login
+func GetMyLeaveRequests(c *gin.Context) {
+	query, ok := leaveRequestQuery(c)
+	if !ok {
+		return
+	}
+	var requests []models.LeaveRequest
+	if err := query.Where("username = ?", c.GetString("username")).Order("start_date DESC").Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// CreateLeaveRequest mengajukan cuti. Jumlah hari dihitung dari hari kerja (tanpa akhir pekan dan hari
+// libur), dan cuti tahunan ditolak jika melebihi sisa saldo setelah dikurangi pengajuan yang masih pending.
+func CreateLeaveRequest(c *gin.Context) {
+	var input LeaveRequestInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	start, err := time.Parse(helper.EventDateLayout, strings.TrimSpace(input.StartDate))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "start_date harus berformat YYYY-MM-DD"})
+		return
+	}
+	end, err := time.Parse(helper.EventDateLayout, strings.TrimSpace(input.EndDate))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "end_date harus berformat YYYY-MM-DD"})
+		return
+	}
+
+	actor := bookingActorFromContext(c)
+	request := models.LeaveRequest{
+		Username:  actor.Name,
+		UserID:    actor.ID,
+		Type:      strings.TrimSpace(input.Type),
+		StartDate: start,
+		EndDate:   end,
+		Reason:    strings.TrimSpace(input.Reason),
+		Status:    models.LeavePending,
+	}
+
+	var leaveType models.LeaveType
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockLeaveUser(tx, request.Username); err != nil {
+			return err
+		}
+		var err error
+		leaveType, err = validateLeaveRequest(tx, &request)
+		if err != nil {
+			return err
+		}
+
+		var supervisor models.LeaveSupervisor
+		err = tx.Table("kegiatan.leave_supervisors").Where("username = ?", request.Username).First(&supervisor).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		request.Supervisor = supervisor.Supervisor
+		return tx.Table("kegiatan.leave_requests").Create(&request).Error
+	})
+	if err != nil {
+		respondLeaveError(c, err)
+		return
+	}
+
+	approvers := []string{request.Supervisor}
+	if request.Supervisor == "" {
+		approvers = helper.AdminUsernames()
+	}
+	err = helper.QueueNotificationMessage("ApprovalRequest", request.ID, leaveRequestTitle(request), request.StartDate, approvers,
+		map[string]string{
+			"type":        fmt.Sprintf("Pengajuan %s (%d hari)", leaveType.Name, request.Days),
+			"actor":       request.Username,
+			"comment":     request.Reason,
+			"source_type": "LeaveRequest",
+		})
+	if err != nil {
+		log.Printf("Gagal mengantrekan notifikasi pengajuan cuti %d: %v", request.ID, err)
+	}
+	c.JSON(http.StatusOK, request)
+}
+
+// ApproveLeaveRequest menyetujui pengajuan dan menambahkannya ke kalender cuti. Hanya atasan atau admin.
+func ApproveLeaveRequest(c *gin.Context) {
+	transitionLeaveRequest(c, "approve")
+}
+
+// RejectLeaveRequest menolak pengajuan dengan alasan wajib (comment). Hanya atasan atau admin.
+func RejectLeaveRequest(c *gin.Context) {
+	transitionLeaveRequest(c, "reject")
+}
+
+// CancelLeaveRequest membatalkan pengajuan pending atau approved dan menghapus entri kalender cutinya.
+// Pemilik hanya bisa membatalkan cuti yang belum dimulai, admin bisa kapan saja.
+func CancelLeaveRequest(c *gin.Context) {
+	transitionLeaveRequest(c, "cancel")
+}
+
+func transitionLeaveRequest(c *gin.Context, action string) {
+	transition := leaveTransitions[action]
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+	var body struct {
+		Comment string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	body.Comment = strings.TrimSpace(body.Comment)
+	if transition.NeedComment && body.Comment == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Alasan (comment) harus diisi"})
+		return
+	}
+	actor := bookingActorFromContext(c)
+	isAdmin := c.GetString("role") == "admin"
+
+	var request models.LeaveRequest
+	var removedCuti uint
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("kegiatan.leave_requests").First(&request, id).Error; err != nil {
+			return err
+		}
+		if err := lockLeaveUser(tx, request.Username); err != nil {
+			return err
+		}
+		// Baca ulang setelah lock agar status tidak berubah di tengah transisi
+		if err := tx.Table("kegiatan.leave_requests").First(&request, id).Error; err != nil {
+			return err
+		}
+
+		if action == "cancel" {
+			if !isAdmin && request.Username != actor.Name {
+				return errLeaveForbidden
+			}
+			if !isAdmin && request.Status == models.LeaveApproved && !request.StartDate.After(leaveToday()) {
+				return fmt.Errorf("%w: cuti yang sudah berjalan hanya bisa dibatalkan admin", errLeaveForbidden)
+			}
+		} else if !isAdmin && (request.Supervisor == "" || request.Supervisor != actor.Name || request.Username == actor.Name) {
+			return errLeaveForbidden
+		}
+		if !containsStatus(transition.From, request.Status) {
+			return fmt.Errorf("%w: pengajuan berstatus %s tidak bisa di-%s", errLeaveTransition, request.Status, action)
+		}
+
+		switch action {
+		case "approve":
+			// Hari libur bisa berubah sejak pengajuan, jumlah hari dan saldo dihitung ulang
+			if _, err := validateLeaveRequest(tx, &request); err != nil {
+				return err
+			}
+			if err := addLeaveToCalendar(tx, &request); err != nil {
+				return err
+			}
+		case "cancel":
+			if request.JadwalCutiID != nil {
+				removedCuti = *request.JadwalCutiID
+				if err := tx.Table("kegiatan.jadwal_cutis").Where("id = ?", *request.JadwalCutiID).Delete(&models.JadwalCuti{}).Error; err != nil {
+					return err
+				}
+				request.JadwalCutiID = nil
+			}
+		}
+
+		now := time.Now()
+		request.Status, request.DecidedBy, request.DecidedAt, request.DecisionNote = transition.To, actor.Name, &now, body.Comment
+		return tx.Table("kegiatan.leave_requests").Save(&request).Error
+	})
+	if err != nil {
+		respondLeaveError(c, err)
+		return
+	}
+
+	// Pemohon diberi tahu kecuali membatalkan sendiri
+	if !strings.EqualFold(request.Username, actor.Name) {
+		err := helper.QueueNotificationMessage("Approval", request.ID, leaveRequestTitle(request), request.StartDate,
+			[]string{request.Username}, map[string]string{
+				"type":        "Pengajuan cuti",
+				"status":      leaveStatusLabel(request.Status),
+				"actor":       actor.Name,
+				"comment":     request.DecisionNote,
+				"source_type": "LeaveRequest",
+			})
+		if err != nil {
+			log.Printf("Gagal mengantrekan notifikasi pengajuan cuti %d: %v", request.ID, err)
+		}
+	}
+	if removedCuti != 0 {
+		helper.SyncNotifications("JadwalCuti", removedCuti)
+		helper.PublishCalendarChange("JadwalCuti", "deleted", removedCuti)
+	}
+	if request.Status == models.LeaveApproved && request.JadwalCutiID != nil {
+		helper.SyncNotifications("JadwalCuti", *request.JadwalCutiID)
+		helper.PublishCalendarChange("JadwalCuti", "created", *request.JadwalCutiID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": transition.SuccessPrefix, "request": request})
+}
+
+// ********** Saldo Cuti ********** //
+
+// GetLeaveBalance mengembalikan saldo cuti tahunan pengguna yang login. Query year default tahun ini,
+// admin bisa melihat pengguna lain lewat query username.
+func GetLeaveBalance(c *gin.Context) {
+	year, ok := leaveYearQuery(c)
+	if !ok {
+		return
+	}
+	username := c.GetString("username")
+	if value := c.Query("username"); value != "" && value != username {
+		if c.GetString("role") != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"message": "Hanya admin yang bisa melihat saldo pengguna lain"})
+			return
+		}
+		username = value
+	}
+	summary, err := leaveBalanceFor(initializers.DB, username, year, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetLeaveBalances mengembalikan saldo semua pengguna yang sudah memiliki saldo di tahun tersebut
+func GetLeaveBalances(c *gin.Context) {
+	year, ok := leaveYearQuery(c)
+	if !ok {
+		return
+	}
+	var usernames []string
+	if err := initializers.DB.Table("kegiatan.leave_balances").Where("year = ?", year).Order("username").
+		Pluck("username", &usernames).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	summaries := []leaveBalanceSummary{}
+	for _, username := range usernames {
+		summary, err := leaveBalanceFor(initializers.DB, username, year, 0)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		summaries = append(summaries, summary)
+	}
+	c.JSON(http.StatusOK, summaries)
+}
+
+// UpdateLeaveBalance mengatur jatah dan sisa bawaan cuti seorang pengguna pada satu tahun
+func UpdateLeaveBalance(c *gin.Context) {
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "year tidak valid"})
+		return
+	}
+	var input LeaveBalanceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	if input.Entitlement < 0 || input.CarriedOver < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "entitlement dan carried_over tidak boleh negatif"})
+		return
+	}
+	username := c.Param("username")
+
+	var summary leaveBalanceSummary
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockLeaveUser(tx, username); err != nil {
+			return err
+		}
+		balance, err := ensureLeaveBalance(tx, username, year)
+		if err != nil {
+			return err
+		}
+		balance.Entitlement, balance.CarriedOver, balance.UpdateBy = input.Entitlement, input.CarriedOver, c.GetString("username")
+		if err := tx.Table("kegiatan.leave_balances").Save(&balance).Error; err != nil {
+			return err
+		}
+		summary, err = leaveBalanceFor(tx, username, year, 0)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// ********** Atasan ********** //
+
+func GetLeaveSupervisors(c *gin.Context) {
+	var supervisors []models.LeaveSupervisor
+	if err := initializers.DB.Table("kegiatan.leave_supervisors").Order("username").Find(&supervisors).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, supervisors)
+}
+
+// UpsertLeaveSupervisor menetapkan atasan seorang pengguna. Berlaku untuk pengajuan baru, pengajuan
+// pending yang belum diputuskan ikut dipindahkan ke atasan baru.
+func UpsertLeaveSupervisor(c *gin.Context) {
+	var input LeaveSupervisorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	supervisor := models.LeaveSupervisor{
+		Username:   c.Param("username"),
+		Supervisor: strings.TrimSpace(input.Supervisor),
+		UpdateBy:   c.GetString("username"),
+	}
+	if supervisor.Supervisor == "" || supervisor.Supervisor == supervisor.Username {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "supervisor harus diisi dan tidak boleh pengguna itu sendiri"})
+		return
+	}
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("kegiatan.leave_supervisors").Save(&supervisor).Error; err != nil {
+			return err
+		}
+		return tx.Table("kegiatan.leave_requests").
+			Where("username = ? AND status = ?", supervisor.Username, models.LeavePending).
+			Update("supervisor", supervisor.Supervisor).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, supervisor)
+}
+
+// DeleteLeaveSupervisor menghapus atasan, pengajuan pengguna tersebut selanjutnya diputuskan admin
+func DeleteLeaveSupervisor(c *gin.Context) {
+	username := c.Param("username")
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("kegiatan.leave_supervisors").Where("username = ?", username).Delete(&models.LeaveSupervisor{}).Error; err != nil {
+			return err
+		}
+		return tx.Table("kegiatan.leave_requests").
+			Where("username = ? AND status = ?", username, models.LeavePending).
+			Update("supervisor", "").Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ********** Helper ********** //
+
+// lockLeaveUser menyerialkan perubahan pengajuan dan saldo cuti per pengguna sampai transaksi selesai
+func lockLeaveUser(tx *gorm.DB, username string) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "kegiatan.leave:"+username).Error
+}
+
+// validateLeaveRequest memeriksa jenis, rentang tanggal, hari kerja, irisan, dan saldo pengajuan,
+// lalu mengisi Days. Pengajuan itu sendiri tidak dihitung saat memeriksa irisan dan saldo.
+func validateLeaveRequest(tx *gorm.DB, request *models.LeaveRequest) (models.LeaveType, error) {
+	var leaveType models.LeaveType
+	if err := tx.Table("kegiatan.leave_types").Where("code = ?", request.Type).First(&leaveType).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return leaveType, fmt.Errorf("%w: jenis cuti %q tidak dikenal", errLeaveInvalid, request.Type)
+		}
+		return leaveType, err
+	}
+	if request.EndDate.Before(request.StartDate) {
+		return leaveType, fmt.Errorf("%w: end_date tidak boleh sebelum start_date", errLeaveInvalid)
+	}
+	if request.StartDate.Year() != request.EndDate.Year() {
+		return leaveType, fmt.Errorf("%w: cuti lintas tahun harus diajukan terpisah per tahun", errLeaveInvalid)
+	}
+
+	holidays, err := helper.LoadHolidays(request.StartDate, request.EndDate)
+	if err != nil {
+		return leaveType, err
+	}
+	request.Days = helper.CountWorkingDays(request.StartDate, request.EndDate, holidays)
+	if request.Days == 0 {
+		return leaveType, fmt.Errorf("%w: rentang cuti tidak berisi hari kerja", errLeaveInvalid)
+	}
+	if leaveType.MaxDays > 0 && request.Days > leaveType.MaxDays {
+		return leaveType, fmt.Errorf("%w: %s maksimal %d hari kerja per pengajuan", errLeaveInvalid, leaveType.Name, leaveType.MaxDays)
+	}
+
+	var overlap int64
+	err = tx.Table("kegiatan.leave_requests").
+		Where("username = ? AND id <> ? AND status IN ?", request.Username, request.ID, models.LeaveActiveStatuses).
+		Where("start_date <= ? AND end_date >= ?", request.EndDate.Format(helper.EventDateLayout), request.StartDate.Format(helper.EventDateLayout)).
+		Count(&overlap).Error
+	if err != nil {
+		return leaveType, err
+	}
+	if overlap > 0 {
+		return leaveType, errLeaveOverlap
+	}
+
+	if leaveType.DeductBalance {
+		summary, err := leaveBalanceFor(tx, request.Username, request.StartDate.Year(), request.ID)
+		if err != nil {
+			return leaveType, err
+		}
+		if request.Days > summary.Remaining {
+			return leaveType, fmt.Errorf("%w: sisa saldo cuti %d hari, diajukan %d hari", errLeaveInvalid, summary.Remaining, request.Days)
+		}
+	}
+	return leaveType, nil
+}
+
+// addLeaveToCalendar membuat entri JadwalCuti sepanjang hari untuk pengajuan yang disetujui
+func addLeaveToCalendar(tx *gorm.DB, request *models.LeaveRequest) error {
+	var leaveType models.LeaveType
+	if err := tx.Table("kegiatan.leave_types").Where("code = ?", request.Type).First(&leaveType).Error; err != nil {
+		return err
+	}
+	start, err := helper.ParseEventTime(request.StartDate.Format(helper.EventDateLayout))
+	if err != nil {
+		return err
+	}
+	// Akhir event AllDay eksklusif
+	end, err := helper.ParseEventTime(request.EndDate.AddDate(0, 0, 1).Format(helper.EventDateLayout))
+	if err != nil {
+		return err
+	}
+	event := models.JadwalCuti{
+		Title:          fmt.Sprintf("%s - %s", request.Username, leaveType.Name),
+		Start:          start,
+		End:            end,
+		AllDay:         true,
+		Color:          leaveType.Color,
+		LeaveRequestID: &request.ID,
+	}
+	if err := tx.Table("kegiatan.jadwal_cutis").Create(&event).Error; err != nil {
+		return err
+	}
+	request.JadwalCutiID = &event.ID
+	return nil
+}
+
+// ensureLeaveBalance mengambil saldo pengguna pada tahun tersebut, atau membuatnya dengan jatah default.
+// Sisa saldo tahun sebelumnya dibawa sampai batas LEAVE_MAX_CARRY_OVER.
+func ensureLeaveBalance(tx *gorm.DB, username string, year int) (models.LeaveBalance, error) {
+	var balance models.LeaveBalance
+	err := tx.Table("kegiatan.leave_balances").Where("username = ? AND year = ?", username, year).First(&balance).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return balance, err
+	}
+
+	balance = models.LeaveBalance{
+		Username:    username,
+		Year:        year,
+		Entitlement: leaveEnvInt("LEAVE_ANNUAL_DAYS", defaultLeaveAnnualDays),
+	}
+	var previous models.LeaveBalance
+	err = tx.Table("kegiatan.leave_balances").Where("username = ? AND year = ?", username, year-1).First(&previous).Error
+	switch {
+	case err == nil:
+		used, _, err := leaveDaysUsed(tx, username, year-1, 0)
+		if err != nil {
+			return balance, err
+		}
+		carry := previous.Entitlement + previous.CarriedOver - used
+		if maxCarry := leaveEnvInt("LEAVE_MAX_CARRY_OVER", defaultLeaveMaxCarryOver); carry > maxCarry {
+			carry = maxCarry
+		}
+		if carry > 0 {
+			balance.CarriedOver = carry
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return balance, err
+	}
+
+	if err := tx.Table("kegiatan.leave_balances").Clauses(clause.OnConflict{DoNothing: true}).Create(&balance).Error; err != nil {
+		return balance, err
+	}
+	err = tx.Table("kegiatan.leave_balances").Where("username = ? AND year = ?", username, year).First(&balance).Error
+	return balance, err
+}
+
+// leaveBalanceFor menghitung saldo cuti tahunan, excludeID tidak dihitung (dipakai saat memvalidasi ulang pengajuan)
+func leaveBalanceFor(tx *gorm.DB, username string, year int, excludeID uint) (leaveBalanceSummary, error) {
+	balance, err := ensureLeaveBalance(tx, username, year)
+	if err != nil {
+		return leaveBalanceSummary{}, err
+	}
+	used, pending, err := leaveDaysUsed(tx, username, year, excludeID)
+	if err != nil {
+		return leaveBalanceSummary{}, err
+	}
+	return leaveBalanceSummary{
+		Username:    username,
+		Year:        year,
+		Entitlement: balance.Entitlement,
+		CarriedOver: balance.CarriedOver,
+		Used:        used,
+		Pending:     pending,
+		Remaining:   balance.Entitlement + balance.CarriedOver - used - pending,
+	}, nil
+}
+
+// leaveDaysUsed menjumlahkan hari kerja cuti yang memotong saldo, terpisah antara approved dan pending
+func leaveDaysUsed(tx *gorm.DB, username string, year int, excludeID uint) (int, int, error) {
+	var rows []struct {
+		Status string
+		Days   int
+	}
+	err := tx.Table("kegiatan.leave_requests AS r").
+		Select("r.status, COALESCE(SUM(r.days), 0) AS days").
+		Joins("JOIN kegiatan.leave_types t ON t.code = r.type").
+		Where("r.username = ? AND EXTRACT(YEAR FROM r.start_date) = ? AND t.deduct_balance", username, year).
+		Where("r.status IN ? AND r.id <> ?", models.LeaveActiveStatuses, excludeID).
+		Group("r.status").
+		Scan(&rows).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	var used, pending int
+	for _, row := range rows {
+		if row.Status == models.LeaveApproved {
+			used = row.Days
+		} else {
+			pending = row.Days
+		}
+	}
+	return used, pending, nil
+}
+
+func leaveRequestQuery(c *gin.Context) (*gorm.DB, bool) {
+	query := initializers.DB.Table("kegiatan.leave_requests")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if c.Query("year") != "" {
+		year, ok := leaveYearQuery(c)
+		if !ok {
+			return nil, false
+		}
+		query = query.Where("EXTRACT(YEAR FROM start_date) = ?", year)
+	}
+	return query, true
+}
+
+func leaveYearQuery(c *gin.Context) (int, bool) {
+	value := c.Query("year")
+	if value == "" {
+		return leaveToday().Year(), true
+	}
+	year, err := strconv.Atoi(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "year tidak valid"})
+		return 0, false
+	}
+	return year, true
+}
+
+// leaveToday adalah tanggal hari ini (WIB) dalam bentuk yang sama dengan kolom date
+func leaveToday() time.Time {
+	today, _ := time.Parse(helper.EventDateLayout, helper.NewEventTime(time.Now(), true).String())
+	return today
+}
+
+func leaveEnvInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value < 0 {
+		return fallback
+	}
+	return value
+}
+
+func leaveRequestTitle(request models.LeaveRequest) string {
+	return fmt.Sprintf("Cuti %s %s s.d. %s", request.Username,
+		request.StartDate.Format(helper.EventDateLayout), request.EndDate.Format(helper.EventDateLayout))
+}
+
+func leaveStatusLabel(status string) string {
+	switch status {
+	case models.LeaveApproved:
+		return "disetujui"
+	case models.LeaveRejected:
+		return "ditolak"
+	case models.LeaveCancelled:
+		return "dibatalkan"
+	default:
+		return status
+	}
+}
+
+func respondLeaveError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Pengajuan cuti tidak ditemukan"})
+	case errors.Is(err, errLeaveInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case errors.Is(err, errLeaveForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+	case errors.Is(err, errLeaveOverlap), errors.Is(err, errLeaveTransition):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	}
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	helper "github.com/arkaramadhan/its-vo/common/utils"
+	"github.com/arkaramadhan/its-vo/kegiatan-service/models"
+	"gorm.io/gorm"
+)
+
+// reminderHorizon adalah batas pencarian kejadian berikutnya event berulang
+const reminderHorizon = 2 * 365 * 24 * time.Hour
+
+// RegisterNotificationSources mendaftarkan pembaca event kegiatan agar worker pengingat bisa memeriksa
+// perubahan event dan menjadwalkan kejadian berikutnya event berulang
+func RegisterNotificationSources() {
+	helper.RegisterNotificationSource("BookingRapat", recurringNotificationSource("kegiatan.booking_rapats",
+		func(query *gorm.DB) *gorm.DB {
+			return query.Where("status IN ?", models.BookingActiveStatuses)
+		},
+		func(event models.BookingRapat, source *helper.NotificationSource) {
+			source.Recipients = []string{event.CreateBy}
+			if err := validateBookingRoom(initializers.DB, &event); err == nil && event.RoomName != "" {
+				source.Data = map[string]string{"room": event.RoomName}
+			}
+		}))
+	helper.RegisterNotificationSource("JadwalRapat", recurringNotificationSource("kegiatan.jadwal_rapats", nil,
+		func(event models.JadwalRapat, source *helper.NotificationSource) {
+			source.Recipients = []string{event.CreateBy}
+		}))
+	helper.RegisterNotificationSource("TimelineDesktop", recurringNotificationSource[models.TimelineDesktop]("kegiatan.timeline_desktops", nil, nil))
+	helper.RegisterNotificationSource("JadwalCuti", func(id uint, after time.Time) (helper.NotificationSource, bool, error) {
+		var event models.JadwalCuti
+		if err := initializers.DB.Table("kegiatan.jadwal_cutis").First(&event, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return helper.NotificationSource{}, false, nil
+			}
+			return helper.NotificationSource{}, false, err
+		}
+		source := helper.NotificationSource{Title: event.Title, Start: event.GetStart()}
+		// Cuti dari pengajuan dikirim ke pemohonnya
+		if event.LeaveRequestID != nil {
+			var request models.LeaveRequest
+			if err := initializers.DB.Table("kegiatan.leave_requests").Select("username").First(&request, *event.LeaveRequestID).Error; err == nil {
+				source.Recipients = []string{request.Username}
+			}
+		}
+		return source, true, nil
+	})
+}
+
+// recurringNotificationSource membaca event dari table. Untuk seri berulang dikembalikan kejadian pertama
+// setelah after yang tidak dilewati dan belum di-override, override punya pengingatnya sendiri. describe
+// mengisi penerima dan data template.
+func recurringNotificationSource[T helper.RecurringEvent[T]](table string, scope func(*gorm.DB) *gorm.DB,
+	describe func(T, *helper.NotificationSource)) helper.NotificationSourceFunc {
+	return func(id uint, after time.Time) (helper.NotificationSource, bool, error) {
+		var event T
+		query := initializers.DB.Table(table).Where("id = ?", id)
+		if scope != nil {
+			query = scope(query)
+		}
+		if err := query.First(&event).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return helper.NotificationSource{}, false, nil
+			}
+			return helper.NotificationSource{}, false, err
+		}
+
+		source := helper.NotificationSource{Title: event.GetTitle(), Start: event.GetStart()}
+		if describe != nil {
+			describe(event, &source)
+		}
+		rec := event.GetRecurrence()
+		if rec.RRule == "" {
+			return source, true, nil
+		}
+		occurrences, err := helper.EventOccurrences(event, rec, after, after.Add(reminderHorizon))
+		if err != nil {
+			return helper.NotificationSource{}, false, err
+		}
+		var overridden []string
+		if err := initializers.DB.Table(table).Where("parent_id = ?", id).Pluck("original_start", &overridden).Error; err != nil {
+			return helper.NotificationSource{}, false, err
+		}
+		skip := make(map[string]bool, len(overridden))
+		for _, key := range overridden {
+			skip[key] = true
+		}
+		for _, occurrence := range occurrences {
+			if occurrence.Start.After(after) && !skip[occurrence.Key] {
+				source.Start = occurrence.Start
+				return source, true, nil
+			}
+		}
+		return helper.NotificationSource{}, false, nil
+	}
+}
+
+// eventIDs mengumpulkan ID event, dipakai untuk menyinkronkan pengingat setelah update
+func eventIDs[T interface{ GetID() uint }](events []T) []uint {
+	ids := make([]uint, len(events))
+	for i, event := range events {
+		ids[i] = event.GetID()
+	}
+	return ids
+}
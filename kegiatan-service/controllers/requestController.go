@@ -1,65 +1,368 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/arkaramadhan/its-vo/common/initializers"
+	helper "github.com/arkaramadhan/its-vo/common/utils"
 	"github.com/arkaramadhan/its-vo/kegiatan-service/models"
 	"github.com/gin-gonic/gin"
-
+	"gorm.io/gorm"
 )
 
-func RequestIndex(c *gin.Context) {
+// bookingSystemActor dipakai untuk persetujuan dan penolakan otomatis
+const bookingSystemActor = "system"
+
+// bookingTransition adalah aksi persetujuan yang memindahkan booking dari salah satu status From ke To
+type bookingTransition struct {
+	From          []string
+	To            string
+	NeedComment   bool
+	OwnerAllowed  bool // Selain admin, pembuat booking juga boleh melakukan aksi ini
+	SuccessPrefix string
+}
+
+var bookingTransitions = map[string]bookingTransition{
+	"approve":  {From: []string{models.BookingPending}, To: models.BookingApproved, SuccessPrefix: "Request diterima"},
+	"reject":   {From: []string{models.BookingPending}, To: models.BookingRejected, NeedComment: true, SuccessPrefix: "Request ditolak"},
+	"cancel":   {From: []string{models.BookingPending, models.BookingApproved}, To: models.BookingCancelled, OwnerAllowed: true, SuccessPrefix: "Booking dibatalkan"},
+	"withdraw": {From: []string{models.BookingPending}, To: models.BookingWithdrawn, OwnerAllowed: true, SuccessPrefix: "Request ditarik"},
+}
+
+// errNotOwner dikembalikan jika pengguna bukan pembuat data dan bukan admin, ditampilkan sebagai 403
+var errNotOwner = errors.New("hanya pembuat atau admin yang boleh mengubah data ini")
+
+// errBookingTransition dikembalikan untuk aksi yang tidak berlaku pada status booking saat ini, ditampilkan sebagai 409
+var errBookingTransition = errors.New("status booking tidak bisa diubah")
+
+// bookingBlockedError dikembalikan jika booking yang akan disetujui bentrok dengan booking yang sudah disetujui
+type bookingBlockedError struct {
+	conflicts []bookingSummary
+}
+
+func (e *bookingBlockedError) Error() string {
+	return fmt.Sprintf("booking bentrok dengan %d booking yang sudah disetujui", len(e.conflicts))
+}
+
+// bookingActor adalah pengguna yang mengubah status booking, diambil dari token
+type bookingActor struct {
+	Name string
+	ID   uint
+}
+
+// checkOwner memastikan pengguna adalah pembuat data atau admin.
+// Data lama tanpa CreateBy hanya bisa diubah admin.
+func checkOwner(c *gin.Context, createBy string) error {
+	if c.GetString("role") == "admin" || (createBy != "" && createBy == c.GetString("username")) {
+		return nil
+	}
+	return errNotOwner
+}
 
-	var request []models.BookingRapat
-	// Tambahkan filter untuk tidak menampilkan event dengan status "pending"
-	if err := initializers.DB.Where("status = ?", "pending").Find(&request).Error; err != nil {
+func bookingActorFromContext(c *gin.Context) bookingActor {
+	actor := bookingActor{Name: c.GetString("username")}
+	if id, ok := c.Get("userID"); ok {
+		actor.ID, _ = id.(uint)
+	}
+	return actor
+}
+
+// RequestIndex menampilkan booking pending beserta booking yang bentrok dengannya
+func RequestIndex(c *gin.Context) {
+	var requests []models.BookingRapat
+	if err := initializers.DB.Table("kegiatan.booking_rapats").Where("status = ?", models.BookingPending).
+		Order("start").Find(&requests).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, request)
+	requests, err := withRoomNames(initializers.DB, requests)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	result := []bookingRapatResponse{}
+	for _, request := range requests {
+		conflicts, err := conflictsBookingRapat(initializers.DB, request)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		summaries := []bookingSummary{}
+		for _, conflict := range conflicts {
+			summary := newBookingSummary(conflict)
+			summary.RoomName = request.RoomName
+			summaries = append(summaries, summary)
+		}
+		result = append(result, bookingRapatResponse{BookingRapat: request, Conflicts: summaries})
+	}
+	c.JSON(http.StatusOK, result)
+}
 
+// ApproveRequest menyetujui booking pending. Bentrok dicek ulang: booking yang bentrok dengan booking
+// yang sudah disetujui ditolak dengan 409, sedangkan booking pending yang bentrok otomatis ditolak.
+func ApproveRequest(c *gin.Context) {
+	transitionBookingRapat(c, "approve")
 }
 
-func AccRequest(c *gin.Context) {
+// RejectRequest menolak booking pending, body comment wajib diisi sebagai alasan
+func RejectRequest(c *gin.Context) {
+	transitionBookingRapat(c, "reject")
+}
 
-	id := c.Params.ByName("id")
+// CancelRequest membatalkan booking pending atau yang sudah disetujui, oleh pembuat booking atau admin
+func CancelRequest(c *gin.Context) {
+	transitionBookingRapat(c, "cancel")
+}
 
-	var request models.BookingRapat
+// WithdrawRequest menarik booking yang masih pending, oleh pembuat booking atau admin
+func WithdrawRequest(c *gin.Context) {
+	transitionBookingRapat(c, "withdraw")
+}
 
-	if err := initializers.DB.First(&request, id); err.Error != nil {
-		c.JSON(404, gin.H{"message": "Request tidak ditemukan"})
+// GetBookingRapatHistory mengembalikan riwayat status sebuah booking, urut dari yang terlama
+func GetBookingRapatHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
 		return
 	}
+	var history []models.BookingRapatHistory
+	if err := initializers.DB.Table("kegiatan.booking_rapat_histories").Where("booking_id = ?", id).
+		Order("created_at, id").Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
 
-	// Cek apakah statusnya "pending"
-	if request.Status != "pending" {
-		c.JSON(400, gin.H{"message": "Request status tidak pending"})
+func transitionBookingRapat(c *gin.Context, action string) {
+	transition := bookingTransitions[action]
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+	var body struct {
+		Comment string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	body.Comment = strings.TrimSpace(body.Comment)
+	if transition.NeedComment && body.Comment == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Alasan (comment) harus diisi"})
 		return
 	}
+	actor := bookingActorFromContext(c)
+
+	var booking models.BookingRapat
+	var superseded []models.BookingRapat
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockBookingRapat(tx); err != nil {
+			return err
+		}
+		if err := tx.Table("kegiatan.booking_rapats").First(&booking, id).Error; err != nil {
+			return err
+		}
+		if transition.OwnerAllowed {
+			if err := checkOwner(c, booking.CreateBy); err != nil {
+				return err
+			}
+		}
+		if !containsStatus(transition.From, booking.Status) {
+			return fmt.Errorf("%w: booking berstatus %s tidak bisa di-%s", errBookingTransition, booking.Status, action)
+		}
 
-	initializers.DB.Model(&request).Update("status", "acc")
+		if action == "approve" {
+			var err error
+			superseded, err = supersededBookingRapat(tx, booking)
+			if err != nil {
+				return err
+			}
+			comment := fmt.Sprintf("Otomatis ditolak karena bentrok dengan booking \"%s\" yang disetujui", booking.Title)
+			for i := range superseded {
+				if err := setBookingRapatStatus(tx, &superseded[i], models.BookingRejected, "auto_reject",
+					bookingActor{Name: bookingSystemActor}, comment); err != nil {
+					return err
+				}
+			}
+		}
+		if err := setBookingRapatStatus(tx, &booking, transition.To, action, actor, body.Comment); err != nil {
+			return err
+		}
+		// Domain event per status untuk webhook, misal booking_rapat.approved dan booking_rapat.rejected
+		for _, changed := range append([]models.BookingRapat{booking}, superseded...) {
+			if err := helper.RecordDomainEvent(tx, "kegiatan", "booking_rapat", changed.Status, changed.ID, changed); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		var blocked *bookingBlockedError
+		switch {
+		case errors.As(err, &blocked):
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error(), "conflicts": blocked.conflicts})
+		case errors.Is(err, errBookingTransition):
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		default:
+			respondBookingRapatError(c, err)
+		}
+		return
+	}
 
-	c.JSON(200, gin.H{"message": "Request diterima"})
+	notifyBookingRapatStatus(booking)
+	helper.SyncNotifications("BookingRapat", booking.ID)
+	publishBookingRapatStatus(booking)
+	for _, rejected := range superseded {
+		notifyBookingRapatStatus(rejected)
+		helper.SyncNotifications("BookingRapat", rejected.ID)
+		publishBookingRapatStatus(rejected)
+	}
 
+	supersededSummaries := []bookingSummary{}
+	for _, rejected := range superseded {
+		supersededSummaries = append(supersededSummaries, newBookingSummary(rejected))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":    transition.SuccessPrefix,
+		"booking":    booking,
+		"superseded": supersededSummaries,
+	})
 }
 
-func CancelRequest(c *gin.Context) {
-
-	id := c.Params.ByName("id")
+// supersededBookingRapat mengembalikan booking pending yang bentrok dengan booking yang akan disetujui.
+// Jika ada booking approved yang bentrok, persetujuan dibatalkan dengan bookingBlockedError.
+func supersededBookingRapat(tx *gorm.DB, booking models.BookingRapat) ([]models.BookingRapat, error) {
+	conflicts, err := conflictsBookingRapat(tx, booking)
+	if err != nil {
+		return nil, err
+	}
 
-	var request models.BookingRapat
+	blocked := &bookingBlockedError{}
+	seen := make(map[uint]bool)
+	var ids []uint
+	for _, conflict := range conflicts {
+		if conflict.Status == models.BookingApproved {
+			blocked.conflicts = append(blocked.conflicts, newBookingSummary(conflict))
+			continue
+		}
+		// Kejadian seri berulang berbagi ID master, seri ditolak sekali saja
+		if !seen[conflict.ID] {
+			seen[conflict.ID] = true
+			ids = append(ids, conflict.ID)
+		}
+	}
+	if len(blocked.conflicts) > 0 {
+		return nil, blocked
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var superseded []models.BookingRapat
+	if err := tx.Table("kegiatan.booking_rapats").Where("id IN ?", ids).Find(&superseded).Error; err != nil {
+		return nil, err
+	}
+	return superseded, nil
+}
 
-	if err := initializers.DB.First(&request, id); err.Error != nil {
-		c.JSON(404, gin.H{"message": "Request tidak ditemukan"})
-		return
+// setBookingRapatStatus menyimpan status baru beserta pemberi keputusan, mencatat riwayat dan
+// menyamakan status ConflictRequest milik booking tersebut
+func setBookingRapatStatus(tx *gorm.DB, booking *models.BookingRapat, status, action string, actor bookingActor, comment string) error {
+	now := time.Now()
+	from := booking.Status
+	booking.Status, booking.DecidedBy, booking.DecidedAt, booking.StatusComment = status, actor.Name, &now, comment
+	err := tx.Table("kegiatan.booking_rapats").Where("id = ?", booking.ID).Updates(map[string]interface{}{
+		"status":         booking.Status,
+		"decided_by":     booking.DecidedBy,
+		"decided_at":     booking.DecidedAt,
+		"status_comment": booking.StatusComment,
+	}).Error
+	if err != nil {
+		if strings.Contains(err.Error(), models.BookingRapatOverlapConstraint) {
+			return errBookingRapatOverlap
+		}
+		return err
+	}
+	if err := tx.Table("kegiatan.conflict_requests").Where("new_event_id = ?", booking.ID).
+		Update("status", status).Error; err != nil {
+		return err
 	}
+	return recordBookingRapatHistory(tx, models.BookingRapatHistory{
+		BookingID:  booking.ID,
+		Action:     action,
+		FromStatus: from,
+		ToStatus:   status,
+		Actor:      actor.Name,
+		ActorID:    actor.ID,
+		Comment:    comment,
+	})
+}
+
+func recordBookingRapatHistory(tx *gorm.DB, entry models.BookingRapatHistory) error {
+	return tx.Table("kegiatan.booking_rapat_histories").Create(&entry).Error
+}
 
-	if err := initializers.DB.Delete(&request).Error; err != nil {
-		c.JSON(400, gin.H{"message": "gagal menghapus request: " + err.Error()})
+// notifyBookingRapatStatus memberi tahu pemohon bahwa status booking berubah, kecuali pemohon sendiri yang
+// membatalkan atau menarik
+func notifyBookingRapatStatus(booking models.BookingRapat) {
+	if booking.CreateBy == "" || strings.EqualFold(booking.CreateBy, booking.DecidedBy) {
 		return
 	}
+	err := helper.QueueNotificationMessage("Approval", booking.ID, booking.Title, booking.GetStart(), []string{booking.CreateBy},
+		map[string]string{
+			"type":        "Booking rapat",
+			"status":      bookingStatusLabel(booking.Status),
+			"actor":       booking.DecidedBy,
+			"comment":     booking.StatusComment,
+			"source_type": "BookingRapat",
+			"link":        helper.NotificationLink("BookingRapat", booking.ID),
+		})
+	if err != nil {
+		log.Printf("Gagal mengantrekan notifikasi status booking %d: %v", booking.ID, err)
+	}
+}
+
+// publishBookingRapatStatus mendorong perubahan status booking ke semua pengguna yang terhubung
+func publishBookingRapatStatus(booking models.BookingRapat) {
+	helper.PublishEvent(helper.RealtimeEvent{
+		Type:     helper.RealtimeBookingStatus,
+		Action:   booking.Status,
+		Category: "BookingRapat",
+		IDs:      []uint{booking.ID},
+		Data:     helper.RealtimeData(newBookingSummary(booking)),
+	})
+}
 
-	c.JSON(200, gin.H{"message": "Request berhasil dihapus"})
+func bookingStatusLabel(status string) string {
+	switch status {
+	case models.BookingApproved:
+		return "disetujui"
+	case models.BookingRejected:
+		return "ditolak"
+	case models.BookingCancelled:
+		return "dibatalkan"
+	case models.BookingWithdrawn:
+		return "ditarik"
+	default:
+		return "menunggu persetujuan"
+	}
+}
 
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
 }
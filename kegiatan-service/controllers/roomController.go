@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	helper "github.com/arkaramadhan/its-vo/common/utils"
+	"github.com/arkaramadhan/its-vo/kegiatan-service/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxAvailabilityDays = 62
+
+// errRoomNotFound dikembalikan jika booking merujuk ruangan yang tidak ada, ditampilkan sebagai 400
+var errRoomNotFound = errors.New("ruangan tidak ditemukan")
+
+func GetRooms(c *gin.Context) {
+	var rooms []models.Room
+	if err := initializers.DB.Table("kegiatan.rooms").Order("name").Find(&rooms).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rooms)
+}
+
+func ShowRoom(c *gin.Context) {
+	room, ok := findRoom(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, room)
+}
+
+func CreateRoom(c *gin.Context) {
+	var room models.Room
+	if err := c.ShouldBindJSON(&room); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	if err := normalizeRoom(&room); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	room.ID = 0
+	if err := initializers.DB.Table("kegiatan.rooms").Create(&room).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, room)
+}
+
+func UpdateRoom(c *gin.Context) {
+	room, ok := findRoom(c)
+	if !ok {
+		return
+	}
+	id := room.ID
+	if err := c.ShouldBindJSON(&room); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	if err := normalizeRoom(&room); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	room.ID = id
+	if err := initializers.DB.Table("kegiatan.rooms").Save(&room).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, room)
+}
+
+// DeleteRoom menolak menghapus ruangan yang masih dipakai booking
+func DeleteRoom(c *gin.Context) {
+	room, ok := findRoom(c)
+	if !ok {
+		return
+	}
+	var count int64
+	if err := initializers.DB.Table("kegiatan.booking_rapats").Where("room_id = ?", room.ID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"message": "Ruangan masih dipakai oleh booking rapat"})
+		return
+	}
+	if err := initializers.DB.Table("kegiatan.rooms").Delete(&room).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RoomAvailability mengembalikan booking dan slot kosong sebuah ruangan di rentang start/end.
+// Query day_start/day_end (HH:MM, default 08:00–17:00) membatasi jam kerja, min_duration (menit,
+// default 30) membuang slot yang terlalu pendek.
+func RoomAvailability(c *gin.Context) {
+	room, ok := findRoom(c)
+	if !ok {
+		return
+	}
+
+	period, explicit, err := helper.ParseOccurrenceRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	if !explicit {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "start dan end harus diisi"})
+		return
+	}
+	if period.End.Sub(period.Start) > maxAvailabilityDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Rentang ketersediaan maksimal 62 hari"})
+		return
+	}
+	dayStart, err := helper.ParseClock(c.Query("day_start"), 8*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	dayEnd, err := helper.ParseClock(c.Query("day_end"), 17*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	if dayEnd <= dayStart {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "day_end harus setelah day_start"})
+		return
+	}
+	minDuration := 30 * time.Minute
+	if value := c.Query("min_duration"); value != "" {
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "min_duration harus berupa jumlah menit"})
+			return
+		}
+		minDuration = time.Duration(minutes) * time.Minute
+	}
+
+	var events []models.BookingRapat
+	if err := initializers.DB.Table("kegiatan.booking_rapats").
+		Where("room_id = ?", room.ID).
+		Where("status IN ?", models.BookingActiveStatuses).
+		Where("rrule <> '' OR (start < ? AND \"end\" > ?)", period.End, period.Start).
+		Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	busy := []bookingSummary{}
+	var slots []helper.TimeSlot
+	for _, event := range helper.FilterEventsByPeriod(helper.ExpandRecurring(events, period.Start, period.End), period) {
+		event.RoomName = room.Name
+		busy = append(busy, newBookingSummary(event))
+		slots = append(slots, helper.TimeSlot{Start: event.GetStart(), End: event.GetEnd()})
+	}
+	free := helper.FreeSlots(slots, period.Start, period.End, dayStart, dayEnd, minDuration)
+	if free == nil {
+		free = []helper.TimeSlot{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"room":  room,
+		"start": period.Start,
+		"end":   period.End,
+		"busy":  busy,
+		"free":  free,
+	})
+}
+
+func findRoom(c *gin.Context) (models.Room, bool) {
+	var room models.Room
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return room, false
+	}
+	if err := initializers.DB.Table("kegiatan.rooms").First(&room, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Ruangan tidak ditemukan"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return room, false
+	}
+	return room, true
+}
+
+func normalizeRoom(room *models.Room) error {
+	room.Name = strings.TrimSpace(room.Name)
+	if room.Name == "" {
+		return errors.New("nama ruangan harus diisi")
+	}
+	if room.Capacity < 0 {
+		return errors.New("kapasitas tidak boleh negatif")
+	}
+	facilities := []string{}
+	for _, facility := range room.Facilities {
+		if facility = strings.TrimSpace(facility); facility != "" {
+			facilities = append(facilities, facility)
+		}
+	}
+	room.Facilities = facilities
+	return nil
+}
+
+// roomNames memetakan ID ruangan ke nama, dipakai sebagai ResourceMap export dan RoomName booking
+func roomNames(tx *gorm.DB) (map[uint]string, error) {
+	var rooms []models.Room
+	if err := tx.Table("kegiatan.rooms").Find(&rooms).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(rooms))
+	for _, room := range rooms {
+		names[room.ID] = room.Name
+	}
+	return names, nil
+}
+
+// validateBookingRoom memastikan ruangan booking ada dan mengisi RoomName
+func validateBookingRoom(tx *gorm.DB, event *models.BookingRapat) error {
+	if event.RoomID == nil {
+		event.RoomName = ""
+		return nil
+	}
+	var room models.Room
+	if err := tx.Table("kegiatan.rooms").First(&room, *event.RoomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errRoomNotFound
+		}
+		return err
+	}
+	event.RoomName = room.Name
+	return nil
+}
+
+// withRoomNames mengisi RoomName setiap booking
+func withRoomNames(tx *gorm.DB, events []models.BookingRapat) ([]models.BookingRapat, error) {
+	names, err := roomNames(tx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		events[i].RoomName = names[events[i].GetResourceID()]
+	}
+	return events, nil
+}
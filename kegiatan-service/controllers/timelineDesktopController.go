@@ -4,13 +4,13 @@ import (
 	"log"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/arkaramadhan/its-vo/common/initializers"
 	helper "github.com/arkaramadhan/its-vo/common/utils"
 	"github.com/arkaramadhan/its-vo/kegiatan-service/models"
 	"github.com/gin-gonic/gin"
 	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
 )
 
 // GetEventsTimeline retrieves all timeline events
@@ -20,6 +20,17 @@ func GetEventsDesktop(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+
+	// Event berulang dipecah menjadi kejadian di dalam rentang start/end
+	period, explicit, err := helper.ParseOccurrenceRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	events = helper.ExpandRecurring(events, period.Start, period.End)
+	if explicit {
+		events = helper.FilterEventsByPeriod(events, period)
+	}
 	c.JSON(http.StatusOK, events)
 }
 
@@ -31,37 +42,67 @@ func CreateEventDesktop(c *gin.Context) {
 		return
 	}
 
-	// Parsing waktu untuk notifikasi
-	loc, err := time.LoadLocation("Asia/Jakarta")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error loading location"})
+	if event.Start.IsZero() || event.End.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "start dan end harus diisi"})
 		return
 	}
-
-	var startTime time.Time
-	if event.AllDay {
-		// Jika AllDay = true, set waktu ke tengah malam
-		startTime, err = time.ParseInLocation("2006-01-02T15:04:05", event.Start+"T00:00:00", loc)
-	} else {
-		// Jika tidak, parse dengan format RFC3339
-		startTime, err = time.ParseInLocation(time.RFC3339, event.Start, loc)
-	}
-
-	if err != nil {
-		log.Printf("Error parsing start time: %v", err)
+	if err := event.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
-	// Panggil fungsi SetNotification
-	helper.SetNotification(event.Title, startTime, "TimelineDesktop")
 	if err := initializers.DB.Create(&event).Error; err != nil {
 		log.Printf("Error creating event: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+	helper.SetNotification(event.Title, event.GetStart(), "TimelineDesktop", event.ID)
+	helper.PublishCalendarChange("TimelineDesktop", "created", event.ID)
 	c.JSON(http.StatusOK, event)
 }
 
+// UpdateEventDesktop mengubah event. Untuk event berulang, query scope menentukan cakupan (this, following, all)
+// dan occurrence_start di body menunjuk kejadian yang diedit.
+func UpdateEventDesktop(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+	scope, err := helper.ParseRecurrenceScope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	var event models.TimelineDesktop
+	if err := c.ShouldBindJSON(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	occurrenceStart := event.OccurrenceStart
+	if err := event.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	var events []models.TimelineDesktop
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		ids, err := helper.UpdateRecurringEvent[models.TimelineDesktop](tx, "kegiatan.timeline_desktops", uint(id), &event, scope, occurrenceStart)
+		if err != nil {
+			return err
+		}
+		return tx.Table("kegiatan.timeline_desktops").Where("id IN ?", ids).Find(&events).Error
+	})
+	if err != nil {
+		helper.RespondRecurrenceError(c, err)
+		return
+	}
+	helper.SyncNotifications("TimelineDesktop", eventIDs(events)...)
+	helper.PublishCalendarChange("TimelineDesktop", "updated", eventIDs(events)...)
+	c.JSON(http.StatusOK, gin.H{"message": "Timeline desktop berhasil diperbarui", "events": events})
+}
+
 // DeleteEventTimeline deletes a timeline event by ID
 func DeleteEventDesktop(c *gin.Context) {
 	idParam := c.Param("id")
@@ -76,10 +117,21 @@ func DeleteEventDesktop(c *gin.Context) {
 		return
 	}
 
-	if err := initializers.DB.Where("id = ?", uint(id)).Delete(&models.TimelineDesktop{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	// Event berulang: scope this, following, atau all dengan occurrence_start kejadian yang dihapus
+	scope, err := helper.ParseRecurrenceScope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return helper.DeleteRecurringEvent[models.TimelineDesktop, *models.TimelineDesktop](tx, "kegiatan.timeline_desktops", uint(id), scope, c.Query("occurrence_start"))
+	})
+	if err != nil {
+		helper.RespondRecurrenceError(c, err)
 		return
 	}
+	helper.SyncNotifications("TimelineDesktop", uint(id))
+	helper.PublishCalendarChange("TimelineDesktop", "deleted", uint(id))
 	c.Status(http.StatusNoContent)
 }
 
@@ -95,11 +147,26 @@ func ExportTimelineDesktopToExcel(c *gin.Context, f *excelize.File, sheetName st
 		return err
 	}
 
+	// Periode export dari query year, start_month/end_month, atau start/end
+	period, err := helper.ParseCalendarPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return err
+	}
+
+	// Event berulang dipecah menjadi kejadian di dalam periode
 	var excelEvents []helper.ExcelEvent
-	for _, event := range events_timeline {
+	for _, event := range helper.ExpandRecurring(events_timeline, period.Start, period.End) {
 		excelEvents = append(excelEvents, event) // Pastikan `event` adalah tipe yang mengimplementasikan `ExcelEvent`
 	}
 
+	// Layout export: month (default), week, atau agenda
+	layout, err := helper.ParseCalendarLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return err
+	}
+
 	config := helper.CalenderConfig{
 		SheetName:   "TIMELINE DESKTOP",
 		FileName:    "its_report_timelineDesktop.xlsx",
@@ -107,6 +174,9 @@ func ExportTimelineDesktopToExcel(c *gin.Context, f *excelize.File, sheetName st
 		UseResource: false,
 		RowOffset:   0,
 		ColOffset:   0,
+		Period:      period,
+		ShowLegend:  c.Query("legend") == "true",
+		Layout:      layout,
 	}
 
 	if f != nil {
@@ -120,3 +190,17 @@ func ExportTimelineDesktopToExcel(c *gin.Context, f *excelize.File, sheetName st
 	}
 	return nil
 }
+
+func ExportTimelineDesktopICS(c *gin.Context) {
+	var events []models.TimelineDesktop
+	if err := initializers.DB.Table("kegiatan.timeline_desktops").Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	var icsEvents []helper.ICSEvent
+	for _, event := range events {
+		icsEvents = append(icsEvents, event)
+	}
+	helper.ServeICS(c, "timeline_desktop", "Timeline Desktop", icsEvents)
+}
@@ -36,6 +36,19 @@ func main() {
 
 	r.Use(middleware.CORS())
 
+	utils.RegisterDomainEvents(initializers.DB, "kegiatan")
+	utils.StartWebhookWorker()
+
+	controllers.RegisterNotificationSources()
+	utils.StartNotificationWorker()
+	utils.StartRealtimeListener()
+
+	// ********** Route iCalendar Feed ********** //
+	r.GET("/ics/:token/bookingRapat.ics", utils.CalendarFeedAuth(), controllers.ExportBookingRapatICS)
+	r.GET("/ics/:token/jadwalRapat.ics", utils.CalendarFeedAuth(), controllers.ExportJadwalRapatICS)
+	r.GET("/ics/:token/jadwalCuti.ics", utils.CalendarFeedAuth(), controllers.ExportJadwalCutiICS)
+	r.GET("/ics/:token/timelineDesktop.ics", utils.CalendarFeedAuth(), controllers.ExportTimelineDesktopICS)
+
 	// ********** Middleware ********** //
 	r.Use(middleware.TokenAuthMiddleware())
 	store := cookie.NewStore([]byte("secret"))
@@ -44,26 +57,71 @@ func main() {
 	// ********** Route Timeline Desktop ********** //
 	r.GET("/timelineDesktop", controllers.GetEventsDesktop)
 	r.POST("/timelineDesktop", controllers.CreateEventDesktop)
+	r.PUT("/timelineDesktop/:id", controllers.UpdateEventDesktop)
 	r.DELETE("/timelineDesktop/:id", controllers.DeleteEventDesktop)
 	r.GET("/exportTimelineDesktop", controllers.ExportTimelineDesktopHandler)
+	r.GET("/icsTimelineDesktop", controllers.ExportTimelineDesktopICS)
 
 	// ********** Route Booking Rapat ********** //
 	r.GET("/booking-rapat", controllers.GetEventsBookingRapat)
+	r.GET("/booking-rapat/mine", controllers.GetMyBookingRapat)
 	r.POST("/booking-rapat", controllers.CreateEventBookingRapat)
+	r.PUT("/booking-rapat/:id", controllers.UpdateEventBookingRapat)
 	r.DELETE("/booking-rapat/:id", controllers.DeleteEventBookingRapat)
 	r.GET("/exportBookingRapat", controllers.ExportBookingRapatHandler)
+	r.GET("/icsBookingRapat", controllers.ExportBookingRapatICS)
+	r.POST("/importIcsBookingRapat/preview", controllers.PreviewICSBookingRapat)
+	r.POST("/importIcsBookingRapat/:id/confirm", controllers.ConfirmICSBookingRapat)
+
+	// ********** Route Ruangan ********** //
+	r.GET("/rooms", controllers.GetRooms)
+	r.GET("/rooms/:id", controllers.ShowRoom)
+	r.GET("/rooms/:id/availability", controllers.RoomAvailability)
+	r.POST("/rooms", middleware.RequireRole("admin"), controllers.CreateRoom)
+	r.PUT("/rooms/:id", middleware.RequireRole("admin"), controllers.UpdateRoom)
+	r.DELETE("/rooms/:id", middleware.RequireRole("admin"), controllers.DeleteRoom)
 
 	// ********** Route Jadwal Rapat ********** //
 	r.GET("/jadwal-rapat", controllers.GetEventsRapat)
 	r.POST("/jadwal-rapat", controllers.CreateEventRapat)
+	r.PUT("/jadwal-rapat/:id", controllers.UpdateEventRapat)
 	r.DELETE("/jadwal-rapat/:id", controllers.DeleteEventRapat)
 	r.GET("/exportRapat", controllers.ExportJadwalRapatHandler)
+	r.GET("/icsRapat", controllers.ExportJadwalRapatICS)
+	r.POST("/importIcsRapat/preview", controllers.PreviewICSJadwalRapat)
+	r.POST("/importIcsRapat/:id/confirm", controllers.ConfirmICSJadwalRapat)
 
 	// ********** Route Jadwal Cuti ********** //
 	r.GET("/jadwal-cuti", controllers.GetEventsCuti)
 	r.POST("/jadwal-cuti", controllers.CreateEventCuti)
 	r.DELETE("/jadwal-cuti/:id", controllers.DeleteEventCuti)
 	r.GET("/exportCuti", controllers.ExportJadwalCutiHandler)
+	r.GET("/icsCuti", controllers.ExportJadwalCutiICS)
+
+	// ********** Route Pengajuan Cuti ********** //
+	r.GET("/leave/types", controllers.GetLeaveTypes)
+	r.PUT("/leave/types/:code", middleware.RequireRole("admin"), controllers.UpdateLeaveType)
+	r.GET("/leave/requests", controllers.GetLeaveRequests)
+	r.GET("/leave/requests/mine", controllers.GetMyLeaveRequests)
+	r.POST("/leave/requests", controllers.CreateLeaveRequest)
+	r.POST("/leave/requests/:id/approve", controllers.ApproveLeaveRequest)
+	r.POST("/leave/requests/:id/reject", controllers.RejectLeaveRequest)
+	r.POST("/leave/requests/:id/cancel", controllers.CancelLeaveRequest)
+	r.GET("/leave/balance", controllers.GetLeaveBalance)
+	r.GET("/leave/balances", middleware.RequireRole("admin"), controllers.GetLeaveBalances)
+	r.PUT("/leave/balances/:username/:year", middleware.RequireRole("admin"), controllers.UpdateLeaveBalance)
+	r.GET("/leave/supervisors", middleware.RequireRole("admin"), controllers.GetLeaveSupervisors)
+	r.PUT("/leave/supervisors/:username", middleware.RequireRole("admin"), controllers.UpsertLeaveSupervisor)
+	r.DELETE("/leave/supervisors/:username", middleware.RequireRole("admin"), controllers.DeleteLeaveSupervisor)
+
+	// ********** Route Hari Libur ********** //
+	r.GET("/holidays", utils.GetHolidays)
+	r.POST("/holidays", middleware.RequireRole("admin"), utils.CreateHoliday)
+	r.PUT("/holidays/:id", middleware.RequireRole("admin"), utils.UpdateHoliday)
+	r.DELETE("/holidays/:id", middleware.RequireRole("admin"), utils.DeleteHoliday)
+	r.POST("/holidays/import", middleware.RequireRole("admin"), utils.ImportHolidays)
+	r.GET("/working-days", utils.WorkingDaysHandler)
+	r.GET("/working-days/add", utils.AddWorkingDaysHandler)
 
 	// ********** Route Meeting ********** //
 	r.GET("/meetings", controllers.MeetingIndex)
@@ -81,12 +139,57 @@ func main() {
 
 	// ********** Route Request ********** //
 	r.GET("/request", controllers.RequestIndex)
-	r.GET("/AccRequest/:id", controllers.AccRequest)
-	r.GET("/CancelRequest/:id", controllers.CancelRequest)
+	r.GET("/booking-rapat/:id/history", controllers.GetBookingRapatHistory)
+	r.POST("/booking-rapat/:id/approve", middleware.RequireRole("admin"), controllers.ApproveRequest)
+	r.POST("/booking-rapat/:id/reject", middleware.RequireRole("admin"), controllers.RejectRequest)
+	r.POST("/booking-rapat/:id/cancel", controllers.CancelRequest)
+	r.POST("/booking-rapat/:id/withdraw", controllers.WithdrawRequest)
 
 	// ********** Route Notification ********** //
 	r.GET("/notifications", utils.GetNotifications)
+	r.GET("/notifications/unread-count", utils.GetUnreadNotificationCount)
+	r.POST("/notifications/read-all", utils.MarkAllNotificationsRead)
+	r.POST("/notifications/:id/read", utils.MarkNotificationRead)
+	r.POST("/notifications/:id/unread", utils.MarkNotificationUnread)
 	r.DELETE("/notifications/:id", utils.DeleteNotification)
+	r.GET("/notification-preferences", utils.GetNotificationPreferences)
+	r.PUT("/notification-preferences", utils.UpdateNotificationPreferences)
+	r.GET("/notification-jobs", middleware.RequireRole("admin"), utils.NotificationJobIndex)
+	r.POST("/notification-jobs/:id/retry", middleware.RequireRole("admin"), utils.RetryNotificationJob)
+	r.POST("/notification-jobs/test-email", middleware.RequireRole("admin"), utils.SendTestEmail)
+
+	// ********** Route Realtime ********** //
+	r.GET("/events/stream", utils.StreamEvents)
+
+	// ********** Route Calendar Feed Token ********** //
+	r.GET("/calendarFeed", utils.GetCalendarFeedToken)
+	r.POST("/calendarFeed", utils.CreateCalendarFeedToken)
+	r.DELETE("/calendarFeed", utils.RevokeCalendarFeedToken)
+
+	// ********** Route Storage ********** //
+	r.GET("/storage/reconcile", middleware.RequireRole("admin"), utils.ReconcileStorageHandler)
+	r.POST("/storage/reconcile", middleware.RequireRole("admin"), utils.ReconcileStorageHandler)
+	r.GET("/storage/usage", middleware.RequireRole("admin"), utils.StorageUsageHandler)
+	r.GET("/storage/usage/export", middleware.RequireRole("admin"), utils.ExportStorageUsageHandler)
+	r.GET("/storage/quotas", middleware.RequireRole("admin"), utils.StorageQuotaIndex)
+	r.PUT("/storage/quotas/:resource", middleware.RequireRole("admin"), utils.UpsertStorageQuota)
+	r.DELETE("/storage/quotas/:resource", middleware.RequireRole("admin"), utils.DeleteStorageQuota)
+
+	// ********** Route Quarantine ********** //
+	r.GET("/quarantine", middleware.RequireRole("admin"), utils.QuarantineIndex)
+	r.POST("/quarantine/scan", middleware.RequireRole("admin"), utils.ScanPendingFiles)
+	r.POST("/quarantine/:id/rescan", middleware.RequireRole("admin"), utils.RescanFile)
+	r.POST("/quarantine/:id/release", middleware.RequireRole("admin"), utils.ReleaseQuarantinedFile)
+	r.DELETE("/quarantine/:id", middleware.RequireRole("admin"), utils.DeleteQuarantinedFile)
+
+	// ********** Route Webhook ********** //
+	r.GET("/webhooks", middleware.RequireRole("admin"), utils.WebhookIndex)
+	r.POST("/webhooks", middleware.RequireRole("admin"), utils.CreateWebhook)
+	r.PUT("/webhooks/:id", middleware.RequireRole("admin"), utils.UpdateWebhook)
+	r.DELETE("/webhooks/:id", middleware.RequireRole("admin"), utils.DeleteWebhook)
+	r.POST("/webhooks/:id/ping", middleware.RequireRole("admin"), utils.PingWebhook)
+	r.GET("/webhooks/:id/deliveries", middleware.RequireRole("admin"), utils.WebhookDeliveryIndex)
+	r.POST("/webhook-deliveries/:id/redeliver", middleware.RequireRole("admin"), utils.RedeliverWebhook)
 
 	r.GET("/exportAll", exportAll.ExportAll)
 
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"fmt"
+	"log"
+
 	"github.com/arkaramadhan/its-vo/common/initializers"
+	helper "github.com/arkaramadhan/its-vo/common/utils"
 	"github.com/arkaramadhan/its-vo/kegiatan-service/models"
 )
 
@@ -10,19 +14,88 @@ func init() {
 	initializers.LoadEnvVariables()
 	initializers.ConnectToDB("kegiatan")
 	initializers.DB.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\";")
+	initializers.DB.Exec("CREATE EXTENSION IF NOT EXISTS btree_gist;") // Untuk exclusion constraint per ruangan
 
 }
 
 func main() {
 
+	// Start/End kalender berpindah dari teks ke timestamptz, data lama dikonversi sebelum AutoMigrate
+	var failures []helper.EventTimeMigrationFailure
+	for _, table := range []string{"booking_rapats", "jadwal_rapats", "jadwal_cutis", "timeline_desktops"} {
+		result, err := helper.MigrateEventTimeColumns(initializers.DB, table, "start", "end")
+		if err != nil {
+			log.Fatalf("Migrasi kolom waktu %s gagal: %v", table, err)
+		}
+		failures = append(failures, result...)
+	}
+	if len(failures) > 0 {
+		log.Printf("%d nilai waktu tidak bisa dikonversi, periksa kolom *_legacy:", len(failures))
+		for _, failure := range failures {
+			log.Printf("- %s id %d kolom %s: %q", failure.Table, failure.ID, failure.Column, failure.Value)
+		}
+	}
+
 	initializers.DB.AutoMigrate(
 		&models.TimelineDesktop{},
+		&models.Room{},
 		&models.BookingRapat{},
+		&models.BookingRapatHistory{},
 		&models.JadwalRapat{},
 		&models.JadwalCuti{},
 		&models.ConflictRequest{},
 		&models.Notification{},
 		&models.Meeting{},
+		&models.LeaveType{},
+		&models.LeaveBalance{},
+		&models.LeaveSupervisor{},
+		&models.LeaveRequest{},
 	)
 
+	// Status lama "acc" menjadi "approved" sesuai alur persetujuan
+	initializers.DB.Exec("UPDATE booking_rapats SET status = ? WHERE status = 'acc'", models.BookingApproved)
+	initializers.DB.Exec("UPDATE conflict_requests SET status = ? WHERE status = 'acc'", models.BookingApproved)
+
+	ensureBookingOverlapConstraint()
+	seedLeaveTypes()
+
+}
+
+// seedLeaveTypes membuat jenis cuti bawaan tanpa menimpa perubahan admin
+func seedLeaveTypes() {
+	types := []models.LeaveType{
+		{Code: models.LeaveAnnual, Name: "Cuti Tahunan", DeductBalance: true, Color: "#3b82f6"},
+		{Code: models.LeaveSick, Name: "Cuti Sakit", Color: "#ef4444"},
+		{Code: models.LeaveSpecial, Name: "Cuti Khusus", MaxDays: 3, Color: "#a855f7"},
+	}
+	for _, leaveType := range types {
+		if err := initializers.DB.Where("code = ?", leaveType.Code).FirstOrCreate(&leaveType).Error; err != nil {
+			log.Printf("Jenis cuti %s gagal dibuat: %v", leaveType.Code, err)
+		}
+	}
+}
+
+// ensureBookingOverlapConstraint memasang exclusion constraint agar dua booking tunggal berstatus approved
+// di ruangan yang sama tidak bisa beririsan meskipun ditulis bersamaan. Seri berulang dan override dicek
+// oleh aplikasi. Booking tanpa ruangan diperlakukan sebagai satu ruangan.
+func ensureBookingOverlapConstraint() {
+	// Constraint versi lama: tanpa ruangan, lalu dengan status "acc"
+	for _, name := range []string{"booking_rapats_no_overlap", "booking_rapats_room_no_overlap"} {
+		initializers.DB.Exec(fmt.Sprintf("ALTER TABLE booking_rapats DROP CONSTRAINT IF EXISTS %s", name))
+	}
+
+	var count int64
+	initializers.DB.Raw("SELECT count(*) FROM pg_constraint WHERE conname = ?", models.BookingRapatOverlapConstraint).Scan(&count)
+	if count > 0 {
+		return
+	}
+
+	err := initializers.DB.Exec(fmt.Sprintf(`ALTER TABLE booking_rapats ADD CONSTRAINT %s
+		EXCLUDE USING gist (COALESCE(room_id, 0) WITH =, tstzrange(start, "end") WITH &&)
+		WHERE (status = 'approved' AND COALESCE(rrule, '') = '' AND parent_id IS NULL AND "end" > start)`,
+		models.BookingRapatOverlapConstraint)).Error
+	if err != nil {
+		// Biasanya karena data lama berisi booking approved yang saling bentrok, perbaiki lalu jalankan ulang migrasi
+		log.Printf("Constraint %s gagal dibuat: %v", models.BookingRapatOverlapConstraint, err)
+	}
 }
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -70,42 +71,75 @@ func (m *Meeting) GetStatus() string {
 	return *m.Status
 }
 
+// BookingRapatOverlapConstraint adalah exclusion constraint yang menolak dua booking tunggal berstatus
+// approved beririsan di ruangan yang sama
+const BookingRapatOverlapConstraint = "booking_rapats_approved_no_overlap"
+
+// Status booking rapat. Pending dan approved menempati slot ruangan, sisanya status akhir.
+const (
+	BookingPending   = "pending"
+	BookingApproved  = "approved"
+	BookingRejected  = "rejected"
+	BookingCancelled = "cancelled"
+	BookingWithdrawn = "withdrawn"
+)
+
+// BookingActiveStatuses adalah status booking yang masih dihitung saat cek bentrok
+var BookingActiveStatuses = []string{BookingPending, BookingApproved}
+
+// Room adalah ruang rapat yang bisa dibooking
+type Room struct {
+	ID         uint     `gorm:"primaryKey" json:"id"`
+	Name       string   `gorm:"uniqueIndex" json:"name"`
+	Capacity   int      `json:"capacity"`
+	Floor      string   `json:"floor"`
+	Facilities []string `gorm:"serializer:json" json:"facilities"` // Mis. ["proyektor", "video conference"]
+}
+
+func (Room) TableName() string {
+	return "rooms"
+}
+
 type BookingRapat struct {
-	ID     uint   `gorm:"primaryKey" json:"id"`
-	Title  string `json:"title"`
-	Start  string `json:"start"`
-	End    string `json:"end"`
-	AllDay bool   `json:"allDay"`
-	Color  string `json:"color"` // Tambahkan field ini untuk warna
-	Status string `json:"status"`
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	Title     string           `json:"title"`
+	Start     helper.EventTime `json:"start"`
+	End       helper.EventTime `json:"end"`
+	AllDay    bool             `json:"allDay"`
+	Color     string           `json:"color"` // Tambahkan field ini untuk warna
+	Status    string           `gorm:"index" json:"status"`
+	ImportUID string           `gorm:"index" json:"import_uid,omitempty"` // UID event dari import .ics, untuk de-duplikasi
+	RoomID    *uint            `gorm:"index" json:"room_id"`              // Kosong untuk booking lama tanpa ruangan
+	CreateBy  string           `gorm:"index" json:"create_by"`            // Username pembuat, diisi dari token
+	// Keputusan terakhir, "system" untuk persetujuan atau penolakan otomatis
+	DecidedBy     string     `json:"decided_by,omitempty"`
+	DecidedAt     *time.Time `json:"decided_at,omitempty"`
+	StatusComment string     `json:"status_comment,omitempty"`
+	RoomName      string     `gorm:"-" json:"room_name,omitempty"` // Diisi controller dari tabel rooms
+	helper.Recurrence
 }
 
 func (BookingRapat) TableName() string {
 	return "booking_rapats"
 }
 
+// AfterFind mengembalikan format JSON Start/End sesuai AllDay
+func (e *BookingRapat) AfterFind(tx *gorm.DB) error {
+	e.Start.SetAllDay(e.AllDay)
+	e.End.SetAllDay(e.AllDay)
+	return nil
+}
+
 func (e BookingRapat) GetTitle() string {
 	return e.Title
 }
 
 func (e BookingRapat) GetStart() time.Time {
-	if e.AllDay {
-		t, _ := time.Parse("2006-01-02", e.Start)
-		return t
-	} else {
-		t, _ := time.Parse(time.RFC3339, e.Start) // Tambahkan penanganan error yang sesuai
-		return t
-	}
+	return e.Start.Time
 }
 
 func (e BookingRapat) GetEnd() time.Time {
-	if e.AllDay {
-		t, _ := time.Parse("2006-01-02", e.End)
-		return t
-	} else {
-		t, _ := time.Parse(time.RFC3339, e.End) // Tambahkan penanganan error yang sesuai
-		return t
-	}
+	return e.End.Time
 }
 
 func (e BookingRapat) GetColor() string {
@@ -117,44 +151,99 @@ func (e BookingRapat) GetAllDay() bool {
 }
 
 func (e BookingRapat) GetResourceID() uint {
-	return 0
+	if e.RoomID == nil {
+		return 0
+	}
+	return *e.RoomID
+}
+
+func (e BookingRapat) GetLocation() string {
+	return e.RoomName
+}
+
+func (e BookingRapat) GetDescription() string {
+	return ""
+}
+
+// GetUID memakai ID master untuk override agar klien kalender mencocokkannya lewat RECURRENCE-ID
+func (e BookingRapat) GetUID() string {
+	id := e.ID
+	if e.ParentID != nil {
+		id = *e.ParentID
+	}
+	return fmt.Sprintf("bookingrapat-%d@its-vo", id)
+}
+
+func (e BookingRapat) GetID() uint {
+	return e.ID
+}
+
+func (e *BookingRapat) SetID(id uint) {
+	e.ID = id
+}
+
+func (e *BookingRapat) SetTimes(start, end time.Time) {
+	e.Start, e.End = helper.NewEventTime(start, e.AllDay), helper.NewEventTime(end, e.AllDay)
+}
+
+// WithOccurrence mengembalikan salinan event untuk satu kejadian hasil ekspansi RRULE
+func (e BookingRapat) WithOccurrence(occurrence helper.Occurrence) BookingRapat {
+	e.Start = helper.NewEventTime(occurrence.Start, e.AllDay)
+	e.End = helper.NewEventTime(occurrence.End, e.AllDay)
+	e.OccurrenceStart = occurrence.Key
+	return e
+}
+
+// BookingRapatHistory mencatat setiap perubahan status booking rapat
+type BookingRapatHistory struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	BookingID  uint      `gorm:"index" json:"booking_id"`
+	Action     string    `json:"action"` // create, import, update, approve, reject, cancel, withdraw, auto_reject
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Actor      string    `json:"actor"` // Username dari token, "system" untuk perubahan otomatis
+	ActorID    uint      `json:"actor_id,omitempty"`
+	Comment    string    `json:"comment,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (BookingRapatHistory) TableName() string {
+	return "booking_rapat_histories"
 }
 
 type JadwalRapat struct {
-	ID     uint   `gorm:"primaryKey" json:"id"`
-	Title  string `json:"title"`
-	Start  string `json:"start"`
-	End    string `json:"end"`
-	AllDay bool   `json:"allDay"`
-	Color  string `json:"color"`
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	Title     string           `json:"title"`
+	Start     helper.EventTime `json:"start"`
+	End       helper.EventTime `json:"end"`
+	AllDay    bool             `json:"allDay"`
+	Color     string           `json:"color"`
+	ImportUID string           `gorm:"index" json:"import_uid,omitempty"` // UID event dari import .ics, untuk de-duplikasi
+	CreateBy  string           `gorm:"index" json:"create_by"`            // Username pembuat, diisi dari token
+	helper.Recurrence
 }
 
 func (JadwalRapat) TableName() string {
 	return "jadwal_rapats"
 }
 
+// AfterFind mengembalikan format JSON Start/End sesuai AllDay
+func (e *JadwalRapat) AfterFind(tx *gorm.DB) error {
+	e.Start.SetAllDay(e.AllDay)
+	e.End.SetAllDay(e.AllDay)
+	return nil
+}
+
 func (e JadwalRapat) GetTitle() string {
 	return e.Title
 }
 
 func (e JadwalRapat) GetStart() time.Time {
-	if e.AllDay {
-		t, _ := time.Parse("2006-01-02", e.Start)
-		return t
-	} else {
-		t, _ := time.Parse(time.RFC3339, e.Start) // Tambahkan penanganan error yang sesuai
-		return t
-	}
+	return e.Start.Time
 }
 
 func (e JadwalRapat) GetEnd() time.Time {
-	if e.AllDay {
-		t, _ := time.Parse("2006-01-02", e.End)
-		return t
-	} else {
-		t, _ := time.Parse(time.RFC3339, e.End) // Tambahkan penanganan error yang sesuai
-		return t
-	}
+	return e.End.Time
 }
 
 func (e JadwalRapat) GetColor() string {
@@ -169,41 +258,67 @@ func (e JadwalRapat) GetResourceID() uint {
 	return 0
 }
 
+// GetUID memakai ID master untuk override agar klien kalender mencocokkannya lewat RECURRENCE-ID
+func (e JadwalRapat) GetUID() string {
+	id := e.ID
+	if e.ParentID != nil {
+		id = *e.ParentID
+	}
+	return fmt.Sprintf("jadwalrapat-%d@its-vo", id)
+}
+
+func (e JadwalRapat) GetID() uint {
+	return e.ID
+}
+
+func (e *JadwalRapat) SetID(id uint) {
+	e.ID = id
+}
+
+func (e *JadwalRapat) SetTimes(start, end time.Time) {
+	e.Start, e.End = helper.NewEventTime(start, e.AllDay), helper.NewEventTime(end, e.AllDay)
+}
+
+// WithOccurrence mengembalikan salinan event untuk satu kejadian hasil ekspansi RRULE
+func (e JadwalRapat) WithOccurrence(occurrence helper.Occurrence) JadwalRapat {
+	e.Start = helper.NewEventTime(occurrence.Start, e.AllDay)
+	e.End = helper.NewEventTime(occurrence.End, e.AllDay)
+	e.OccurrenceStart = occurrence.Key
+	return e
+}
+
 type JadwalCuti struct {
-	ID     uint   `gorm:"primaryKey" json:"id"`
-	Title  string `json:"title"`
-	Start  string `json:"start"`
-	End    string `json:"end"`
-	AllDay bool   `json:"allDay"`
-	Color  string `json:"color"` // Tambahkan field ini untuk warna
+	ID     uint             `gorm:"primaryKey" json:"id"`
+	Title  string           `json:"title"`
+	Start  helper.EventTime `json:"start"`
+	End    helper.EventTime `json:"end"`
+	AllDay bool             `json:"allDay"`
+	Color  string           `json:"color"` // Tambahkan field ini untuk warna
+
+	LeaveRequestID *uint `gorm:"index" json:"leave_request_id,omitempty"` // Diisi jika dibuat dari pengajuan cuti yang disetujui
 }
 
 func (e JadwalCuti) TableName() string {
 	return "jadwal_cutis"
 }
 
+// AfterFind mengembalikan format JSON Start/End sesuai AllDay
+func (e *JadwalCuti) AfterFind(tx *gorm.DB) error {
+	e.Start.SetAllDay(e.AllDay)
+	e.End.SetAllDay(e.AllDay)
+	return nil
+}
+
 func (e JadwalCuti) GetTitle() string {
 	return e.Title
 }
 
 func (e JadwalCuti) GetStart() time.Time {
-	if e.AllDay {
-		t, _ := time.Parse("2006-01-02", e.Start)
-		return t
-	} else {
-		t, _ := time.Parse(time.RFC3339, e.Start) // Tambahkan penanganan error yang sesuai
-		return t
-	}
+	return e.Start.Time
 }
 
 func (e JadwalCuti) GetEnd() time.Time {
-	if e.AllDay {
-		t, _ := time.Parse("2006-01-02", e.End)
-		return t
-	} else {
-		t, _ := time.Parse(time.RFC3339, e.End) // Tambahkan penanganan error yang sesuai
-		return t
-	}
+	return e.End.Time
 }
 
 func (e JadwalCuti) GetColor() string {
@@ -218,41 +333,41 @@ func (e JadwalCuti) GetResourceID() uint {
 	return 0
 }
 
+func (e JadwalCuti) GetUID() string {
+	return fmt.Sprintf("jadwalcuti-%d@its-vo", e.ID)
+}
+
 type TimelineDesktop struct {
-	ID         uint   `gorm:"primaryKey" json:"id"`
-	Start      string `json:"start"`
-	End        string `json:"end"`
-	Title      string `json:"title"`
-	Color      string `json:"color"`
-	AllDay     bool   `json:"allDay"`
+	ID     uint             `gorm:"primaryKey" json:"id"`
+	Start  helper.EventTime `json:"start"`
+	End    helper.EventTime `json:"end"`
+	Title  string           `json:"title"`
+	Color  string           `json:"color"`
+	AllDay bool             `json:"allDay"`
+	helper.Recurrence
 }
 
 func (TimelineDesktop) TableName() string {
 	return "timeline_desktops"
 }
 
+// AfterFind mengembalikan format JSON Start/End sesuai AllDay
+func (e *TimelineDesktop) AfterFind(tx *gorm.DB) error {
+	e.Start.SetAllDay(e.AllDay)
+	e.End.SetAllDay(e.AllDay)
+	return nil
+}
+
 func (e TimelineDesktop) GetTitle() string {
 	return e.Title
 }
 
 func (e TimelineDesktop) GetStart() time.Time {
-	if e.AllDay {
-		t, _ := time.Parse("2006-01-02", e.Start)
-		return t
-	} else {
-		t, _ := time.Parse(time.RFC3339, e.Start) // Tambahkan penanganan error yang sesuai
-		return t
-	}
+	return e.Start.Time
 }
 
 func (e TimelineDesktop) GetEnd() time.Time {
-	if e.AllDay {
-		t, _ := time.Parse("2006-01-02", e.End)
-		return t
-	} else {
-		t, _ := time.Parse(time.RFC3339, e.End) // Tambahkan penanganan error yang sesuai
-		return t
-	}
+	return e.End.Time
 }
 
 func (e TimelineDesktop) GetColor() string {
@@ -263,6 +378,35 @@ func (e TimelineDesktop) GetResourceID() uint {
 	return 0
 }
 
+// GetUID memakai ID master untuk override agar klien kalender mencocokkannya lewat RECURRENCE-ID
+func (e TimelineDesktop) GetUID() string {
+	id := e.ID
+	if e.ParentID != nil {
+		id = *e.ParentID
+	}
+	return fmt.Sprintf("timelinedesktop-%d@its-vo", id)
+}
+
+func (e TimelineDesktop) GetID() uint {
+	return e.ID
+}
+
+func (e *TimelineDesktop) SetID(id uint) {
+	e.ID = id
+}
+
+func (e *TimelineDesktop) SetTimes(start, end time.Time) {
+	e.Start, e.End = helper.NewEventTime(start, e.AllDay), helper.NewEventTime(end, e.AllDay)
+}
+
+// WithOccurrence mengembalikan salinan event untuk satu kejadian hasil ekspansi RRULE
+func (e TimelineDesktop) WithOccurrence(occurrence helper.Occurrence) TimelineDesktop {
+	e.Start = helper.NewEventTime(occurrence.Start, e.AllDay)
+	e.End = helper.NewEventTime(occurrence.End, e.AllDay)
+	e.OccurrenceStart = occurrence.Key
+	return e
+}
+
 func (e TimelineDesktop) GetAllDay() bool {
 	return e.AllDay
 }
@@ -285,3 +429,80 @@ type Notification struct {
 	Start    time.Time `json:"start"`
 	Category string    `json:"category"`
 }
+
+// Jenis cuti bawaan, dibuat oleh migrate
+const (
+	LeaveAnnual  = "annual"
+	LeaveSick    = "sick"
+	LeaveSpecial = "special"
+)
+
+// Status pengajuan cuti
+const (
+	LeavePending   = "pending"
+	LeaveApproved  = "approved"
+	LeaveRejected  = "rejected"
+	LeaveCancelled = "cancelled"
+)
+
+// LeaveActiveStatuses adalah status pengajuan yang memakai tanggal dan saldo cuti
+var LeaveActiveStatuses = []string{LeavePending, LeaveApproved}
+
+// LeaveType adalah jenis cuti. Hanya jenis dengan DeductBalance yang mengurangi saldo tahunan.
+type LeaveType struct {
+	Code          string `gorm:"primaryKey" json:"code"`
+	Name          string `json:"name"`
+	DeductBalance bool   `json:"deduct_balance"`
+	MaxDays       int    `json:"max_days"` // Batas hari kerja per pengajuan, 0 berarti tanpa batas
+	Color         string `json:"color"`    // Warna entri di kalender cuti
+}
+
+// LeaveBalance adalah saldo cuti tahunan seorang pengguna, dibuat saat pertama kali dipakai
+type LeaveBalance struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Username    string `gorm:"uniqueIndex:idx_leave_balance_user_year" json:"username"`
+	Year        int    `gorm:"uniqueIndex:idx_leave_balance_user_year" json:"year"`
+	Entitlement int    `json:"entitlement"`  // Jatah cuti tahun berjalan
+	CarriedOver int    `json:"carried_over"` // Sisa tahun sebelumnya yang dibawa
+	UpdateBy    string `json:"update_by"`
+}
+
+// LeaveSupervisor menentukan atasan yang menyetujui cuti seorang pengguna
+type LeaveSupervisor struct {
+	Username   string `gorm:"primaryKey" json:"username"`
+	Supervisor string `gorm:"index" json:"supervisor"`
+	UpdateBy   string `json:"update_by"`
+}
+
+// LeaveRequest adalah pengajuan cuti. Setelah disetujui dibuat entri JadwalCuti agar tampil di kalender cuti.
+type LeaveRequest struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	Username     string     `gorm:"index" json:"username"`
+	UserID       uint       `json:"user_id"`
+	Type         string     `gorm:"index" json:"type"`
+	StartDate    time.Time  `gorm:"type:date" json:"start_date"`
+	EndDate      time.Time  `gorm:"type:date" json:"end_date"` // Inklusif
+	Days         int        `json:"days"`                      // Hari kerja, tanpa akhir pekan dan hari libur
+	Reason       string     `json:"reason"`
+	Status       string     `gorm:"index" json:"status"`
+	Supervisor   string     `gorm:"index" json:"supervisor"` // Kosong berarti disetujui admin
+	DecidedBy    string     `json:"decided_by,omitempty"`
+	DecidedAt    *time.Time `json:"decided_at,omitempty"`
+	DecisionNote string     `json:"decision_note,omitempty"`
+	JadwalCutiID *uint      `json:"jadwal_cuti_id,omitempty"`
+}
+
+// MarshalJSON menampilkan StartDate dan EndDate sebagai tanggal tanpa jam
+func (r LeaveRequest) MarshalJSON() ([]byte, error) {
+	type Alias LeaveRequest
+	return json.Marshal(&struct {
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date"`
+		Alias
+	}{
+		StartDate: r.StartDate.Format(helper.EventDateLayout),
+		EndDate:   r.EndDate.Format(helper.EventDateLayout),
+		Alias:     Alias(r),
+	})
+}
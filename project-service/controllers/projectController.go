@@ -49,6 +49,23 @@ func DownloadFileHandlerProject(c *gin.Context) {
 	helper.DownloadFileHandler(c, "/app/UploadedFile/project")
 }
 
+func DownloadZipHandlerProject(c *gin.Context) {
+	var record models.Project
+	helper.DownloadZipByID(c, initializers.DB, "project.projects", "/app/UploadedFile/project", &record, "project")
+}
+
+func DownloadZipFilterHandlerProject(c *gin.Context) {
+	helper.DownloadZipByFilter[models.Project](c, initializers.DB, "project.projects", "/app/UploadedFile/project", helper.ZipFilterConfig{
+		DateField:   "bulan",
+		NumberField: "kode_project",
+	}, "project")
+}
+
+func CreateShareLinkProject(c *gin.Context) {
+	var record models.Project
+	helper.CreateShareLink(c, initializers.DB, "project.projects", "/app/UploadedFile/project", &record, "project")
+}
+
 func ProjectCreate(c *gin.Context) {
 	var requestBody ProjectRequest
 
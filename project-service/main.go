@@ -6,6 +6,7 @@ import (
 
 	"github.com/arkaramadhan/its-vo/common/initializers"
 	"github.com/arkaramadhan/its-vo/common/middleware"
+	"github.com/arkaramadhan/its-vo/common/utils"
 	exportAll "github.com/arkaramadhan/its-vo/common/exportAll"
 	"github.com/arkaramadhan/its-vo/project-service/controllers"
 	"github.com/gin-contrib/sessions"
@@ -35,6 +36,13 @@ func main() {
 
 	r.Use(middleware.CORS())
 
+	utils.RegisterDomainEvents(initializers.DB, "project")
+	utils.StartWebhookWorker()
+
+	// ********** Public Share Link ********** //
+	r.GET("/share/:token", utils.DownloadShareLink)
+	r.POST("/share/:token", utils.DownloadShareLink)
+
 	// ********** Middleware ********** //
 	r.Use(middleware.TokenAuthMiddleware())
 	store := cookie.NewStore([]byte("secret"))
@@ -53,6 +61,48 @@ func main() {
 	r.GET("/downloadProject/:id/:filename", controllers.DownloadFileHandlerProject)
 	r.DELETE("/deleteProject/:id/:filename", controllers.DeleteFileHandlerProject)
 	r.GET("/filesProject/:id", controllers.GetFilesByIDProject)
+	r.GET("/zipProject", controllers.DownloadZipFilterHandlerProject)
+	r.GET("/zipProject/:id", controllers.DownloadZipHandlerProject)
+	r.POST("/shareProject/:id", middleware.RequireRole("admin"), controllers.CreateShareLinkProject)
+
+	// ********** Route Share Link ********** //
+	r.GET("/shareLinks", middleware.RequireRole("admin"), utils.ShareLinkIndex)
+	r.DELETE("/shareLinks/:id", middleware.RequireRole("admin"), utils.RevokeShareLink)
+	r.GET("/shareLinks/:id/access", middleware.RequireRole("admin"), utils.GetShareLinkAccessLog)
+
+	// ********** Route Storage ********** //
+	r.GET("/storage/reconcile", middleware.RequireRole("admin"), utils.ReconcileStorageHandler)
+	r.POST("/storage/reconcile", middleware.RequireRole("admin"), utils.ReconcileStorageHandler)
+	r.GET("/storage/usage", middleware.RequireRole("admin"), utils.StorageUsageHandler)
+	r.GET("/storage/usage/export", middleware.RequireRole("admin"), utils.ExportStorageUsageHandler)
+	r.GET("/storage/quotas", middleware.RequireRole("admin"), utils.StorageQuotaIndex)
+	r.PUT("/storage/quotas/:resource", middleware.RequireRole("admin"), utils.UpsertStorageQuota)
+	r.DELETE("/storage/quotas/:resource", middleware.RequireRole("admin"), utils.DeleteStorageQuota)
+
+	// ********** Route Quarantine ********** //
+	r.GET("/quarantine", middleware.RequireRole("admin"), utils.QuarantineIndex)
+	r.POST("/quarantine/scan", middleware.RequireRole("admin"), utils.ScanPendingFiles)
+	r.POST("/quarantine/:id/rescan", middleware.RequireRole("admin"), utils.RescanFile)
+	r.POST("/quarantine/:id/release", middleware.RequireRole("admin"), utils.ReleaseQuarantinedFile)
+	r.DELETE("/quarantine/:id", middleware.RequireRole("admin"), utils.DeleteQuarantinedFile)
+
+	// ********** Route Hari Libur ********** //
+	r.GET("/holidays", utils.GetHolidays)
+	r.POST("/holidays", middleware.RequireRole("admin"), utils.CreateHoliday)
+	r.PUT("/holidays/:id", middleware.RequireRole("admin"), utils.UpdateHoliday)
+	r.DELETE("/holidays/:id", middleware.RequireRole("admin"), utils.DeleteHoliday)
+	r.POST("/holidays/import", middleware.RequireRole("admin"), utils.ImportHolidays)
+	r.GET("/working-days", utils.WorkingDaysHandler)
+	r.GET("/working-days/add", utils.AddWorkingDaysHandler)
+
+	// ********** Route Webhook ********** //
+	r.GET("/webhooks", middleware.RequireRole("admin"), utils.WebhookIndex)
+	r.POST("/webhooks", middleware.RequireRole("admin"), utils.CreateWebhook)
+	r.PUT("/webhooks/:id", middleware.RequireRole("admin"), utils.UpdateWebhook)
+	r.DELETE("/webhooks/:id", middleware.RequireRole("admin"), utils.DeleteWebhook)
+	r.POST("/webhooks/:id/ping", middleware.RequireRole("admin"), utils.PingWebhook)
+	r.GET("/webhooks/:id/deliveries", middleware.RequireRole("admin"), utils.WebhookDeliveryIndex)
+	r.POST("/webhook-deliveries/:id/redeliver", middleware.RequireRole("admin"), utils.RedeliverWebhook)
 
 	r.GET("/exportAll", exportAll.ExportAll)
 
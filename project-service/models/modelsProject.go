@@ -105,3 +105,11 @@ func (p *Project) GetDocType() string {
 	}
 	return "ISO"
 }
+
+func (p *Project) GetID() uint {
+	return p.ID
+}
+
+func (p *Project) GetDocNumber() string {
+	return helper.GetValue(p.KodeProject)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/arkaramadhan/its-vo/common/initializers"
 	"github.com/arkaramadhan/its-vo/common/middleware"
+	"github.com/arkaramadhan/its-vo/common/utils"
 	"github.com/arkaramadhan/its-vo/user-service/controllers"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
@@ -34,6 +35,9 @@ func main() {
 
 	r.Use(middleware.CORS())
 
+	utils.RegisterDomainEvents(initializers.DB, "user")
+	utils.StartWebhookWorker()
+
 	r.POST("/login", controllers.Login)
 
 	r.Use(middleware.TokenAuthMiddleware())
@@ -48,5 +52,23 @@ func main() {
 
 	r.POST("/logout", controllers.Logout)
 
+	// ********** Route Hari Libur ********** //
+	r.GET("/holidays", utils.GetHolidays)
+	r.POST("/holidays", middleware.RequireRole("admin"), utils.CreateHoliday)
+	r.PUT("/holidays/:id", middleware.RequireRole("admin"), utils.UpdateHoliday)
+	r.DELETE("/holidays/:id", middleware.RequireRole("admin"), utils.DeleteHoliday)
+	r.POST("/holidays/import", middleware.RequireRole("admin"), utils.ImportHolidays)
+	r.GET("/working-days", utils.WorkingDaysHandler)
+	r.GET("/working-days/add", utils.AddWorkingDaysHandler)
+
+	// ********** Route Webhook ********** //
+	r.GET("/webhooks", middleware.RequireRole("admin"), utils.WebhookIndex)
+	r.POST("/webhooks", middleware.RequireRole("admin"), utils.CreateWebhook)
+	r.PUT("/webhooks/:id", middleware.RequireRole("admin"), utils.UpdateWebhook)
+	r.DELETE("/webhooks/:id", middleware.RequireRole("admin"), utils.DeleteWebhook)
+	r.POST("/webhooks/:id/ping", middleware.RequireRole("admin"), utils.PingWebhook)
+	r.GET("/webhooks/:id/deliveries", middleware.RequireRole("admin"), utils.WebhookDeliveryIndex)
+	r.POST("/webhook-deliveries/:id/redeliver", middleware.RequireRole("admin"), utils.RedeliverWebhook)
+
 	r.Run(":8084")
 }
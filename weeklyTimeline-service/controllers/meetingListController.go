@@ -334,3 +334,55 @@ func ImportExcelMeetingList(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Data berhasil diimport"})
 }
+
+func ExportMeetingListICS(c *gin.Context) {
+	var meetings []models.MeetingSchedule
+	if err := initializers.DB.Table("weekly_timeline.meeting_schedules").Where("tanggal IS NOT NULL").Find(&meetings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	var icsEvents []helper.ICSEvent
+	for i := range meetings {
+		icsEvents = append(icsEvents, &meetings[i])
+	}
+	helper.ServeICS(c, "meeting_schedule", "Meeting Schedule", icsEvents)
+}
+
+// meetingListImport mengimport event .ics ke meeting schedule. Waktu dan Selesai diisi jam WIB,
+// event sepanjang hari dibiarkan tanpa jam.
+var meetingListImport = helper.ICSImportTarget{
+	Name: "meetingSchedule",
+	Exists: func(uid string) (bool, error) {
+		var count int64
+		err := initializers.DB.Table("weekly_timeline.meeting_schedules").Where("import_uid = ?", uid).Count(&count).Error
+		return count > 0, err
+	},
+	Save: func(c *gin.Context, event helper.ICSImportEvent) (string, error) {
+		tanggal := time.Date(event.Start.Year(), event.Start.Month(), event.Start.Day(), 0, 0, 0, 0, time.UTC)
+		hari := hariIndonesia(event.Start.Weekday().String())
+		meeting := models.MeetingSchedule{
+			Hari:      &hari,
+			Tanggal:   &tanggal,
+			Perihal:   &event.Title,
+			CreateBy:  c.MustGet("username").(string),
+			ImportUID: event.UID,
+		}
+		if !event.AllDay {
+			waktu, selesai := event.Start.Format("15:04"), event.End.Format("15:04")
+			meeting.Waktu, meeting.Selesai = &waktu, &selesai
+		}
+		if event.Location != "" {
+			meeting.Tempat = &event.Location
+		}
+		return "", initializers.DB.Table("weekly_timeline.meeting_schedules").Create(&meeting).Error
+	},
+}
+
+func PreviewICSMeetingList(c *gin.Context) {
+	helper.PreviewICSImport(c, meetingListImport)
+}
+
+func ConfirmICSMeetingList(c *gin.Context) {
+	helper.ConfirmICSImport(c, meetingListImport)
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arkaramadhan/its-vo/common/initializers"
+	helper "github.com/arkaramadhan/its-vo/common/utils"
+	"github.com/arkaramadhan/its-vo/weeklyTimeline-service/models"
+	"gorm.io/gorm"
+)
+
+// RegisterNotificationSources mendaftarkan pembaca event timeline project agar worker pengingat bisa
+// memeriksa perubahan event sebelum mengirim dan tahu penerimanya
+func RegisterNotificationSources() {
+	helper.RegisterNotificationSource("TimelineProject", func(id uint, after time.Time) (helper.NotificationSource, bool, error) {
+		var event models.TimelineProject
+		if err := initializers.DB.Table("weekly_timeline.timeline_projects").First(&event, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return helper.NotificationSource{}, false, nil
+			}
+			return helper.NotificationSource{}, false, err
+		}
+		source := helper.NotificationSource{Title: event.Title, Start: event.GetStart()}
+		// Nama resource diperlakukan seperti PIC, dicocokkan ke username
+		var resource models.ResourceProject
+		if err := initializers.DB.Table("weekly_timeline.resource_projects").First(&resource, event.ResourceId).Error; err == nil {
+			source.Recipients = helper.SplitRecipients(resource.Name)
+			source.Data = map[string]string{"resource": resource.Name}
+		}
+		return source, true, nil
+	})
+}
@@ -4,7 +4,6 @@ import (
 	"log"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/arkaramadhan/its-vo/common/initializers"
 	helper "github.com/arkaramadhan/its-vo/common/utils"
@@ -27,28 +26,19 @@ func CreateEventProject(c *gin.Context) {
 		return
 	}
 
-	// Parsing waktu untuk notifikasi
-	loc, err := time.LoadLocation("Asia/Jakarta")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error loading location"})
+	if event.Start.IsZero() || event.End.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "start dan end harus diisi"})
 		return
 	}
 
-	// Ubah format parsing sesuai dengan format yang diterima
-	startTime, err := time.ParseInLocation("2006-01-02 15:04:05", event.Start, loc) // Ubah format di sini
-	if err != nil {
-		log.Printf("Error parsing start time: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Error parsing start time"})
-		return
-	}
-
-	// Panggil fungsi SetNotification
-	helper.SetNotification(event.Title, startTime, "TimelineProject")
-
 	if err := initializers.DB.Create(&event).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+
+	// Panggil fungsi SetNotification
+	helper.SetNotification(event.Title, event.GetStart(), "TimelineProject", event.ID)
+	helper.PublishCalendarChange("TimelineProject", "created", event.ID)
 	c.JSON(http.StatusOK, event)
 }
 
@@ -71,6 +61,8 @@ func DeleteEventProject(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"messag": err.Error()})
 		return
 	}
+	helper.SyncNotifications("TimelineProject", uint(id))
+	helper.PublishCalendarChange("TimelineProject", "deleted", uint(id))
 	c.Status(http.StatusNoContent)
 }
 
@@ -148,9 +140,10 @@ func ExportTimelineProjectToExcel(c *gin.Context, f *excelize.File, sheetName st
 		return err
 	}
 
-	resourceMap := make(map[uint]string)
+	// Resource induk dan anak ditampilkan berurutan sesuai ParentID
+	var ganttResources []helper.GanttResource
 	for _, resource := range resources {
-		resourceMap[resource.ID] = resource.Name
+		ganttResources = append(ganttResources, helper.GanttResource{ID: resource.ID, Name: resource.Name, ParentID: resource.ParentID})
 	}
 
 	var excelEvents []helper.ExcelEvent
@@ -158,19 +151,47 @@ func ExportTimelineProjectToExcel(c *gin.Context, f *excelize.File, sheetName st
 		excelEvents = append(excelEvents, event) // Pastikan `event` adalah tipe yang mengimplementasikan `ExcelEvent`
 	}
 
-	config := helper.CalenderConfig{
+	// Periode export dari query year, start_month/end_month, atau start/end
+	period, err := helper.ParseCalendarPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return err
+	}
+
+	// Skala kolom: day, week, atau kosong untuk otomatis
+	scale, err := helper.ParseGanttScale(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return err
+	}
+
+	config := helper.GanttConfig{
 		SheetName:   "TIMELINE PROJECT",
 		FileName:    "its_report_timelineProject.xlsx",
 		Events:      excelEvents,
-		UseResource: true,
-		ResourceMap: resourceMap,
-		RowOffset:   0,
-		ColOffset:   0,
+		Resources:   ganttResources,
+		Period:      period,
+		Scale:       scale,
+		ShowSummary: c.Query("summary") == "true",
 	}
 
 	if f != nil {
-		return helper.ExportCalenderToSheet(f, config)
+		return helper.ExportGanttToSheet(f, config)
 	} else {
-		return helper.ExportCalenderToExcel(c, config)
+		return helper.ExportGanttToExcel(c, config)
+	}
+}
+
+func ExportTimelineProjectICS(c *gin.Context) {
+	var events []models.TimelineProject
+	if err := initializers.DB.Table("weekly_timeline.timeline_projects").Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
 	}
-}
\ No newline at end of file
+
+	var icsEvents []helper.ICSEvent
+	for _, event := range events {
+		icsEvents = append(icsEvents, event)
+	}
+	helper.ServeICS(c, "timeline_project", "Timeline Project", icsEvents)
+}
@@ -36,6 +36,17 @@ func main() {
 
 	r.Use(middleware.CORS())
 
+	utils.RegisterDomainEvents(initializers.DB, "weekly_timeline")
+	utils.StartWebhookWorker()
+
+	controllers.RegisterNotificationSources()
+	utils.StartNotificationWorker()
+	utils.StartRealtimeListener()
+
+	// ********** Route iCalendar Feed ********** //
+	r.GET("/ics/:token/timelineProject.ics", utils.CalendarFeedAuth(), controllers.ExportTimelineProjectICS)
+	r.GET("/ics/:token/meetingSchedule.ics", utils.CalendarFeedAuth(), controllers.ExportMeetingListICS)
+
 	// ********** Middleware ********** //
 	r.Use(middleware.TokenAuthMiddleware())
 	store := cookie.NewStore([]byte("secret"))
@@ -48,7 +59,10 @@ func main() {
 	r.PUT("/meetingSchedule/:id", controllers.MeetingListUpdate)
 	r.DELETE("/meetingSchedule/:id", controllers.MeetingListDelete)
 	r.GET("/exportMeetingList", controllers.CreateExcelMeetingList)
+	r.GET("/icsMeetingList", controllers.ExportMeetingListICS)
 	r.POST("/uploadMeetingList", controllers.ImportExcelMeetingList)
+	r.POST("/importIcsMeetingList/preview", controllers.PreviewICSMeetingList)
+	r.POST("/importIcsMeetingList/:id/confirm", controllers.ConfirmICSMeetingList)
 
 	r.POST("/uploadFileMeetingList", controllers.UploadHandlerMeetingList)
 	r.GET("/downloadMeetingList/:id/:filename", controllers.DownloadFileHandlerMeetingList)
@@ -63,9 +77,61 @@ func main() {
 	r.POST("/resourceProject", controllers.CreateResourceProject)
 	r.DELETE("/resourceProject/:id", controllers.DeleteResourceProject)
 	r.GET("/exportTimelineProject", controllers.ExportTimelineProjectHandler)
+	r.GET("/icsTimelineProject", controllers.ExportTimelineProjectICS)
 
 	r.GET("/notifications", utils.GetNotifications)
+	r.GET("/notifications/unread-count", utils.GetUnreadNotificationCount)
+	r.POST("/notifications/read-all", utils.MarkAllNotificationsRead)
+	r.POST("/notifications/:id/read", utils.MarkNotificationRead)
+	r.POST("/notifications/:id/unread", utils.MarkNotificationUnread)
 	r.DELETE("/notifications/:id", utils.DeleteNotification)
+	r.GET("/notification-preferences", utils.GetNotificationPreferences)
+	r.PUT("/notification-preferences", utils.UpdateNotificationPreferences)
+	r.GET("/notification-jobs", middleware.RequireRole("admin"), utils.NotificationJobIndex)
+	r.POST("/notification-jobs/:id/retry", middleware.RequireRole("admin"), utils.RetryNotificationJob)
+	r.POST("/notification-jobs/test-email", middleware.RequireRole("admin"), utils.SendTestEmail)
+
+	// ********** Route Realtime ********** //
+	r.GET("/events/stream", utils.StreamEvents)
+
+	// ********** Route Calendar Feed Token ********** //
+	r.GET("/calendarFeed", utils.GetCalendarFeedToken)
+	r.POST("/calendarFeed", utils.CreateCalendarFeedToken)
+	r.DELETE("/calendarFeed", utils.RevokeCalendarFeedToken)
+
+	// ********** Route Storage ********** //
+	r.GET("/storage/reconcile", middleware.RequireRole("admin"), utils.ReconcileStorageHandler)
+	r.POST("/storage/reconcile", middleware.RequireRole("admin"), utils.ReconcileStorageHandler)
+	r.GET("/storage/usage", middleware.RequireRole("admin"), utils.StorageUsageHandler)
+	r.GET("/storage/usage/export", middleware.RequireRole("admin"), utils.ExportStorageUsageHandler)
+	r.GET("/storage/quotas", middleware.RequireRole("admin"), utils.StorageQuotaIndex)
+	r.PUT("/storage/quotas/:resource", middleware.RequireRole("admin"), utils.UpsertStorageQuota)
+	r.DELETE("/storage/quotas/:resource", middleware.RequireRole("admin"), utils.DeleteStorageQuota)
+
+	// ********** Route Quarantine ********** //
+	r.GET("/quarantine", middleware.RequireRole("admin"), utils.QuarantineIndex)
+	r.POST("/quarantine/scan", middleware.RequireRole("admin"), utils.ScanPendingFiles)
+	r.POST("/quarantine/:id/rescan", middleware.RequireRole("admin"), utils.RescanFile)
+	r.POST("/quarantine/:id/release", middleware.RequireRole("admin"), utils.ReleaseQuarantinedFile)
+	r.DELETE("/quarantine/:id", middleware.RequireRole("admin"), utils.DeleteQuarantinedFile)
+
+	// ********** Route Hari Libur ********** //
+	r.GET("/holidays", utils.GetHolidays)
+	r.POST("/holidays", middleware.RequireRole("admin"), utils.CreateHoliday)
+	r.PUT("/holidays/:id", middleware.RequireRole("admin"), utils.UpdateHoliday)
+	r.DELETE("/holidays/:id", middleware.RequireRole("admin"), utils.DeleteHoliday)
+	r.POST("/holidays/import", middleware.RequireRole("admin"), utils.ImportHolidays)
+	r.GET("/working-days", utils.WorkingDaysHandler)
+	r.GET("/working-days/add", utils.AddWorkingDaysHandler)
+
+	// ********** Route Webhook ********** //
+	r.GET("/webhooks", middleware.RequireRole("admin"), utils.WebhookIndex)
+	r.POST("/webhooks", middleware.RequireRole("admin"), utils.CreateWebhook)
+	r.PUT("/webhooks/:id", middleware.RequireRole("admin"), utils.UpdateWebhook)
+	r.DELETE("/webhooks/:id", middleware.RequireRole("admin"), utils.DeleteWebhook)
+	r.POST("/webhooks/:id/ping", middleware.RequireRole("admin"), utils.PingWebhook)
+	r.GET("/webhooks/:id/deliveries", middleware.RequireRole("admin"), utils.WebhookDeliveryIndex)
+	r.POST("/webhook-deliveries/:id/redeliver", middleware.RequireRole("admin"), utils.RedeliverWebhook)
 
 	r.GET("/exportAll", exportAll.ExportAll)
 
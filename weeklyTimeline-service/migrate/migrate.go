@@ -1,7 +1,10 @@
 package main
 
 import (
+	"log"
+
 	"github.com/arkaramadhan/its-vo/common/initializers"
+	helper "github.com/arkaramadhan/its-vo/common/utils"
 	"github.com/arkaramadhan/its-vo/weeklyTimeline-service/models"
 )
 
@@ -15,6 +18,22 @@ func init() {
 
 func main() {
 
+	// Start/End kalender berpindah dari teks ke timestamptz, data lama dikonversi sebelum AutoMigrate
+	var failures []helper.EventTimeMigrationFailure
+	for _, table := range []string{"timeline_projects"} {
+		result, err := helper.MigrateEventTimeColumns(initializers.DB, table, "start", "end")
+		if err != nil {
+			log.Fatalf("Migrasi kolom waktu %s gagal: %v", table, err)
+		}
+		failures = append(failures, result...)
+	}
+	if len(failures) > 0 {
+		log.Printf("%d nilai waktu tidak bisa dikonversi, periksa kolom *_legacy:", len(failures))
+		for _, failure := range failures {
+			log.Printf("- %s id %d kolom %s: %q", failure.Table, failure.ID, failure.Column, failure.Value)
+		}
+	}
+
 	initializers.DB.AutoMigrate(
 		&models.MeetingSchedule{},
 		&models.ResourceProject{},
@@ -2,25 +2,35 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	helper "github.com/arkaramadhan/its-vo/common/utils"
+	"gorm.io/gorm"
 
 )
 
 type TimelineProject struct {
-	ID         uint   `gorm:"primaryKey" json:"id"`
-	Start      string `json:"start"`
-	End        string `json:"end"`
-	ResourceId int    `json:"resourceId"` // Ubah tipe data dari string ke int
-	Title      string `json:"title"`
-	BgColor    string `json:"bgColor"`
+	ID         uint             `gorm:"primaryKey" json:"id"`
+	Start      helper.EventTime `json:"start"`
+	End        helper.EventTime `json:"end"`
+	ResourceId int              `json:"resourceId"` // Ubah tipe data dari string ke int
+	Title      string           `json:"title"`
+	BgColor    string           `json:"bgColor"`
 }
 
 func (TimelineProject) TableName() string {
 	return "timeline_projects"
 }
 
+// AfterFind mengembalikan format JSON Start/End ke "2006-01-02 15:04:05" yang dipakai frontend
+func (e *TimelineProject) AfterFind(tx *gorm.DB) error {
+	e.Start.Layout = helper.EventDateTimeLayout
+	e.End.Layout = helper.EventDateTimeLayout
+	return nil
+}
+
 type ResourceProject struct {
 	ID       uint   `gorm:"primaryKey" json:"id"`
 	Name     string `json:"name"`
@@ -32,13 +42,11 @@ func (e TimelineProject) GetTitle() string {
 }
 
 func (e TimelineProject) GetStart() time.Time {
-	t, _ := time.Parse("2006-01-02 15:04:05", e.Start) // Tambahkan penanganan error yang sesuai
-	return t
+	return e.Start.Time
 }
 
 func (e TimelineProject) GetEnd() time.Time {
-	t, _ := time.Parse("2006-01-02 15:04:05", e.End) // Tambahkan penanganan error yang sesuai
-	return t
+	return e.End.Time
 }
 
 func (e TimelineProject) GetColor() string {
@@ -53,6 +61,10 @@ func (e TimelineProject) GetAllDay() bool {
 	return false
 }
 
+func (e TimelineProject) GetUID() string {
+	return fmt.Sprintf("timelineproject-%d@its-vo", e.ID)
+}
+
 type MeetingSchedule struct {
 	ID        uint       `gorm:"primaryKey"`
 	CreatedAt *time.Time `gorm:"autoCreateTime"`
@@ -67,6 +79,7 @@ type MeetingSchedule struct {
 	Status    *string    `json:"status"`
 	CreateBy  string     `json:"create_by"`
 	Color     string     `json:"color"`
+	ImportUID string     `gorm:"index" json:"import_uid,omitempty"` // UID event dari import .ics, untuk de-duplikasi
 }
 
 func (i *MeetingSchedule) MarshalJSON() ([]byte, error) {
@@ -108,3 +121,74 @@ func (m *MeetingSchedule) GetStatus() string {
 	}
 	return *m.Status
 }
+
+// parseWaktu membaca jam rapat yang diisi bebas, misal "09:00" atau "09.00"
+func (m *MeetingSchedule) parseWaktu(waktu *string) (time.Time, bool) {
+	if m.Tanggal == nil || waktu == nil {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{"15:04", "15.04", "15:04:05"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(*waktu)); err == nil {
+			return time.Date(m.Tanggal.Year(), m.Tanggal.Month(), m.Tanggal.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (m *MeetingSchedule) GetTitle() string {
+	return helper.GetValue(m.Perihal)
+}
+
+func (m *MeetingSchedule) GetStart() time.Time {
+	if start, ok := m.parseWaktu(m.Waktu); ok {
+		return start
+	}
+	if m.Tanggal == nil {
+		return time.Time{}
+	}
+	return time.Date(m.Tanggal.Year(), m.Tanggal.Month(), m.Tanggal.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (m *MeetingSchedule) GetEnd() time.Time {
+	if m.GetAllDay() {
+		return m.GetStart().AddDate(0, 0, 1)
+	}
+	if end, ok := m.parseWaktu(m.Selesai); ok && end.After(m.GetStart()) {
+		return end
+	}
+	// Jam selesai kosong atau tidak valid, anggap rapat berlangsung satu jam
+	return m.GetStart().Add(time.Hour)
+}
+
+func (m *MeetingSchedule) GetColor() string {
+	return m.Color
+}
+
+// GetAllDay bernilai true jika jam mulai tidak bisa dibaca
+func (m *MeetingSchedule) GetAllDay() bool {
+	_, ok := m.parseWaktu(m.Waktu)
+	return !ok
+}
+
+func (m *MeetingSchedule) GetResourceID() uint {
+	return 0
+}
+
+func (m *MeetingSchedule) GetUID() string {
+	return fmt.Sprintf("meetingschedule-%d@its-vo", m.ID)
+}
+
+func (m *MeetingSchedule) GetLocation() string {
+	return helper.GetValue(m.Tempat)
+}
+
+func (m *MeetingSchedule) GetDescription() string {
+	var details []string
+	if pic := helper.GetValue(m.Pic); pic != "" {
+		details = append(details, "PIC: "+pic)
+	}
+	if status := helper.GetValue(m.Status); status != "" {
+		details = append(details, "Status: "+status)
+	}
+	return strings.Join(details, "\n")
+}